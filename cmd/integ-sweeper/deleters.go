@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/terraconstructs/base/integ/sweeper"
+)
+
+// instances are terminated before their security groups and subnets are deleted
+const instanceTerminatedTimeout = 10 * time.Minute
+
+// arnDeleter adapts a function of the parsed ARN to a sweeper.Deleter
+func arnDeleter(fn func(ctx context.Context, arn sweeper.ARN) error) sweeper.Deleter {
+	return sweeper.DeleterFunc(func(ctx context.Context, r sweeper.Resource) error {
+		arn, err := sweeper.ParseARN(r.ARN)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, arn)
+	})
+}
+
+// newDeleters returns the deleters of the resource types in sweeper.DeleteOrder
+func newDeleters(cfg aws.Config) map[string]sweeper.Deleter {
+	asgClient := autoscaling.NewFromConfig(cfg)
+	cwClient := cloudwatch.NewFromConfig(cfg)
+	logsClient := cloudwatchlogs.NewFromConfig(cfg)
+	ddbClient := dynamodb.NewFromConfig(cfg)
+	ec2Client := ec2.NewFromConfig(cfg)
+	ecsClient := ecs.NewFromConfig(cfg)
+	eventsClient := eventbridge.NewFromConfig(cfg)
+	kinesisClient := kinesis.NewFromConfig(cfg)
+	kmsClient := kms.NewFromConfig(cfg)
+	lambdaClient := lambda.NewFromConfig(cfg)
+	s3Client := s3.NewFromConfig(cfg)
+	secretsClient := secretsmanager.NewFromConfig(cfg)
+	sfnClient := sfn.NewFromConfig(cfg)
+	snsClient := sns.NewFromConfig(cfg)
+	sqsClient := sqs.NewFromConfig(cfg)
+
+	return map[string]sweeper.Deleter{
+		"events:rule": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			// rule/<name> or rule/<event bus>/<name>
+			var eventBus *string
+			name := arn.ID()
+			if bus, rule, ok := strings.Cut(name, "/"); ok {
+				eventBus, name = aws.String(bus), rule
+			}
+			targets, err := eventsClient.ListTargetsByRule(ctx, &eventbridge.ListTargetsByRuleInput{
+				Rule:         aws.String(name),
+				EventBusName: eventBus,
+			})
+			if err != nil {
+				return err
+			}
+			if len(targets.Targets) > 0 {
+				ids := make([]string, 0, len(targets.Targets))
+				for _, target := range targets.Targets {
+					ids = append(ids, aws.ToString(target.Id))
+				}
+				if _, err := eventsClient.RemoveTargets(ctx, &eventbridge.RemoveTargetsInput{
+					Rule:         aws.String(name),
+					EventBusName: eventBus,
+					Ids:          ids,
+				}); err != nil {
+					return err
+				}
+			}
+			_, err = eventsClient.DeleteRule(ctx, &eventbridge.DeleteRuleInput{
+				Name:         aws.String(name),
+				EventBusName: eventBus,
+			})
+			return err
+		}),
+		"sns:subscription": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := snsClient.Unsubscribe(ctx, &sns.UnsubscribeInput{SubscriptionArn: aws.String(arn.String())})
+			return err
+		}),
+		"cloudwatch:alarm": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := cwClient.DeleteAlarms(ctx, &cloudwatch.DeleteAlarmsInput{AlarmNames: []string{arn.ID()}})
+			return err
+		}),
+		"states:stateMachine": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := sfnClient.DeleteStateMachine(ctx, &sfn.DeleteStateMachineInput{StateMachineArn: aws.String(arn.String())})
+			return err
+		}),
+		"ecs:service": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			// service/<cluster>/<name>
+			cluster, _, _ := strings.Cut(arn.ID(), "/")
+			_, err := ecsClient.DeleteService(ctx, &ecs.DeleteServiceInput{
+				Cluster: aws.String(cluster),
+				Service: aws.String(arn.String()),
+				Force:   aws.Bool(true),
+			})
+			return err
+		}),
+		"autoscaling:autoScalingGroup": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			// autoScalingGroup:<uuid>:autoScalingGroupName/<name>
+			_, name, ok := strings.Cut(arn.ID(), "autoScalingGroupName/")
+			if !ok {
+				return fmt.Errorf("no Auto Scaling group name in %s", arn)
+			}
+			_, err := asgClient.DeleteAutoScalingGroup(ctx, &autoscaling.DeleteAutoScalingGroupInput{
+				AutoScalingGroupName: aws.String(name),
+				ForceDelete:          aws.Bool(true),
+			})
+			return err
+		}),
+		"lambda:function": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := lambdaClient.DeleteFunction(ctx, &lambda.DeleteFunctionInput{FunctionName: aws.String(arn.String())})
+			return err
+		}),
+		"ec2:instance": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			input := &ec2.DescribeInstancesInput{InstanceIds: []string{arn.ID()}}
+			if _, err := ec2Client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{InstanceIds: input.InstanceIds}); err != nil {
+				return err
+			}
+			return ec2.NewInstanceTerminatedWaiter(ec2Client).Wait(ctx, input, instanceTerminatedTimeout)
+		}),
+		"ec2:launch-template": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := ec2Client.DeleteLaunchTemplate(ctx, &ec2.DeleteLaunchTemplateInput{LaunchTemplateId: aws.String(arn.ID())})
+			return err
+		}),
+		"ecs:cluster": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := ecsClient.DeleteCluster(ctx, &ecs.DeleteClusterInput{Cluster: aws.String(arn.String())})
+			return err
+		}),
+		"sqs:queue": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			queue, err := sqsClient.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{
+				QueueName:              aws.String(arn.ID()),
+				QueueOwnerAWSAccountId: aws.String(arn.AccountID),
+			})
+			if err != nil {
+				return err
+			}
+			_, err = sqsClient.DeleteQueue(ctx, &sqs.DeleteQueueInput{QueueUrl: queue.QueueUrl})
+			return err
+		}),
+		"sns:topic": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := snsClient.DeleteTopic(ctx, &sns.DeleteTopicInput{TopicArn: aws.String(arn.String())})
+			return err
+		}),
+		"kinesis:stream": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := kinesisClient.DeleteStream(ctx, &kinesis.DeleteStreamInput{
+				StreamARN:               aws.String(arn.String()),
+				EnforceConsumerDeletion: aws.Bool(true),
+			})
+			return err
+		}),
+		"dynamodb:table": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := ddbClient.DeleteTable(ctx, &dynamodb.DeleteTableInput{TableName: aws.String(arn.ID())})
+			return err
+		}),
+		"s3:bucket": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			if err := emptyBucket(ctx, s3Client, arn.ID()); err != nil {
+				return err
+			}
+			_, err := s3Client.DeleteBucket(ctx, &s3.DeleteBucketInput{Bucket: aws.String(arn.ID())})
+			return err
+		}),
+		"secretsmanager:secret": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := secretsClient.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+				SecretId:                   aws.String(arn.String()),
+				ForceDeleteWithoutRecovery: aws.Bool(true),
+			})
+			return err
+		}),
+		"logs:log-group": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := logsClient.DeleteLogGroup(ctx, &cloudwatchlogs.DeleteLogGroupInput{
+				LogGroupName: aws.String(strings.TrimSuffix(arn.ID(), ":*")),
+			})
+			return err
+		}),
+		"ec2:natgateway": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := ec2Client.DeleteNatGateway(ctx, &ec2.DeleteNatGatewayInput{NatGatewayId: aws.String(arn.ID())})
+			return err
+		}),
+		"ec2:elastic-ip": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := ec2Client.ReleaseAddress(ctx, &ec2.ReleaseAddressInput{AllocationId: aws.String(arn.ID())})
+			return err
+		}),
+		"ec2:internet-gateway": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			gateways, err := ec2Client.DescribeInternetGateways(ctx, &ec2.DescribeInternetGatewaysInput{
+				InternetGatewayIds: []string{arn.ID()},
+			})
+			if err != nil {
+				return err
+			}
+			for _, gateway := range gateways.InternetGateways {
+				for _, attachment := range gateway.Attachments {
+					if _, err := ec2Client.DetachInternetGateway(ctx, &ec2.DetachInternetGatewayInput{
+						InternetGatewayId: aws.String(arn.ID()),
+						VpcId:             attachment.VpcId,
+					}); err != nil {
+						return err
+					}
+				}
+			}
+			_, err = ec2Client.DeleteInternetGateway(ctx, &ec2.DeleteInternetGatewayInput{InternetGatewayId: aws.String(arn.ID())})
+			return err
+		}),
+		"ec2:security-group": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := ec2Client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: aws.String(arn.ID())})
+			return err
+		}),
+		"ec2:subnet": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := ec2Client.DeleteSubnet(ctx, &ec2.DeleteSubnetInput{SubnetId: aws.String(arn.ID())})
+			return err
+		}),
+		"ec2:route-table": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := ec2Client.DeleteRouteTable(ctx, &ec2.DeleteRouteTableInput{RouteTableId: aws.String(arn.ID())})
+			return err
+		}),
+		"ec2:vpc": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			_, err := ec2Client.DeleteVpc(ctx, &ec2.DeleteVpcInput{VpcId: aws.String(arn.ID())})
+			return err
+		}),
+		"kms:key": arnDeleter(func(ctx context.Context, arn sweeper.ARN) error {
+			// scheduling the deletion again fails with KMSInvalidStateException
+			key, err := kmsClient.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(arn.String())})
+			if err != nil {
+				return err
+			}
+			if key.KeyMetadata.KeyState == kmstypes.KeyStatePendingDeletion {
+				return sweeper.ErrPendingDeletion
+			}
+			_, err = kmsClient.ScheduleKeyDeletion(ctx, &kms.ScheduleKeyDeletionInput{
+				KeyId:               aws.String(arn.String()),
+				PendingWindowInDays: aws.Int32(7),
+			})
+			return err
+		}),
+	}
+}
+
+// emptyBucket deletes all object versions and delete markers of the bucket
+func emptyBucket(ctx context.Context, client *s3.Client, bucket string) error {
+	input := &s3.ListObjectVersionsInput{Bucket: aws.String(bucket)}
+	for {
+		page, err := client.ListObjectVersions(ctx, input)
+		if err != nil {
+			return err
+		}
+		var objects []s3types.ObjectIdentifier
+		for _, v := range page.Versions {
+			objects = append(objects, s3types.ObjectIdentifier{Key: v.Key, VersionId: v.VersionId})
+		}
+		for _, m := range page.DeleteMarkers {
+			objects = append(objects, s3types.ObjectIdentifier{Key: m.Key, VersionId: m.VersionId})
+		}
+		if len(objects) > 0 {
+			if _, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+				Bucket: aws.String(bucket),
+				Delete: &s3types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+			}); err != nil {
+				return err
+			}
+		}
+		if !aws.ToBool(page.IsTruncated) {
+			return nil
+		}
+		input.KeyMarker = page.NextKeyMarker
+		input.VersionIdMarker = page.NextVersionIdMarker
+	}
+}
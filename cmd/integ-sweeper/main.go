@@ -0,0 +1,79 @@
+// Command integ-sweeper deletes resources leaked by integration test runs.
+//
+// Integration test apps are tagged with their app, run ID and creation time (see integ.RunTags). The sweeper finds
+// tagged resources older than -ttl through the Resource Groups Tagging API and deletes them in dependency order.
+//
+//	go run ./cmd/integ-sweeper -dry-run              # report leaked resources
+//	go run ./cmd/integ-sweeper -ttl 2h -app sqs      # delete resources of the sqs app older than 2 hours
+//	go run ./cmd/integ-sweeper -run-id 4f2a9c -ttl 0 # delete resources of a run, whatever their age
+//
+// Set -endpoint to run against a local fake tagging endpoint, see sweeper.FakeTaggingAPI.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/terraconstructs/base/integ"
+	"github.com/terraconstructs/base/integ/sweeper"
+)
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "integ-sweeper:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("integ-sweeper", flag.ContinueOnError)
+	region := flags.String("region", envOr("AWS_REGION", "us-east-1"), "AWS region to sweep")
+	ttl := flags.Duration("ttl", sweeper.DefaultTTL, "keep resources created less than ttl ago, 0 deletes all matching resources")
+	dryRun := flags.Bool("dry-run", false, "report the resources without deleting them")
+	app := flags.String("app", "", "only sweep resources of the integration test app")
+	runID := flags.String("run-id", "", "only sweep resources of the run")
+	endpoint := flags.String("endpoint", "", "tagging API endpoint, defaults to the regional endpoint")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(*region))
+	if err != nil {
+		return fmt.Errorf("error loading AWS config: %v", err)
+	}
+
+	s := &sweeper.Sweeper{
+		Tagging:  sweeper.NewTaggingClient(cfg, *endpoint),
+		Deleters: newDeleters(cfg),
+		TTL:      *ttl,
+		DryRun:   *dryRun,
+	}
+	if *app != "" {
+		s.Filters = append(s.Filters, sweeper.TagFilter{Key: integ.TagApp, Values: []string{*app}})
+	}
+	if *runID != "" {
+		s.Filters = append(s.Filters, sweeper.TagFilter{Key: integ.TagRunID, Values: []string{*runID}})
+	}
+
+	report, sweepErr := s.SweepE(ctx)
+	if report != nil {
+		if err := report.Write(stdout); err != nil {
+			return err
+		}
+	}
+	return sweepErr
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
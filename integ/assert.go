@@ -7,13 +7,25 @@ import (
 	"testing"
 
 	"github.com/hashicorp/go-multierror"
-	"github.com/jmespath/go-jmespath"
 )
 
 type Assertion struct {
 	Path           string  // JMESPath of the value to assert
-	Exists         bool    // Whether the value should exist, `true` if ExpectedRegexp is provided
+	Exists         bool    // Whether the value should exist, `true` if ExpectedRegexp or any typed matcher is provided
 	ExpectedRegexp *string // Regexp to match the value against
+
+	// Typed matchers are evaluated against the JMESPath result without string conversion
+
+	Equals      any       // Value must equal Equals, numbers are compared regardless of Go type
+	NotEquals   any       // Value must not equal NotEquals
+	GreaterThan *float64  // Value must be a number greater than GreaterThan
+	LessThan    *float64  // Value must be a number less than LessThan
+	Length      *int      // Value must be a string, array or object of the given length
+	Contains    any       // Value must be a string containing the substring, an array containing the element or an object containing the key
+	OneOf       []any     // Value must equal one of the OneOf values
+	TypeIs      ValueType // Value must be of the given JSON type
+
+	Matcher Matcher // Structural matcher to test the value against, i.e. ObjectLike, ArrayWith, Absent, SerializedJSON
 }
 
 // ref https://github.com/aws/aws-cdk/blob/v2.161.1/packages/%40aws-cdk/integ-tests-alpha/lib/assertions/sdk.ts
@@ -34,14 +46,16 @@ func AssertE(input any, assertions []Assertion) error {
 			combinedErr = multierror.Append(combinedErr, fmt.Errorf("path cannot be empty"))
 			continue
 		}
-		p, err := jmespath.Compile(a.Path)
+		p, err := CompileJMESPath(a.Path)
 		if err != nil {
 			combinedErr = multierror.Append(combinedErr, fmt.Errorf("error compiling JMESPath '%s': '%v'", a.Path, err))
 			continue
 		}
 		value, err := p.Search(input)
-		if err != nil || value == nil {
-			if !a.Exists && a.ExpectedRegexp == nil {
+		// a nil value expected by TypeIs or Absent is still checked against the other matchers of the assertion
+		nilExpected := err == nil && value == nil && (a.TypeIs == TypeNull || isAbsent(a.Matcher))
+		if (err != nil || value == nil) && !nilExpected {
+			if !a.Exists && a.ExpectedRegexp == nil && !a.hasTypedMatchers() {
 				// If the path does not exist or is nil and the value should not exist, we consider this a success
 				continue
 			}
@@ -55,10 +69,20 @@ func AssertE(input any, assertions []Assertion) error {
 		// debug print JMES expression result
 		// fmt.Printf("%q => %#v\n", a.Path, value)
 		if a.ExpectedRegexp != nil {
-			if err := assertRegexp(value, *a.ExpectedRegexp); err != nil {
+			if value == nil {
+				combinedErr = multierror.Append(combinedErr, fmt.Errorf("value at '%s' is nil", a.Path))
+			} else if err := assertRegexp(value, *a.ExpectedRegexp); err != nil {
 				combinedErr = multierror.Append(combinedErr, fmt.Errorf("error asserting value at '%s': %v", a.Path, err))
 			}
 		}
+		for _, err := range a.matchTyped(value) {
+			combinedErr = multierror.Append(combinedErr, fmt.Errorf("error asserting value at '%s': %v", a.Path, err))
+		}
+		if a.Matcher != nil {
+			for _, failure := range a.Matcher.Test(value).Failures() {
+				combinedErr = multierror.Append(combinedErr, fmt.Errorf("error matching value at '%s': %s", a.Path, failure))
+			}
+		}
 	}
 	return combinedErr
 }
@@ -23,7 +23,7 @@ func TestApigwTokenAuthorizer(t *testing.T) {
 	runComputeIntegrationTest(t, "apigw.token-authorizer", options, func(t *testing.T, tfWorkingDir, awsRegion string) {
 		// Optionally force re-deployment of the API Gateway to ensure the latest changes
 		// are applied. (no longer needed)
-		// util.ReplaceTerraformResource(t, tfWorkingDir, "aws_api_gateway_deployment", "")
+		// util.ReplaceTerraformResource(t, tfWorkingDir, integ.StateFilter{Type: "aws_api_gateway_deployment"})
 
 		terraformOptions := test_structure.LoadTerraformOptions(t, tfWorkingDir)
 		apiUrl := util.LoadOutputAttribute(t, terraformOptions, "api", "url")
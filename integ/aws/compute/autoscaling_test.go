@@ -2,7 +2,6 @@ package test
 
 import (
 	"fmt"
-	"os"
 	"testing"
 	"time"
 
@@ -10,10 +9,10 @@ import (
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/terraconstructs/go-synth/executors"
 
 	"github.com/gruntwork-io/terratest/modules/retry"
 	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/terraconstructs/base/integ"
 	util "github.com/terraconstructs/base/integ/aws"
 )
 
@@ -186,10 +185,13 @@ func TestAutoscalingUpdatePolicy(t *testing.T) {
 	options := integrationTestOptions{
 		Region: region,
 	}
-	runComputeIntegrationTest(t, "autoscaling.update-policy", options, validateAutoscalingUpdatePolicy)
+	r := util.NewRunner("autoscaling.update-policy", options.runnerOptions()...)
+	r.Run(t, func(t *testing.T, tfWorkingDir, awsRegion string) {
+		validateAutoscalingUpdatePolicy(t, r, tfWorkingDir, awsRegion)
+	})
 }
 
-func validateAutoscalingUpdatePolicy(t *testing.T, tfWorkingDir, awsRegion string) {
+func validateAutoscalingUpdatePolicy(t *testing.T, r *integ.Runner, tfWorkingDir, awsRegion string) {
 	opts := test_structure.LoadTerraformOptions(t, tfWorkingDir)
 	asgName := util.LoadOutputAttribute(t, opts, "asg", "autoScalingGroupName")
 
@@ -212,14 +214,8 @@ func validateAutoscalingUpdatePolicy(t *testing.T, tfWorkingDir, awsRegion strin
 	// This second synth+apply lives inside the validate stage rather than in stages
 	// of its own so the `%-synth-only` / `%-validate-only` make targets (which only
 	// know the four standard stage names) keep behaving sensibly.
-	envVars := executors.EnvMap(os.Environ())
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = "autoscaling.update-policy"
-	envVars["LAUNCH_TEMPLATE_REVISION"] = "v2"
-
-	util.SynthApp(t, "autoscaling.update-policy", tfWorkingDir, envVars, "handlers")
-	util.DeployUsingTerraform(t, tfWorkingDir, nil)
+	r.Synth(t, map[string]string{"LAUNCH_TEMPLATE_REVISION": "v2"})
+	util.DeployUsingTerraform(t, tfWorkingDir, r.RetryableErrors)
 
 	// (c) The apply hands the rollout to EC2 Auto Scaling and returns without
 	// waiting for it, so poll until the refresh shows up.
@@ -1,8 +1,6 @@
 package test
 
 import (
-	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -11,69 +9,24 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/terraconstructs/go-synth/executors"
 
 	"github.com/gruntwork-io/terratest/modules/aws"
 	"github.com/gruntwork-io/terratest/modules/terraform"
 
 	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/terraconstructs/base/integ"
 	util "github.com/terraconstructs/base/integ/aws"
 )
 
 // Test the instance app
 func TestInstance(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
-
 	// See if app deploys
-	testApp := "instance"
-	awsRegion := "us-east-1"
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	// test_structure.RunTestStage(t, "validate", func() {
-	// 	validate(t, tfWorkingDir, awsRegion)
-	// })
+	util.NewRunner("instance", integ.WithRegion(region)).Run(t, nil)
 }
 
 // Test the launch-template app
 func TestLaunchTemplate(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
-
-	testApp := "launch-template"
-	awsRegion := "us-east-1"
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-
-	test_structure.RunTestStage(t, "validate", func() {
-		validateLaunchTemplate(t, tfWorkingDir, awsRegion)
-	})
+	util.NewRunner("launch-template", integ.WithRegion(region)).Run(t, validateLaunchTemplate)
 }
 
 func validateLaunchTemplate(t *testing.T, tfWorkingDir, awsRegion string) {
@@ -120,56 +73,15 @@ func validateLaunchTemplate(t *testing.T, tfWorkingDir, awsRegion string) {
 
 // Test the instance-public app
 func TestInstancePublic(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
-
-	// See if app deploys
-	testApp := "instance-public"
-	awsRegion := "us-east-1"
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validateInstancePublic(t, tfWorkingDir, awsRegion)
-	})
+	util.NewRunner("instance-public", integ.WithRegion(region)).Run(t, validateInstancePublic)
 }
 
 func TestVpcLookup(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
-
-	testApp := "vpc-lookup"
-	awsRegion := "us-east-1"
 	lookupRegion := "us-west-2"
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars["AWS_REGION"] = awsRegion
-	envVars["LOOKUP_REGION"] = lookupRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	test_structure.RunTestStage(t, "validate", func() {
+	util.NewRunner("vpc-lookup",
+		integ.WithRegion(region),
+		integ.WithEnv(map[string]string{"LOOKUP_REGION": lookupRegion}),
+	).Run(t, func(t *testing.T, tfWorkingDir, awsRegion string) {
 		validateVpcLookup(t, tfWorkingDir, lookupRegion)
 	})
 }
@@ -190,30 +102,8 @@ func validateVpcLookup(t *testing.T, tfWorkingDir string, lookupRegion string) {
 
 // Test the machine-image app
 func TestMachineImage(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
-
-	testApp := "machine-image"
-	awsRegion := "us-east-1"
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
 	// TODO: Resolve permanent diff on the AMI due to the "resolve:ssm" string
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validateMachineImage(t, tfWorkingDir, awsRegion)
-	})
+	util.NewRunner("machine-image", integ.WithRegion(region)).Run(t, validateMachineImage)
 }
 
 func validateMachineImage(t *testing.T, tfWorkingDir string, awsRegion string) {
@@ -3,8 +3,6 @@ package test
 import (
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
 	"testing"
 	"time"
 
@@ -13,7 +11,6 @@ import (
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/terraconstructs/go-synth/executors"
 
 	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
 	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
@@ -49,8 +46,6 @@ func TestLambdaChain(t *testing.T) {
 		Region: region,
 	}
 	runComputeIntegrationTest(t, "lambda-chain", options, func(t *testing.T, tfWorkingDir, awsRegion string) {
-		// sleep for event bridge rules to be ready
-		time.Sleep(10 * time.Second)
 		validateLambdaChainSuccess(t, tfWorkingDir, awsRegion)
 		validateLambdaChainFailure(t, tfWorkingDir, awsRegion)
 	})
@@ -132,8 +127,8 @@ func validateDestinations(t *testing.T, tfWorkingDir string, awsRegion string) {
 			ExpectedRegexp: strPtr("OK"),
 		},
 		{
-			Path:           "responseContext.statusCode",
-			ExpectedRegexp: strPtr("200"),
+			Path:   "responseContext.statusCode",
+			Equals: 200,
 		},
 		{
 			Path:           "responsePayload",
@@ -154,7 +149,19 @@ func validateLambdaChainSuccess(t *testing.T, tfWorkingDir string, awsRegion str
 		InvocationType: &invocationTypeEvent,
 		Payload:        map[string]interface{}{"status": "success"},
 	})
-	messages := util.WaitForLogEvents(t, awsRegion, thirdFunctionLogGroup, 12, 5*time.Second)
+	// poll the log group of the last function until the chain completes
+	var messages []string
+	integ.AssertEventually(t, func() (any, error) {
+		var err error
+		messages, err = util.FilterLogEventsE(t, awsRegion, thirdFunctionLogGroup)
+		if err != nil {
+			return nil, err
+		}
+		if len(messages) == 0 {
+			return nil, fmt.Errorf("no log events found yet in log group %s", thirdFunctionLogGroup)
+		}
+		return messages, nil
+	}, nil, &integ.EventuallyOptions{Timeout: 2 * time.Minute, Interval: 5 * time.Second})
 	for _, message := range messages {
 		// we log messages only, no messages fails the test
 		terratestLogger.Logf(t, "Success Test: Message: %s", message)
@@ -310,68 +317,34 @@ func (o integrationTestOptions) retryableErrors() map[string]string {
 	return retryable
 }
 
-// run integration test
-func runComputeIntegrationTest(t *testing.T, testApp string, options integrationTestOptions, validate func(t *testing.T, tfWorkingDir string, awsRegion string)) {
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars := executors.EnvMap(os.Environ())
-	envVars["AWS_REGION"] = options.Region
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	assets := []string{"handlers"}
-	if len(options.AdditionalAssets) > 0 {
-		assets = append(assets, options.AdditionalAssets...)
+// runnerOptions returns the integ.Runner options of a compute test, "handlers" is always included in the assets
+func (o integrationTestOptions) runnerOptions() []integ.RunnerOption {
+	return []integ.RunnerOption{
+		integ.WithRegion(o.Region),
+		integ.WithAssets("handlers"),
+		integ.WithAssets(o.AdditionalAssets...),
+		integ.WithRetryableErrors(o.retryableErrors()),
 	}
+}
 
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars, assets...)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, options.retryableErrors())
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validate(t, tfWorkingDir, options.Region)
-	})
+// run integration test
+func runComputeIntegrationTest(t *testing.T, testApp string, options integrationTestOptions, validate func(t *testing.T, tfWorkingDir string, awsRegion string)) {
+	util.NewRunner(testApp, options.runnerOptions()...).Run(t, validate)
 }
 
 // run integration test and validate renaming the environment works without replacing any resources
 func runComputeIntegrationTestWithRename(t *testing.T, testApp string, options integrationTestOptions, validate func(t *testing.T, tfWorkingDir string, awsRegion string)) {
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars := executors.EnvMap(os.Environ())
-	envVars["AWS_REGION"] = options.Region
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars, "handlers")
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, options.retryableErrors())
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validate(t, tfWorkingDir, options.Region)
-	})
-
-	// rename the environment name
-	envVars["ENVIRONMENT_NAME"] = "renamed"
-	test_structure.RunTestStage(t, "rename_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars, "handlers")
-	})
-
-	// confirm no changes in plan
-	test_structure.RunTestStage(t, "validate_rename", func() {
-		replanUsingTerraform(t, tfWorkingDir)
-	})
+	runnerOptions := append(options.runnerOptions(),
+		// rename the environment name
+		integ.WithStageAfterValidate("rename_app", func(t *testing.T, r *integ.Runner) {
+			r.Synth(t, map[string]string{"ENVIRONMENT_NAME": r.Env["ENVIRONMENT_NAME"] + "-renamed"})
+		}),
+		// confirm no changes in plan
+		integ.WithStageAfterValidate("validate_rename", func(t *testing.T, r *integ.Runner) {
+			util.ReplanUsingTerraform(t, r.TfWorkingDir, util.NoReplacePolicy)
+		}),
+	)
+	util.NewRunner(testApp, runnerOptions...).Run(t, validate)
 }
 
 func strPtr(s string) *string {
@@ -2,8 +2,6 @@ package test
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"testing"
 	"time"
 
@@ -12,7 +10,6 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/terraconstructs/base/integ"
 	util "github.com/terraconstructs/base/integ/aws"
-	"github.com/terraconstructs/go-synth/executors"
 
 	// loggers "github.com/gruntwork-io/terratest/modules/logger"
 	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
@@ -22,7 +19,7 @@ import (
 
 // Test the multi-zone-acm-pub-cert app
 func TestMultiZoneAcmPubCert(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
+	envVars := map[string]string{}
 	envVars["DNS_DOMAIN_NAME1"] = "test2.e2e.terraconstructs.dev"
 	envVars["DNS_ZONE_ID1"] = "Z08908061QI3ISWIOB5X"
 	envVars["DNS_DOMAIN_NAME2"] = "test1.e2e.terraconstructs.dev"
@@ -32,8 +29,7 @@ func TestMultiZoneAcmPubCert(t *testing.T) {
 
 // Test the url-rewrite-spa app
 func TestUrlRewriteSpa(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
-	runEdgeIntegrationTest(t, "url-rewrite-spa", "us-east-1", envVars, validateURLRewriteFunction)
+	runEdgeIntegrationTest(t, "url-rewrite-spa", "us-east-1", nil, validateURLRewriteFunction)
 }
 
 // Secret to sign JWT Tokens for tests
@@ -41,15 +37,14 @@ const jwtTestSecret = "terratest-test-secret"
 
 // Test the kvs-jwt-verify app
 func TestKvsJwtVerify(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
+	envVars := map[string]string{}
 	envVars["SECRET_KEY"] = jwtTestSecret
 	runEdgeIntegrationTest(t, "kvs-jwt-verify", "us-east-1", envVars, validateJwtVerifyFunction)
 }
 
 // Run the apps/distribution-policies.ts integration test
 func TestDistributionPolicies(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
-	runEdgeIntegrationTest(t, "distribution-policies", "us-east-1", envVars,
+	runEdgeIntegrationTest(t, "distribution-policies", "us-east-1", nil,
 		func(t *testing.T, tfWorkingDir string, awsRegion string) {
 			// Load the Terraform Options saved by the earlier deploy_terraform stage
 			terraformOptions := test_structure.LoadTerraformOptions(t, tfWorkingDir)
@@ -61,8 +56,7 @@ func TestDistributionPolicies(t *testing.T) {
 // Test the apps/service-with-http-namespace.ts app
 // ref: https://github.com/aws/aws-cdk/blob/v2.233.0/packages/@aws-cdk-testing/framework-integ/test/aws-servicediscovery/test/integ.service-with-http-namespace.lit.ts
 func TestServiceWithHttpNamespace(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
-	runEdgeIntegrationTest(t, "service-with-http-namespace", "us-east-1", envVars, validateServiceWithHttpNamespace)
+	runEdgeIntegrationTest(t, "service-with-http-namespace", "us-east-1", nil, validateServiceWithHttpNamespace)
 }
 
 func validateMultiZoneAcmPubCert(t *testing.T, workingDir string, awsRegion string) {
@@ -221,11 +215,10 @@ func validateJwtVerifyFunction(t *testing.T, workingDir string, _awsRegion strin
 						return nil
 					}
 
-					expectedStatusStr := fmt.Sprintf("%d", int(tc.expectedStatus))
 					return integ.AssertE(r.Output, []integ.Assertion{
 						{
-							Path:           "response.statusCode",
-							ExpectedRegexp: &expectedStatusStr,
+							Path:   "response.statusCode",
+							Equals: tc.expectedStatus,
 						},
 					})
 				})
@@ -258,23 +251,9 @@ func strPtr(s string) *string {
 
 // run integration test
 func runEdgeIntegrationTest(t *testing.T, testApp, awsRegion string, envVars map[string]string, validate func(t *testing.T, tfWorkingDir string, awsRegion string)) {
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars, "handlers")
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validate(t, tfWorkingDir, awsRegion)
-	})
+	util.NewRunner(testApp,
+		integ.WithRegion(awsRegion),
+		integ.WithEnv(envVars),
+		integ.WithAssets("handlers"),
+	).Run(t, validate)
 }
@@ -3,23 +3,19 @@ package test
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/gruntwork-io/terratest/modules/aws"
-	loggers "github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
 	"github.com/stretchr/testify/require"
 	"github.com/terraconstructs/base/integ"
 	util "github.com/terraconstructs/base/integ/aws"
-	"github.com/terraconstructs/go-synth/executors"
+	"github.com/terraconstructs/base/integ/snapshot"
 )
 
-var terratestLogger = loggers.Default
-
 // Run the apps/key.ts integration test
 func TestKey(t *testing.T) {
 	app := "key"
@@ -36,8 +32,8 @@ func TestKey(t *testing.T) {
 			var policyDoc any
 			err := json.Unmarshal([]byte(keyPolicy), &policyDoc)
 			require.NoError(t, err)
-			if os.Getenv("WRITE_SNAPSHOTS") == "true" {
-				writeSnapshot(t, snapshotPath, policyDoc, "PolicyDocument")
+			if snapshot.Writing() {
+				snapshot.Write(t, snapshotPath, policyDoc, "PolicyDocument")
 			} else {
 				integ.Assert(t, policyDoc, []integ.Assertion{
 					// assert at least one statement grants kms:encrypt
@@ -140,39 +136,7 @@ func validateSecretReplica(t *testing.T, tfWorkingDir string, awsRegion string)
 
 // run encryption integration test
 func runEncryptionIntegrationTest(t *testing.T, testApp, awsRegion string, validate func(t *testing.T, tfWorkingDir string, awsRegion string)) {
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars := executors.EnvMap(os.Environ())
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validate(t, tfWorkingDir, awsRegion)
-	})
-}
-
-// writeSnapshot writes the full entity to a snapshot file
-// this is useful in an initial run to capture the created resources in AWS.
-func writeSnapshot(t *testing.T, snapshotDir string, entity any, entityName string) {
-	fileName := filepath.Join(snapshotDir, "outputs", entityName+".json")
-	roleString, err := json.MarshalIndent(entity, "", "  ")
-	require.NoError(t, err)
-	err = os.MkdirAll(filepath.Dir(fileName), 0755)
-	require.NoError(t, err)
-	terratestLogger.Logf(t, "Writing snapshot to %s", fileName)
-	err = os.WriteFile(fileName, roleString, 0644)
-	require.NoError(t, err)
+	util.NewRunner(testApp, integ.WithRegion(awsRegion)).Run(t, validate)
 }
 
 func strPtr(s string) *string {
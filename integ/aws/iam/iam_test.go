@@ -1,13 +1,13 @@
 package test
 
 import (
-	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/aws"
+	"github.com/terraconstructs/base/integ"
 	util "github.com/terraconstructs/base/integ/aws"
-	"github.com/terraconstructs/go-synth/executors"
+	"github.com/terraconstructs/base/integ/snapshot"
 
 	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
 )
@@ -34,11 +34,11 @@ func TestRole(t *testing.T) {
 				t.Run(tc.outputs, func(t *testing.T) {
 					snapshotPath := filepath.Join("snapshots", testApp)
 					validateRole(t, awsRegion, tc.outputs, tfWorkingDir, snapshotPath,
-						[]check{
+						[]snapshot.Check{
 							{
-								fieldPath:   "AssumeRolePolicyDocument",
-								shouldMatch: tc.snapshotFile,
-								unmarshal:   true,
+								FieldPath:   "AssumeRolePolicyDocument",
+								ShouldMatch: tc.snapshotFile,
+								Unmarshal:   true,
 							}})
 				})
 			}
@@ -52,11 +52,11 @@ func TestCompositePrincipal(t *testing.T) {
 		func(t *testing.T, tfWorkingDir string, awsRegion string) {
 			snapshotPath := filepath.Join("snapshots", testApp)
 			validateRole(t, awsRegion, "RoleWithCompositePrincipalOutputs", tfWorkingDir, snapshotPath,
-				[]check{
+				[]snapshot.Check{
 					{
-						fieldPath:   "AssumeRolePolicyDocument",
-						shouldMatch: "assume-role.json",
-						unmarshal:   true,
+						FieldPath:   "AssumeRolePolicyDocument",
+						ShouldMatch: "assume-role.json",
+						Unmarshal:   true,
 					},
 				})
 		})
@@ -69,11 +69,11 @@ func TestConditionWithRef(t *testing.T) {
 		func(t *testing.T, tfWorkingDir string, awsRegion string) {
 			snapshotPath := filepath.Join("snapshots", testApp)
 			validateRole(t, awsRegion, "MyRoleOutputs", tfWorkingDir, snapshotPath,
-				[]check{
+				[]snapshot.Check{
 					{
-						fieldPath:   "AssumeRolePolicyDocument",
-						shouldMatch: "assume-role.tmpl.json",
-						unmarshal:   true,
+						FieldPath:   "AssumeRolePolicyDocument",
+						ShouldMatch: "assume-role.tmpl.json",
+						Unmarshal:   true,
 					},
 				})
 		})
@@ -86,94 +86,75 @@ func TestManagedPolicy(t *testing.T) {
 		func(t *testing.T, tfWorkingDir string, awsRegion string) {
 			snapshotPath := filepath.Join("snapshots", testApp)
 			validateRole(t, awsRegion, "RoleOutputs", tfWorkingDir, snapshotPath,
-				[]check{
+				[]snapshot.Check{
 					{
-						fieldPath:   "AssumeRolePolicyDocument",
-						shouldMatch: "Role-assumeDoc.tmpl.json",
-						unmarshal:   true,
+						FieldPath:   "AssumeRolePolicyDocument",
+						ShouldMatch: "Role-assumeDoc.tmpl.json",
+						Unmarshal:   true,
 					},
 					{
-						fieldPath:   "AttachedPolicyArns",
-						shouldMatch: "Role-attachedPolicyArns.tmpl.json",
+						FieldPath:   "AttachedPolicyArns",
+						ShouldMatch: "Role-attachedPolicyArns.tmpl.json",
 					},
 				})
 			validateManagedPolicy(t, awsRegion, "OneManagedPolicyOutputs", tfWorkingDir, snapshotPath,
-				[]check{
+				[]snapshot.Check{
 					{
-						fieldPath:   "PolicyDocument",
-						shouldMatch: "OneManagedPolicy-doc.tmpl.json",
-						unmarshal:   true,
+						FieldPath:   "PolicyDocument",
+						ShouldMatch: "OneManagedPolicy-doc.tmpl.json",
+						Unmarshal:   true,
 					},
 				})
 			validateManagedPolicy(t, awsRegion, "TwoManagedPolicyOutputs", tfWorkingDir, snapshotPath,
-				[]check{
+				[]snapshot.Check{
 					{
-						fieldPath:   "PolicyDocument",
-						shouldMatch: "TwoManagedPolicy-doc.tmpl.json",
-						unmarshal:   true,
+						FieldPath:   "PolicyDocument",
+						ShouldMatch: "TwoManagedPolicy-doc.tmpl.json",
+						Unmarshal:   true,
 					},
 				})
 		})
 }
 
 // validate or snapshot the role created
-func validateRole(t *testing.T, awsRegion string, roleKey string, tfWorkingDir string, snapshotDir string, checks []check) {
+func validateRole(t *testing.T, awsRegion string, roleKey string, tfWorkingDir string, snapshotDir string, checks []snapshot.Check) {
 	// Load the Terraform Options saved by the earlier deploy_terraform stage
 	terraformOptions := test_structure.LoadTerraformOptions(t, tfWorkingDir)
 	roleName := util.LoadOutputAttribute(t, terraformOptions, roleKey, "name")
 	role := util.GetIamRole(t, awsRegion, roleName)
 	if snapshotDir != "" {
-		if os.Getenv("WRITE_SNAPSHOTS") == "true" {
+		if snapshot.Writing() {
 			// write a single full role snapshot
-			writeSnapshot(t, snapshotDir, role, roleKey)
+			snapshot.Write(t, snapshotDir, role, roleKey)
 		} else {
 			tmplVars := util.Variables{
 				"AccountId": aws.GetAccountId(t),
 			}
-			runChecks(t, snapshotDir, role, checks, &tmplVars)
+			snapshot.RunChecks(t, snapshotDir, role, checks, &tmplVars)
 		}
 	}
 }
 
 // validate or snapshot the managed Policy created
-func validateManagedPolicy(t *testing.T, awsRegion string, managedRoleKey string, tfWorkingDir string, snapshotDir string, checks []check) {
+func validateManagedPolicy(t *testing.T, awsRegion string, managedRoleKey string, tfWorkingDir string, snapshotDir string, checks []snapshot.Check) {
 	// Load the Terraform Options saved by the earlier deploy_terraform stage
 	terraformOptions := test_structure.LoadTerraformOptions(t, tfWorkingDir)
 	managedRoleArn := util.LoadOutputAttribute(t, terraformOptions, managedRoleKey, "arn")
 	policy := util.GetIamManagedPolicy(t, awsRegion, managedRoleArn)
 	if snapshotDir != "" {
-		if os.Getenv("WRITE_SNAPSHOTS") == "true" {
+		if snapshot.Writing() {
 			// write a single full policy snapshot
-			writeSnapshot(t, snapshotDir, policy, managedRoleKey)
+			snapshot.Write(t, snapshotDir, policy, managedRoleKey)
 		} else {
 			tmplVars := util.Variables{
 				"AccountId": aws.GetAccountId(t),
 			}
-			runChecks(t, snapshotDir, policy, checks, &tmplVars)
+			snapshot.RunChecks(t, snapshotDir, policy, checks, &tmplVars)
 		}
 	}
 }
 
 // run integration test
 func runIamIntegrationTest(t *testing.T, testApp, awsRegion string, validate func(t *testing.T, tfWorkingDir string, awsRegion string)) {
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars := executors.EnvMap(os.Environ())
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validate(t, tfWorkingDir, awsRegion)
-	})
+	util.NewRunner(testApp, integ.WithRegion(awsRegion)).Run(t, validate)
 }
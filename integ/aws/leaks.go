@@ -0,0 +1,252 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/terraconstructs/base/integ"
+	"github.com/terraconstructs/base/integ/sweeper"
+)
+
+const (
+	// LeakCheckEnv controls the verify_cleanup stage, LEAK_CHECK=fail fails the test on leaks, otherwise they are logged
+	LeakCheckEnv  = "LEAK_CHECK"
+	LeakCheckFail = "fail"
+
+	// StageVerifyCleanup is the stage added by WithLeakCheck
+	StageVerifyCleanup = "verify_cleanup"
+
+	// the tagging API lists deleted resources for a while
+	leakCheckTimeout  = 5 * time.Minute
+	leakCheckInterval = 30 * time.Second
+)
+
+// WithLeakCheck verifies nothing of the app remains after the cleanup stage: no resources with the run tags
+// of the app (see injectRunTags) and none of the implicit resources derived from the state before destroy,
+// i.e. `/aws/lambda/<function>` log groups or ENIs of VPC Lambdas, see integ.ImplicitResourceRules.
+//
+// Leaks are logged with a report, set LEAK_CHECK=fail to fail the test. NewRunner adds the check to every app.
+func WithLeakCheck() integ.RunnerOption {
+	return integ.WithStageAfterCleanup(StageVerifyCleanup, func(t *testing.T, r *integ.Runner) {
+		VerifyCleanup(t, r.TestApp, r.TfWorkingDir, r.Region)
+	})
+}
+
+// VerifyCleanup reports the leaks of the destroyed app. Implicit resources are reported right away,
+// tagged resources are looked up again for a while, as the tagging API lists deleted resources for some time.
+// The lookups stop early with the run, see integ.RunContext.
+func VerifyCleanup(t *testing.T, testApp, workingDir, awsRegion string) {
+	leaks, err := FindImplicitLeaksE(t, workingDir, awsRegion)
+	if err != nil {
+		terratestLogger.Logf(t, "[WARNING] Failed to verify cleanup of %s: %v", testApp, err)
+		return
+	}
+	var tagged []integ.Leak
+	var lookupErr error
+	// tagged resources still listed once the wait is over are reported
+	_ = integ.AssertEventuallyE(t, func() (any, error) {
+		tagged, lookupErr = FindTaggedLeaksE(t, testApp, workingDir, awsRegion)
+		if lookupErr != nil {
+			// a failed lookup ends the wait, reported below
+			return map[string]any{"remaining": 0}, nil
+		}
+		return map[string]any{"remaining": len(tagged)}, nil
+	}, []integ.Assertion{{Path: "remaining", Equals: 0}}, &integ.EventuallyOptions{
+		Timeout:     leakCheckTimeout,
+		Interval:    leakCheckInterval,
+		MaxInterval: leakCheckInterval,
+		Backoff:     1,
+	})
+	if lookupErr != nil {
+		terratestLogger.Logf(t, "[WARNING] Failed to verify cleanup of %s: %v", testApp, lookupErr)
+		return
+	}
+	leaks = append(leaks, tagged...)
+	if len(leaks) == 0 {
+		terratestLogger.Logf(t, "No resources of %s remain after cleanup", testApp)
+		return
+	}
+	report := integ.LeakReport(leaks)
+	if os.Getenv(LeakCheckEnv) == LeakCheckFail {
+		t.Errorf("Resources of %s leaked after cleanup:\n%s", testApp, report)
+		return
+	}
+	terratestLogger.Logf(t, "[WARNING] Resources of %s leaked after cleanup, delete them with cmd/integ-sweeper:\n%s", testApp, report)
+}
+
+// SaveImplicitResources saves the implicit resources of the deployed app for the leak check, before it is destroyed
+func SaveImplicitResources(t *testing.T, workingDir string) {
+	terraformOptions := test_structure.LoadTerraformOptions(t, workingDir)
+	state, err := integ.LoadStateE(t, terraformOptions)
+	if err != nil {
+		terratestLogger.Logf(t, "[WARNING] Failed to load state of %s, implicit resources are not verified: %v", workingDir, err)
+		return
+	}
+	implicit := integ.ImplicitResources(state)
+	test_structure.SaveTestData(t, formatImplicitResourcesPath(workingDir), true, &implicit)
+}
+
+// FindTaggedLeaksE returns the resources with the run tags of the destroyed app, in its region and the regions
+// of its implicit resources. The lookups use the RunContext of t.
+func FindTaggedLeaksE(t *testing.T, testApp, workingDir, awsRegion string) ([]integ.Leak, error) {
+	// the creation time of the run is retired by the cleanup, see retireCreatedAt
+	if !test_structure.IsTestDataPresent(t, formatDestroyedCreatedAtPath(workingDir)) {
+		return nil, nil
+	}
+	var createdAt time.Time
+	test_structure.LoadTestData(t, formatDestroyedCreatedAtPath(workingDir), &createdAt)
+	// the creation time identifies the run, even without run ID
+	tags := integ.RunTags(testApp, "", createdAt)
+
+	regions := map[string]bool{awsRegion: true}
+	for _, r := range loadImplicitResources(t, workingDir) {
+		if r.Region != "" {
+			regions[r.Region] = true
+		}
+	}
+	var leaks []integ.Leak
+	for region := range regions {
+		tagged, err := findTaggedLeaksE(integ.RunContext(t), region, tags)
+		if err != nil {
+			return nil, err
+		}
+		leaks = append(leaks, tagged...)
+	}
+	return leaks, nil
+}
+
+// FindImplicitLeaksE returns the implicit resources saved before destroy that still exist, see SaveImplicitResources.
+// The lookups use the RunContext of t.
+func FindImplicitLeaksE(t *testing.T, workingDir, awsRegion string) ([]integ.Leak, error) {
+	var leaks []integ.Leak
+	for _, r := range loadImplicitResources(t, workingDir) {
+		region := r.Region
+		if region == "" {
+			region = awsRegion
+		}
+		found, err := findImplicitResourceE(integ.RunContext(t), region, r)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range found {
+			leaks = append(leaks, integ.Leak{Kind: r.Kind, ID: id, Region: region, Source: r.Owner})
+		}
+	}
+	return leaks, nil
+}
+
+func loadImplicitResources(t *testing.T, workingDir string) []integ.ImplicitResource {
+	var implicit []integ.ImplicitResource
+	if test_structure.IsTestDataPresent(t, formatImplicitResourcesPath(workingDir)) {
+		test_structure.LoadTestData(t, formatImplicitResourcesPath(workingDir), &implicit)
+	}
+	return implicit
+}
+
+// findTaggedLeaksE returns the resources with the tags, except resources pending deletion
+func findTaggedLeaksE(ctx context.Context, region string, tags map[string]string) ([]integ.Leak, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	var filters []sweeper.TagFilter
+	for k, v := range tags {
+		filters = append(filters, sweeper.TagFilter{Key: k, Values: []string{v}})
+	}
+	sort.Slice(filters, func(i, j int) bool {
+		return filters[i].Key < filters[j].Key
+	})
+	resources, err := sweeper.NewTaggingClient(cfg, "").GetResourcesE(ctx, filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	var leaks []integ.Leak
+	for _, r := range resources {
+		arn, err := sweeper.ParseARN(r.ARN)
+		if err != nil {
+			return nil, err
+		}
+		pending, err := pendingDeletionE(ctx, cfg, arn)
+		if err != nil {
+			return nil, err
+		}
+		if !pending {
+			leaks = append(leaks, integ.Leak{Kind: arn.Type(), ID: r.ARN, Region: region, Source: "tagged"})
+		}
+	}
+	return leaks, nil
+}
+
+// pendingDeletionE reports resources scheduled for deletion, they remain tagged during their recovery window
+func pendingDeletionE(ctx context.Context, cfg aws.Config, arn sweeper.ARN) (bool, error) {
+	switch arn.Type() {
+	case "kms:key":
+		key, err := kms.NewFromConfig(cfg).DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(arn.String())})
+		if err != nil {
+			return false, err
+		}
+		return key.KeyMetadata.KeyState == kmstypes.KeyStatePendingDeletion, nil
+	case "secretsmanager:secret":
+		secret, err := secretsmanager.NewFromConfig(cfg).DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: aws.String(arn.String())})
+		if err != nil {
+			return false, err
+		}
+		return secret.DeletedDate != nil, nil
+	}
+	return false, nil
+}
+
+// findImplicitResourceE returns the IDs of the existing resources matching the implicit resource
+func findImplicitResourceE(ctx context.Context, region string, r integ.ImplicitResource) ([]string, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	switch r.Kind {
+	case integ.KindLogGroup:
+		output, err := cloudwatchlogs.NewFromConfig(cfg).DescribeLogGroups(ctx, &cloudwatchlogs.DescribeLogGroupsInput{
+			LogGroupNamePrefix: aws.String(r.ID),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range output.LogGroups {
+			if aws.ToString(g.LogGroupName) == r.ID {
+				return []string{r.ID}, nil
+			}
+		}
+		return nil, nil
+	case integ.KindNetworkInterface:
+		output, err := ec2.NewFromConfig(cfg).DescribeNetworkInterfaces(ctx, &ec2.DescribeNetworkInterfacesInput{
+			Filters: []ec2types.Filter{{Name: aws.String("description"), Values: []string{r.ID + "*"}}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, eni := range output.NetworkInterfaces {
+			ids = append(ids, aws.ToString(eni.NetworkInterfaceId))
+		}
+		return ids, nil
+	default:
+		return nil, fmt.Errorf("unknown implicit resource kind %q", r.Kind)
+	}
+}
+
+func formatImplicitResourcesPath(testFolder string) string {
+	return test_structure.FormatTestDataPath(testFolder, "implicit-resources.json")
+}
@@ -3,7 +3,6 @@ package test
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"testing"
 
@@ -15,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/terraconstructs/base/integ"
 	util "github.com/terraconstructs/base/integ/aws"
+	"github.com/terraconstructs/base/integ/snapshot"
 )
 
 // Run the apps/ecs-insights-metrics.ts integration test.
@@ -68,8 +68,8 @@ func TestEcsInsightsMetrics(t *testing.T) {
 				alarmName := util.LoadOutputAttribute(t, terraformOptions, expected.outputName, "alarmName")
 				alarm := util.GetMetricAlarm(t, awsRegion, alarmName)
 				require.NotNil(t, alarm)
-				if os.Getenv("WRITE_SNAPSHOTS") == "true" {
-					writeSnapshot(t, snapshotPath, alarm, expected.outputName)
+				if snapshot.Writing() {
+					snapshot.Write(t, snapshotPath, alarm, expected.outputName)
 				} else {
 					integ.Assert(t, alarm, []integ.Assertion{
 						{
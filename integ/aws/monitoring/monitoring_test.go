@@ -2,7 +2,6 @@ package test
 
 import (
 	"encoding/json"
-	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -10,16 +9,13 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
 	terratestaws "github.com/gruntwork-io/terratest/modules/aws"
-	loggers "github.com/gruntwork-io/terratest/modules/logger"
 	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
 	"github.com/stretchr/testify/require"
 	"github.com/terraconstructs/base/integ"
 	util "github.com/terraconstructs/base/integ/aws"
-	"github.com/terraconstructs/go-synth/executors"
+	"github.com/terraconstructs/base/integ/snapshot"
 )
 
-var terratestLogger = loggers.Default
-
 // Run the apps/log-destination-kinesis.ts integration test
 func TestLogDestinationKinesis(t *testing.T) {
 	runMonitoringIntegrationTest(t, "log-destination-kinesis", "us-east-1",
@@ -55,8 +51,8 @@ func TestLogDestinationLambda(t *testing.T) {
 				},
 			})
 			resp := terratestaws.WaitForQueueMessage(t, awsRegion, queueUrl, 20)
-			if os.Getenv("WRITE_SNAPSHOTS") == "true" {
-				writeSnapshot(t, snapshotPath, resp, "ReceivedMessage")
+			if snapshot.Writing() {
+				snapshot.Write(t, snapshotPath, resp, "ReceivedMessage")
 			} else {
 				var messageBody map[string]interface{}
 				err := json.Unmarshal([]byte(resp.MessageBody), &messageBody)
@@ -83,8 +79,8 @@ func TestLogGroupMetrics(t *testing.T) {
 				alarmName := util.LoadOutputAttribute(t, terraformOptions, outputName, "alarmName")
 				alarm := util.GetMetricAlarm(t, awsRegion, alarmName)
 				require.NotNil(t, alarm)
-				if os.Getenv("WRITE_SNAPSHOTS") == "true" {
-					writeSnapshot(t, snapshotPath, alarm, outputName)
+				if snapshot.Writing() {
+					snapshot.Write(t, snapshotPath, alarm, outputName)
 				} else {
 					integ.Assert(t, alarm, []integ.Assertion{
 						{
@@ -128,8 +124,8 @@ func TestLogGroupDataProtection(t *testing.T) {
 			var policy any
 			err := json.Unmarshal([]byte(policyStr), &policy)
 			require.NoError(t, err)
-			if os.Getenv("WRITE_SNAPSHOTS") == "true" {
-				writeSnapshot(t, snapshotPath, policy, "DataProtectionPolicy")
+			if snapshot.Writing() {
+				snapshot.Write(t, snapshotPath, policy, "DataProtectionPolicy")
 			} else {
 				integ.Assert(t, policy, []integ.Assertion{
 					{
@@ -157,37 +153,5 @@ func TestLogGroupDataProtection(t *testing.T) {
 
 // run monitoring integration test
 func runMonitoringIntegrationTest(t *testing.T, testApp, awsRegion string, validate func(t *testing.T, tfWorkingDir string, awsRegion string)) {
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars := executors.EnvMap(os.Environ())
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars, "handlers")
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validate(t, tfWorkingDir, awsRegion)
-	})
-}
-
-// writeSnapshot writes the full entity to a snapshot file
-// this is useful in an initial run to capture the created resources in AWS.
-func writeSnapshot(t *testing.T, snapshotDir string, entity any, entityName string) {
-	fileName := filepath.Join(snapshotDir, "outputs", entityName+".json")
-	roleString, err := json.MarshalIndent(entity, "", "  ")
-	require.NoError(t, err)
-	err = os.MkdirAll(filepath.Dir(fileName), 0755)
-	require.NoError(t, err)
-	terratestLogger.Logf(t, "Writing snapshot to %s", fileName)
-	err = os.WriteFile(fileName, roleString, 0644)
-	require.NoError(t, err)
+	util.NewRunner(testApp, integ.WithRegion(awsRegion), integ.WithAssets("handlers")).Run(t, validate)
 }
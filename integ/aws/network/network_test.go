@@ -1,14 +1,11 @@
 package test
 
 import (
-	"os"
-	"path/filepath"
 	"testing"
 
 	loggers "github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/terraconstructs/base/integ"
 	util "github.com/terraconstructs/base/integ/aws"
-	"github.com/terraconstructs/go-synth/executors"
 
 	"github.com/gruntwork-io/terratest/modules/aws"
 	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
@@ -18,32 +15,8 @@ var terratestLogger = loggers.Default
 
 // Test the simple-ipv4-vpc app
 func TestSimpleIPv4Vpc(t *testing.T) {
-	t.Parallel()
-	testApp := "simple-ipv4-vpc"
-	awsRegion := "us-east-1"
-
-	envVars := executors.EnvMap(os.Environ())
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	tfWorkingDir := filepath.Join("tf", testApp)
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		// synth app with handlers for connectivity testing
-		util.SynthApp(t, testApp, tfWorkingDir, envVars, "handlers")
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-
-	// Validate the network connectivity
-	test_structure.RunTestStage(t, "validate", func() {
-		validateWithLambdaInvocations(t, tfWorkingDir, awsRegion)
-	})
+	// synth app with handlers for connectivity testing
+	util.NewRunner("simple-ipv4-vpc", integ.WithAssets("handlers")).Run(t, validateWithLambdaInvocations)
 }
 
 // fetchFunctionPayload is the payload for the fetch function
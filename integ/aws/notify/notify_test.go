@@ -19,6 +19,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/terraconstructs/base/integ"
 	util "github.com/terraconstructs/base/integ/aws"
+	"github.com/terraconstructs/base/integ/snapshot"
+	"github.com/terraconstructs/base/integ/template"
 	"github.com/terraconstructs/go-synth/executors"
 
 	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
@@ -28,23 +30,115 @@ var terratestLogger = loggers.Default
 
 // Test the sqs app
 func TestQueue(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
 	// Confirm the queue is working as expected
-	runNotifyIntegrationTest(t, "sqs", "us-east-1", envVars, validateQueue)
+	runNotifyIntegrationTest(t, "sqs", "us-east-1", nil, validateQueue)
+}
+
+// Upgrade the sqs app from the latest terraconstructs release (or UPGRADE_FROM_VERSION) to the local lib without replacements
+func TestQueueUpgrade(t *testing.T) {
+	util.NewRunner("sqs",
+		integ.WithRegion("us-east-1"),
+		util.WithUpgradeFrom(util.UpgradeFromVersion(), util.NoReplacePolicy),
+	).Run(t, nil)
+}
+
+// Plan the sqs app without deploying, no AWS credentials are required
+func TestQueuePlan(t *testing.T) {
+	t.Parallel()
+	testApp := "sqs"
+	tfWorkingDir := filepath.Join("tf", testApp+"-plan")
+	envVars := executors.EnvMap(os.Environ())
+	envVars["AWS_REGION"] = "us-east-1"
+	envVars["ENVIRONMENT_NAME"] = "test"
+	envVars["STACK_NAME"] = testApp
+
+	test_structure.RunTestStage(t, "synth_app", func() {
+		util.SynthApp(t, testApp, tfWorkingDir, envVars)
+	})
+	test_structure.RunTestStage(t, "plan_terraform", func() {
+		plan := util.PlanSynthesizedStack(t, tfWorkingDir, &util.PlanOptions{MockProviders: true})
+		integ.AssertPlan(t, plan, []integ.Assertion{
+			{
+				Path:   "length(resource_changes[?type=='aws_sqs_queue'])",
+				Equals: 7,
+			},
+			{
+				// KMS_MANAGED encryption
+				Path: "resource_changes[?type=='aws_sqs_queue'].change.after",
+				Matcher: integ.ArrayWith([]any{
+					integ.ObjectLike(map[string]any{"kms_master_key_id": "alias/aws/sqs"}),
+				}),
+			},
+			{
+				// high throughput FIFO
+				Path: "resource_changes[?type=='aws_sqs_queue'].change.after",
+				Matcher: integ.ArrayWith([]any{
+					integ.ObjectLike(map[string]any{
+						"fifo_queue":            true,
+						"fifo_throughput_limit": "perMessageGroupId",
+						"deduplication_scope":   "messageGroup",
+					}),
+				}),
+			},
+			{
+				// SQS_MANAGED encryption
+				Path: "resource_changes[?type=='aws_sqs_queue'].change.after",
+				Matcher: integ.ArrayWith([]any{
+					integ.ObjectLike(map[string]any{"sqs_managed_sse_enabled": true}),
+				}),
+			},
+		})
+	})
+}
+
+// Assert the synthesized sqs app, no AWS credentials are required
+func TestQueueTemplate(t *testing.T) {
+	t.Parallel()
+	testApp := "sqs"
+	tfWorkingDir := filepath.Join("tf", testApp+"-template")
+	envVars := executors.EnvMap(os.Environ())
+	envVars["AWS_REGION"] = "us-east-1"
+	envVars["ENVIRONMENT_NAME"] = "test"
+	envVars["STACK_NAME"] = testApp
+
+	test_structure.RunTestStage(t, "synth_app", func() {
+		util.SynthApp(t, testApp, tfWorkingDir, envVars)
+	})
+	test_structure.RunTestStage(t, "assert_template", func() {
+		tpl := template.FromStack(t, tfWorkingDir)
+		tpl.ResourceCountIs(t, "aws_sqs_queue", 7)
+		tpl.HasResourceProperties(t, "aws_sqs_queue", map[string]any{
+			"kms_master_key_id": "alias/aws/sqs",
+			"redrive_policy": integ.SerializedJSON(map[string]any{
+				"maxReceiveCount": 5,
+			}),
+		})
+		tpl.HasResourceProperties(t, "aws_sqs_queue", map[string]any{
+			"fifo_queue":            true,
+			"fifo_throughput_limit": "perMessageGroupId",
+			"deduplication_scope":   "messageGroup",
+		})
+		tpl.HasResourceProperties(t, "aws_sqs_queue", map[string]any{
+			"sqs_managed_sse_enabled": false,
+			"kms_master_key_id":       integ.Absent(),
+		})
+		tpl.HasOutput(t, "QueueUrl", map[string]any{
+			"value": integ.StringLikeRegexp(`aws_sqs_queue\.\w+\.url`),
+		})
+		assert.Len(t, tpl.FindResources("aws_sqs_queue", map[string]any{"fifo_queue": true}), 2)
+	})
 }
 
 // Test the sqs-source-queue-permission app
 func TestSourceQueuePermission(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
 	// Confirm the Source Queue Permissions are working as expected
-	runNotifyIntegrationTest(t, "sqs-source-queue-permission", "us-east-1", envVars, validateSourceQueuePermission)
+	runNotifyIntegrationTest(t, "sqs-source-queue-permission", "us-east-1", nil, validateSourceQueuePermission)
 }
 
 // Test the fifo-queue app
 func TestFifoQueue(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
 	// Confirm the FIFO queue is working as expected
-	runNotifyIntegrationTest(t, "fifo-queue", "us-east-1", envVars, validateFifoQueue)
+	runNotifyIntegrationTest(t, "fifo-queue", "us-east-1", nil, validateFifoQueue)
 }
 
 // Test the dlq-queue app
@@ -55,92 +149,47 @@ func TestDlqQueue(t *testing.T) {
 	maxReceiveCount := 2
 	visibilityTimeoutSeconds := 5
 
-	envVars := executors.EnvMap(os.Environ())
-	envVars["MAX_RECEIVE_COUNT"] = strconv.Itoa(maxReceiveCount)
-	envVars["VISIBILITY_TIMEOUT_SECONDS"] = strconv.Itoa(visibilityTimeoutSeconds)
+	envVars := map[string]string{
+		"MAX_RECEIVE_COUNT":          strconv.Itoa(maxReceiveCount),
+		"VISIBILITY_TIMEOUT_SECONDS": strconv.Itoa(visibilityTimeoutSeconds),
+	}
 
-	// save maxReceiveCount for future stages
-	tfWorkingDir := filepath.Join("tf", testApp)
-	test_structure.SaveInt(t, tfWorkingDir, "max_receive_count", maxReceiveCount)
 	// Confirm the DLQ queue is working as expected
-	runNotifyIntegrationTest(t, testApp, awsRegion, envVars, validateDlqQueue)
+	runNotifyIntegrationTest(t, testApp, awsRegion, envVars, validateDlqQueue,
+		// save maxReceiveCount for future stages, in the working directory of the run
+		integ.WithStageBeforeSynth("save_test_data", func(t *testing.T, r *integ.Runner) {
+			test_structure.SaveInt(t, r.TfWorkingDir, "max_receive_count", maxReceiveCount)
+		}),
+	)
 }
 
 // Test the stream app
 func TestStream(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
 	// Confirm the kinesis stream is active and iam role has the correct permissions
-	runNotifyIntegrationTest(t, "stream", "us-east-1", envVars, validateStream)
+	runNotifyIntegrationTest(t, "stream", "us-east-1", nil, validateStream)
 }
 
 // Test the stream-dashboard app
 func TestStreamDashboard(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
 	// Confirm the dashboard is working as expected
-	runNotifyIntegrationTest(t, "stream-dashboard", "us-east-1", envVars, validateStreamDashboard)
+	runNotifyIntegrationTest(t, "stream-dashboard", "us-east-1", nil, validateStreamDashboard)
 }
 
 // Test the stream-resource-policy app
 func TestStreamResourcePolicy(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
 	// Confirm the stream resource policy is set as expected
-	runNotifyIntegrationTest(t, "stream-resource-policy", "us-east-1", envVars, validateStreamResourcePoliy)
+	runNotifyIntegrationTest(t, "stream-resource-policy", "us-east-1", nil, validateStreamResourcePoliy)
 }
 
 // Test the sns app
 func TestSns(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
-
 	// See if app deploys
-	testApp := "sns"
-	awsRegion := "us-east-1"
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	// test_structure.RunTestStage(t, "validate", func() {
-	// 	validate(t, tfWorkingDir, awsRegion)
-	// })
+	util.NewRunner("sns").Run(t, nil)
 }
 
 // Test the sns-lambda app
 func TestSnsLambda(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
-
-	// See if app deploys
-	testApp := "sns-lambda"
-	awsRegion := "us-east-1"
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars, "handlers")
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validateSnsLambda(t, tfWorkingDir, awsRegion)
-	})
+	util.NewRunner("sns-lambda", integ.WithAssets("handlers")).Run(t, validateSnsLambda)
 }
 
 func validateSnsLambda(t *testing.T, tfDir, awsRegion string) {
@@ -192,36 +241,17 @@ func validateSnsLambda(t *testing.T, tfDir, awsRegion string) {
 
 // Test the sns-sqs app
 func TestSnsSqs(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
-
-	// See if app deploys
-	testApp := "sns-sqs"
-	awsRegion := "us-east-1"
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validateSnsToSqs(t, tfWorkingDir, awsRegion)
-	})
+	util.NewRunner("sns-sqs").Run(t, validateSnsToSqs)
 }
 
 func validateSnsToSqs(t *testing.T, tfDir, awsRegion string) {
 	opts := test_structure.LoadTerraformOptions(t, tfDir)
-	topicArn := util.LoadOutputAttribute(t, opts, "my_topic", "topicArn")
-	queueUrl := util.LoadOutputAttribute(t, opts, "my_queue", "url")
+	var outputs struct {
+		TopicArn string `jmes:"my_topic.topicArn,required"`
+		QueueUrl string `jmes:"my_queue.url,required"`
+	}
+	integ.DecodeOutputs(t, opts, &outputs)
+	topicArn, queueUrl := outputs.TopicArn, outputs.QueueUrl
 
 	// 1) Positive case: matches filter → should arrive
 	bodyPos := `{ "background": { "color": "green" }, "price": 200 }`
@@ -246,30 +276,8 @@ func validateSnsToSqs(t *testing.T, tfDir, awsRegion string) {
 
 // Test the sns-url app
 func TestSnsUrl(t *testing.T) {
-	envVars := executors.EnvMap(os.Environ())
-
 	// See if app deploys
-	testApp := "sns-url"
-	awsRegion := "us-east-1"
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	// test_structure.RunTestStage(t, "validate", func() {
-	// 	validate(t, tfWorkingDir, awsRegion)
-	// })
+	util.NewRunner("sns-url").Run(t, nil)
 }
 
 func validateQueue(t *testing.T, workingDir string, awsRegion string) {
@@ -380,8 +388,8 @@ func validateQueue(t *testing.T, workingDir string, awsRegion string) {
 	terratestLogger.Logf(t, "Validating SSL Queue policy...")
 	sslPolicyDoc := util.GetQueuePolicy(t, awsRegion, sslUrl)
 
-	if os.Getenv("WRITE_SNAPSHOTS") == "true" {
-		writeSnapshot(t, snapshotPath, sslPolicyDoc, "SSLQueuePolicy")
+	if snapshot.Writing() {
+		snapshot.Write(t, snapshotPath, sslPolicyDoc, "SSLQueuePolicy")
 	} else {
 		// Validate policy has Deny statement with aws:SecureTransport condition
 		actionRe := "^sqs:\\*$"
@@ -413,9 +421,9 @@ func validateQueue(t *testing.T, workingDir string, awsRegion string) {
 	err = json.Unmarshal([]byte(role.InlinePolicies[0].PolicyDocument), &rolePolicyDoc)
 	require.NoError(t, err, "Should be able to parse policy document JSON")
 
-	if os.Getenv("WRITE_SNAPSHOTS") == "true" {
-		writeSnapshot(t, snapshotPath, role, "RoleWithQueuePermissions")
-		writeSnapshot(t, snapshotPath, rolePolicyDoc, "QueueConsumePolicyDocument")
+	if snapshot.Writing() {
+		snapshot.Write(t, snapshotPath, role, "RoleWithQueuePermissions")
+		snapshot.Write(t, snapshotPath, rolePolicyDoc, "QueueConsumePolicyDocument")
 	} else {
 		// Validate consume actions are present
 		actionsRe := "^sqs:(ChangeMessageVisibility|DeleteMessage|ReceiveMessage|GetQueueAttributes|GetQueueUrl)$"
@@ -585,9 +593,9 @@ func validateStream(t *testing.T, workingDir string, awsRegion string) {
 	var policyDoc any
 	err := json.Unmarshal([]byte(role.InlinePolicies[0].PolicyDocument), &policyDoc)
 	require.NoError(t, err)
-	if os.Getenv("WRITE_SNAPSHOTS") == "true" {
-		writeSnapshot(t, snapshotPath, role, "RoleOutputs")
-		writeSnapshot(t, snapshotPath, policyDoc, "PolicyDocument")
+	if snapshot.Writing() {
+		snapshot.Write(t, snapshotPath, role, "RoleOutputs")
+		snapshot.Write(t, snapshotPath, policyDoc, "PolicyDocument")
 	} else {
 		actionsRe := "^kinesis:PutRecord$"
 		integ.Assert(t, policyDoc, []integ.Assertion{
@@ -610,8 +618,8 @@ func validateStreamDashboard(t *testing.T, workingDir string, awsRegion string)
 	var dashboard any
 	err := json.Unmarshal([]byte(*dashboardBody), &dashboard)
 	require.NoError(t, err)
-	if os.Getenv("WRITE_SNAPSHOTS") == "true" {
-		writeSnapshot(t, snapshotPath, dashboard, "DashBoardBody")
+	if snapshot.Writing() {
+		snapshot.Write(t, snapshotPath, dashboard, "DashBoardBody")
 	}
 }
 
@@ -625,8 +633,8 @@ func validateStreamResourcePoliy(t *testing.T, workingDir string, awsRegion stri
 	var policyDoc any
 	err := json.Unmarshal([]byte(policyString), &policyDoc)
 	require.NoError(t, err)
-	if os.Getenv("WRITE_SNAPSHOTS") == "true" {
-		writeSnapshot(t, snapshotPath, policyDoc, "StreamResourcePolicy")
+	if snapshot.Writing() {
+		snapshot.Write(t, snapshotPath, policyDoc, "StreamResourcePolicy")
 	} else {
 		actionsRe := "^kinesis:GetRecords$"
 		principalRe := "^arn:aws:iam::\\d{12}:root$"
@@ -644,37 +652,14 @@ func validateStreamResourcePoliy(t *testing.T, workingDir string, awsRegion stri
 }
 
 // run integration test
-func runNotifyIntegrationTest(t *testing.T, testApp, awsRegion string, envVars map[string]string, validate func(t *testing.T, tfWorkingDir string, awsRegion string)) {
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	test_structure.RunTestStage(t, "validate", func() {
+func runNotifyIntegrationTest(t *testing.T, testApp, awsRegion string, envVars map[string]string, validate func(t *testing.T, tfWorkingDir string, awsRegion string), opts ...integ.RunnerOption) {
+	opts = append([]integ.RunnerOption{integ.WithRegion(awsRegion), integ.WithEnv(envVars)}, opts...)
+	r := util.NewRunner(testApp, opts...)
+	r.Run(t, func(t *testing.T, tfWorkingDir string, awsRegion string) {
 		validate(t, tfWorkingDir, awsRegion)
+		if manifestPath, ok := integ.FindAssertionManifest(testApp); ok {
+			terraformOptions := test_structure.LoadTerraformOptions(t, tfWorkingDir)
+			integ.RunAssertionManifest(t, manifestPath, terraformOptions, awsRegion)
+		}
 	})
 }
-
-// writeSnapshot writes the full entity to a snapshot file
-// this is useful in an initial run to capture the created resources in AWS.
-func writeSnapshot(t *testing.T, snapshotDir string, entity any, entityName string) {
-	fileName := filepath.Join(snapshotDir, "outputs", entityName+".json")
-	roleString, err := json.MarshalIndent(entity, "", "  ")
-	require.NoError(t, err)
-	err = os.MkdirAll(filepath.Dir(fileName), 0755)
-	require.NoError(t, err)
-	terratestLogger.Logf(t, "Writing snapshot to %s", fileName)
-	err = os.WriteFile(fileName, roleString, 0644)
-	require.NoError(t, err)
-}
@@ -0,0 +1,170 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+	"github.com/terraconstructs/base/integ"
+)
+
+// mockProvidersOverrideFile is merged into the synthesized stack by Terraform, see
+// https://opentofu.org/docs/language/files/override/
+const mockProvidersOverrideFile = "integ_mock_providers_override.tf.json"
+
+// PlanOptions configures offline plans of a synthesized stack
+type PlanOptions struct {
+	MockProviders bool   // Configure the default aws provider with mock credentials and a mock STS endpoint, so no AWS access is needed
+	MockAccountId string // Account returned by the mock STS endpoint, defaults to 123456789012
+	StateFile     string // Local state to plan against, defaults to an empty state (all resources are created)
+	Region        string // Region of the mocked provider, defaults to us-east-1
+	Binary        string // OpenTofu or Terraform binary, see TerraformBinary
+}
+
+// PlanSynthesizedStack plans the stack synthesized into tfWorkingDir by SynthApp using `tofu plan -refresh=false`, or the binary of PlanOptions.Binary.
+// The stack is copied to a temporary folder, so the plan does not affect the deploy stages.
+// This fails the test on any errors
+func PlanSynthesizedStack(t *testing.T, tfWorkingDir string, opts *PlanOptions) *tfjson.Plan {
+	plan, err := PlanSynthesizedStackE(t, tfWorkingDir, opts)
+	require.NoError(t, err, "Failed to plan %s", tfWorkingDir)
+	return plan
+}
+
+// PlanSynthesizedStackE plans the stack synthesized into tfWorkingDir by SynthApp using `tofu plan -refresh=false`, or the binary of PlanOptions.Binary.
+//
+// With MockProviders, the aws provider skips credential validation and the stack account (aws_caller_identity)
+// is served by a local mock STS endpoint. Data sources calling other AWS APIs fail the plan.
+func PlanSynthesizedStackE(t *testing.T, tfWorkingDir string, opts *PlanOptions) (*tfjson.Plan, error) {
+	if opts == nil {
+		opts = &PlanOptions{}
+	}
+	planDir, err := files.CopyTerraformFolderToTemp(tfWorkingDir, "plan-"+filepath.Base(tfWorkingDir))
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(planDir)
+
+	planArgs := []string{"-refresh=false", "-lock=false"}
+	if opts.StateFile != "" {
+		stateFile, err := filepath.Abs(opts.StateFile)
+		if err != nil {
+			return nil, err
+		}
+		planArgs = append(planArgs, "-state="+stateFile)
+	}
+	if opts.MockProviders {
+		sts := httptest.NewServer(mockStsHandler(opts.MockAccountId))
+		defer sts.Close()
+		if err := writeMockProvidersOverride(planDir, opts.Region, sts.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	binary, err := terraformBinaryE(t, opts.Binary)
+	if err != nil {
+		return nil, err
+	}
+	pluginCache, err := integ.PluginCacheEnvE()
+	if err != nil {
+		return nil, err
+	}
+	terraformOptions := &terraform.Options{
+		TerraformDir:    planDir,
+		TerraformBinary: binary,
+		EnvVars:         pluginCache,
+		NoColor:         true,
+		ExtraArgs: terraform.ExtraArgs{
+			Plan: planArgs,
+		},
+	}
+	return initAndPlanE(t, terraformOptions)
+}
+
+// initAndPlanE inits the working directory of terraformOptions, see initTerraform, plans into a temporary plan file
+// and returns the plan
+func initAndPlanE(t *testing.T, terraformOptions *terraform.Options) (*tfjson.Plan, error) {
+	planFile, err := os.CreateTemp("", "integ-plan-")
+	if err != nil {
+		return nil, err
+	}
+	planFile.Close()
+	defer os.Remove(planFile.Name())
+	options := *terraformOptions
+	options.PlanFilePath = planFile.Name()
+
+	if err := initTerraformE(t, &options); err != nil {
+		return nil, err
+	}
+	if _, err := terraform.PlanE(t, &options); err != nil {
+		return nil, err
+	}
+	planStruct, err := terraform.ShowWithStructE(t, &options)
+	if err != nil {
+		return nil, err
+	}
+	return &planStruct.RawPlan, nil
+}
+
+// writeMockProvidersOverride overrides the default aws provider with mock credentials
+func writeMockProvidersOverride(dir, region, stsEndpoint string) error {
+	if region == "" {
+		region = "us-east-1"
+	}
+	override := map[string]any{
+		"provider": map[string]any{
+			"aws": []map[string]any{
+				{
+					"region":                      region,
+					"access_key":                  "mock_access_key",
+					"secret_key":                  "mock_secret_key",
+					"skip_credentials_validation": true,
+					"skip_metadata_api_check":     true,
+					"skip_region_validation":      true,
+					"skip_requesting_account_id":  true,
+					"endpoints": []map[string]any{
+						{"sts": stsEndpoint},
+					},
+				},
+			},
+		},
+	}
+	data, err := json.MarshalIndent(override, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, mockProvidersOverrideFile), data, 0644)
+}
+
+// mockStsHandler serves STS GetCallerIdentity for the aws_caller_identity data source
+func mockStsHandler(accountId string) http.HandlerFunc {
+	if accountId == "" {
+		accountId = "123456789012"
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("Action") != "GetCallerIdentity" {
+			w.Header().Set("Content-Type", "text/xml")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `<ErrorResponse><Error><Type>Sender</Type><Code>InvalidAction</Code><Message>%s is not supported by the mocked provider</Message></Error><RequestId>mock</RequestId></ErrorResponse>`, r.Form.Get("Action"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, `<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>arn:aws:iam::%[1]s:user/mock</Arn>
+    <UserId>AIDAMOCKUSER</UserId>
+    <Account>%[1]s</Account>
+  </GetCallerIdentityResult>
+  <ResponseMetadata>
+    <RequestId>mock</RequestId>
+  </ResponseMetadata>
+</GetCallerIdentityResponse>`, accountId)
+	}
+}
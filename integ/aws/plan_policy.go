@@ -0,0 +1,215 @@
+package aws
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/hashicorp/go-multierror"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+)
+
+// PlanAction is the action of a resource change, replacements are a single action
+type PlanAction string
+
+const (
+	ActionNoOp    PlanAction = "no-op"
+	ActionRead    PlanAction = "read"
+	ActionCreate  PlanAction = "create"
+	ActionUpdate  PlanAction = "update"
+	ActionDelete  PlanAction = "delete"
+	ActionReplace PlanAction = "replace"
+)
+
+// PlanRule allows actions on resources with an address matching a glob, i.e. "aws_lambda_function.*"
+type PlanRule struct {
+	Address string       // path.Match glob over the full resource address, including any module prefix
+	Allow   []PlanAction // Actions allowed on matching resources, no-op and read are always allowed
+}
+
+// PlanPolicy restricts the changes a plan may contain. The first rule matching a resource address applies,
+// resources without a matching rule may only change with the Default actions.
+type PlanPolicy struct {
+	Rules   []PlanRule
+	Default []PlanAction // Actions allowed on resources without a matching rule, defaults to no changes
+}
+
+// NoReplacePolicy allows any change except replacing resources
+var NoReplacePolicy = &PlanPolicy{
+	Default: []PlanAction{ActionCreate, ActionUpdate, ActionDelete},
+}
+
+// PlanChange is a resource change evaluated against a PlanPolicy
+type PlanChange struct {
+	Address string
+	Action  PlanAction
+	Allowed bool
+	Rule    string // Address glob of the matching rule, empty for the policy default
+	Change  *tfjson.ResourceChange
+}
+
+// Evaluate evaluates the resource changes against the policy, sorted by address.
+// A nil policy allows no changes.
+func (p *PlanPolicy) Evaluate(changes []*tfjson.ResourceChange) []PlanChange {
+	result := make([]PlanChange, 0, len(changes))
+	for _, rc := range changes {
+		if rc == nil || rc.Change == nil {
+			continue
+		}
+		action := changeAction(rc.Change.Actions)
+		rule, allowed := p.allowed(rc.Address)
+		result = append(result, PlanChange{
+			Address: rc.Address,
+			Action:  action,
+			Allowed: action == ActionNoOp || action == ActionRead || containsAction(allowed, action),
+			Rule:    rule,
+			Change:  rc,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Address < result[j].Address
+	})
+	return result
+}
+
+// allowed returns the glob and actions of the rule matching address
+func (p *PlanPolicy) allowed(address string) (string, []PlanAction) {
+	if p == nil {
+		return "", nil
+	}
+	for _, r := range p.Rules {
+		if matched, _ := path.Match(r.Address, address); matched {
+			return r.Address, r.Allow
+		}
+	}
+	return "", p.Default
+}
+
+// CheckE returns an error listing every change the policy does not allow
+func (p *PlanPolicy) CheckE(changes []*tfjson.ResourceChange) error {
+	var combinedErr error
+	for _, c := range p.Evaluate(changes) {
+		if c.Allowed {
+			continue
+		}
+		_, allowed := p.allowed(c.Address)
+		combinedErr = multierror.Append(combinedErr, fmt.Errorf("%s: %s is not allowed (allowed: %s)", c.Address, c.Action, formatActions(allowed)))
+	}
+	return combinedErr
+}
+
+// changeAction maps terraform change actions to a single PlanAction
+func changeAction(actions tfjson.Actions) PlanAction {
+	switch {
+	case actions.Replace():
+		return ActionReplace
+	case actions.Create():
+		return ActionCreate
+	case actions.Update():
+		return ActionUpdate
+	case actions.Delete():
+		return ActionDelete
+	case actions.Read():
+		return ActionRead
+	default:
+		return ActionNoOp
+	}
+}
+
+func containsAction(actions []PlanAction, action PlanAction) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+func formatActions(actions []PlanAction) string {
+	if len(actions) == 0 {
+		return "none"
+	}
+	names := make([]string, len(actions))
+	for i, a := range actions {
+		names[i] = string(a)
+	}
+	return strings.Join(names, ", ")
+}
+
+// SummarizePlanChanges renders the changes as a table for the test log, no-op changes are omitted
+func SummarizePlanChanges(changes []PlanChange) string {
+	var buf bytes.Buffer
+	counts := map[PlanAction]int{}
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tADDRESS\tPOLICY")
+	for _, c := range changes {
+		if c.Action == ActionNoOp {
+			continue
+		}
+		counts[c.Action]++
+		policy := "allowed"
+		if !c.Allowed {
+			policy = "DENIED"
+		}
+		if c.Rule != "" {
+			policy += " (" + c.Rule + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", c.Action, c.Address, policy)
+	}
+	w.Flush()
+	fmt.Fprintf(&buf, "%d to add, %d to change, %d to replace, %d to destroy.\n",
+		counts[ActionCreate], counts[ActionUpdate], counts[ActionReplace], counts[ActionDelete])
+	return buf.String()
+}
+
+// ReplanUsingTerraform plans the stack deployed from workingDir, i.e. after re-synthesizing the app,
+// logs a summary of the changes and fails the test if the policy does not allow them.
+func ReplanUsingTerraform(t *testing.T, workingDir string, policy *PlanPolicy) *tfjson.Plan {
+	terraformOptions := test_structure.LoadTerraformOptions(t, workingDir)
+	plan := initAndPlanNoLog(t, terraformOptions)
+	enforcePlanPolicy(t, "Plan", plan.ResourceChanges, policy)
+	return plan
+}
+
+// CheckDriftUsingTerraform runs a refresh-only plan of the stack deployed from workingDir
+// and fails the test if the policy does not allow the drift, a nil policy allows no drift.
+func CheckDriftUsingTerraform(t *testing.T, workingDir string, policy *PlanPolicy) *tfjson.Plan {
+	terraformOptions := test_structure.LoadTerraformOptions(t, workingDir)
+	driftOptions := *terraformOptions
+	driftOptions.ExtraArgs.Plan = append([]string{"-refresh-only"}, terraformOptions.ExtraArgs.Plan...)
+	plan := initAndPlanNoLog(t, &driftOptions)
+	enforcePlanPolicy(t, "Drift", plan.ResourceDrift, policy)
+	return plan
+}
+
+// initAndPlanNoLog plans without logging the output of terraform, the policy logs a summary instead
+func initAndPlanNoLog(t *testing.T, terraformOptions *terraform.Options) *tfjson.Plan {
+	options := *terraformOptions
+	options.Logger = logger.Discard
+	plan, err := initAndPlanE(t, &options)
+	require.NoError(t, err)
+	return plan
+}
+
+// enforcePlanPolicy logs the summary table and diffs of updated and replaced resources
+func enforcePlanPolicy(t *testing.T, title string, resourceChanges []*tfjson.ResourceChange, policy *PlanPolicy) {
+	changes := policy.Evaluate(resourceChanges)
+	terratestLogger.Logf(t, "%s summary:\n%s", title, SummarizePlanChanges(changes))
+	for _, c := range changes {
+		if c.Action != ActionUpdate && c.Action != ActionReplace {
+			continue
+		}
+		prettyDiff, err := PrettyPrintResourceChange(c.Change)
+		require.NoError(t, err)
+		terratestLogger.Logf(t, "%s %s - %s", c.Action, c.Address, prettyDiff)
+	}
+	require.NoError(t, policy.CheckE(resourceChanges), "%s changes not allowed by policy", title)
+}
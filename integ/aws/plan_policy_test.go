@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func resourceChange(address string, actions ...tfjson.Action) *tfjson.ResourceChange {
+	return &tfjson.ResourceChange{
+		Address: address,
+		Change:  &tfjson.Change{Actions: actions},
+	}
+}
+
+var policyTestChanges = []*tfjson.ResourceChange{
+	resourceChange("aws_lambda_function.Handler_886CB40B", tfjson.ActionUpdate),
+	resourceChange("aws_iam_role.Role_1ABCC5F0", tfjson.ActionDelete, tfjson.ActionCreate),
+	resourceChange("aws_sqs_queue.Queue_4A7E3555", tfjson.ActionNoop),
+	resourceChange("data.aws_iam_policy_document.Policy", tfjson.ActionRead),
+	resourceChange("aws_cloudwatch_log_group.Handler_LogGroup", tfjson.ActionCreate),
+}
+
+func TestPlanPolicy_Evaluate(t *testing.T) {
+	policy := &PlanPolicy{
+		Rules: []PlanRule{
+			{Address: "aws_lambda_function.*", Allow: []PlanAction{ActionUpdate}},
+			{Address: "aws_cloudwatch_log_group.*", Allow: []PlanAction{ActionCreate}},
+		},
+	}
+	changes := policy.Evaluate(policyTestChanges)
+	expected := []struct {
+		address string
+		action  PlanAction
+		allowed bool
+	}{
+		{"aws_cloudwatch_log_group.Handler_LogGroup", ActionCreate, true},
+		{"aws_iam_role.Role_1ABCC5F0", ActionReplace, false},
+		{"aws_lambda_function.Handler_886CB40B", ActionUpdate, true},
+		{"aws_sqs_queue.Queue_4A7E3555", ActionNoOp, true},
+		{"data.aws_iam_policy_document.Policy", ActionRead, true},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %d", len(expected), len(changes))
+	}
+	for i, e := range expected {
+		c := changes[i]
+		if c.Address != e.address || c.Action != e.action || c.Allowed != e.allowed {
+			t.Errorf("Expected %s %s allowed=%t, got %s %s allowed=%t", e.address, e.action, e.allowed, c.Address, c.Action, c.Allowed)
+		}
+	}
+
+	err := policy.CheckE(policyTestChanges)
+	if err == nil || !strings.Contains(err.Error(), "aws_iam_role.Role_1ABCC5F0: replace is not allowed (allowed: none)") {
+		t.Errorf("Expected the role replacement to be denied, got %v", err)
+	}
+}
+
+func TestPlanPolicy_FirstRuleWins(t *testing.T) {
+	policy := &PlanPolicy{
+		Rules: []PlanRule{
+			{Address: "aws_lambda_function.Handler_*", Allow: []PlanAction{ActionReplace}},
+			{Address: "aws_lambda_function.*", Allow: []PlanAction{ActionUpdate}},
+		},
+	}
+	changes := []*tfjson.ResourceChange{
+		resourceChange("aws_lambda_function.Handler_886CB40B", tfjson.ActionCreate, tfjson.ActionDelete),
+		resourceChange("aws_lambda_function.Other_1234", tfjson.ActionCreate, tfjson.ActionDelete),
+	}
+	err := policy.CheckE(changes)
+	if err == nil || strings.Contains(err.Error(), "Handler_886CB40B") || !strings.Contains(err.Error(), "aws_lambda_function.Other_1234: replace is not allowed (allowed: update)") {
+		t.Errorf("Expected only Other_1234 to be denied, got %v", err)
+	}
+}
+
+func TestNoReplacePolicy(t *testing.T) {
+	err := NoReplacePolicy.CheckE(policyTestChanges)
+	if err == nil || !strings.Contains(err.Error(), "1 error occurred") {
+		t.Errorf("Expected only the replacement to be denied, got %v", err)
+	}
+
+	var nilPolicy *PlanPolicy
+	if err := nilPolicy.CheckE(policyTestChanges[2:4]); err != nil {
+		t.Errorf("Expected no-op and read changes to be allowed, got %v", err)
+	}
+}
+
+func TestSummarizePlanChanges(t *testing.T) {
+	policy := &PlanPolicy{
+		Rules: []PlanRule{{Address: "aws_lambda_function.*", Allow: []PlanAction{ActionUpdate}}},
+	}
+	summary := SummarizePlanChanges(policy.Evaluate(policyTestChanges))
+	expected := `ACTION   ADDRESS                                    POLICY
+create   aws_cloudwatch_log_group.Handler_LogGroup  DENIED
+replace  aws_iam_role.Role_1ABCC5F0                 DENIED
+update   aws_lambda_function.Handler_886CB40B       allowed (aws_lambda_function.*)
+read     data.aws_iam_policy_document.Policy        allowed
+1 to add, 1 to change, 1 to replace, 0 to destroy.
+`
+	if summary != expected {
+		t.Errorf("Expected summary:\n%s\ngot:\n%s", expected, summary)
+	}
+}
@@ -0,0 +1,65 @@
+package aws
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMockStsHandler(t *testing.T) {
+	server := httptest.NewServer(mockStsHandler("111122223333"))
+	defer server.Close()
+
+	resp, err := http.PostForm(server.URL, url.Values{"Action": {"GetCallerIdentity"}, "Version": {"2011-06-15"}})
+	if err != nil {
+		t.Fatalf("Failed to call mock STS: %s", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "<Account>111122223333</Account>") {
+		t.Errorf("Expected caller identity of account 111122223333, got %d: %s", resp.StatusCode, body)
+	}
+
+	resp, err = http.PostForm(server.URL, url.Values{"Action": {"AssumeRole"}})
+	if err != nil {
+		t.Fatalf("Failed to call mock STS: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected unsupported actions to fail, got %d", resp.StatusCode)
+	}
+}
+
+func TestWriteMockProvidersOverride(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeMockProvidersOverride(dir, "", "http://127.0.0.1:1234"); err != nil {
+		t.Fatalf("Failed to write override: %s", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, mockProvidersOverrideFile))
+	if err != nil {
+		t.Fatalf("Failed to read override: %s", err)
+	}
+	var override struct {
+		Provider struct {
+			Aws []struct {
+				Region    string              `json:"region"`
+				Endpoints []map[string]string `json:"endpoints"`
+			} `json:"aws"`
+		} `json:"provider"`
+	}
+	if err := json.Unmarshal(data, &override); err != nil {
+		t.Fatalf("Failed to parse override: %s", err)
+	}
+	if len(override.Provider.Aws) != 1 || override.Provider.Aws[0].Region != "us-east-1" {
+		t.Errorf("Expected a single aws provider in us-east-1, got %s", data)
+	}
+	if override.Provider.Aws[0].Endpoints[0]["sts"] != "http://127.0.0.1:1234" {
+		t.Errorf("Expected the sts endpoint to be mocked, got %s", data)
+	}
+}
@@ -0,0 +1,39 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/terraconstructs/base/integ"
+)
+
+// Lifecycle synthesizes apps using SynthApp and deploys them using Terraform
+var Lifecycle integ.Lifecycle = terraformLifecycle{}
+
+type terraformLifecycle struct {
+	binary string // see TerraformBinary
+}
+
+// WithBinary deploys the app with the OpenTofu or Terraform binary name instead of the INTEG_TF_BINARY or default one
+func WithBinary(name string) integ.RunnerOption {
+	return integ.WithLifecycle(terraformLifecycle{binary: name})
+}
+
+func (terraformLifecycle) Synth(t *testing.T, testApp, tfWorkingDir string, env map[string]string, assets ...string) {
+	SynthApp(t, testApp, tfWorkingDir, env, assets...)
+}
+
+func (l terraformLifecycle) Deploy(t *testing.T, tfWorkingDir string, retryableErrors map[string]string) {
+	DeployUsingBinary(t, l.binary, tfWorkingDir, retryableErrors)
+}
+
+func (terraformLifecycle) Destroy(t *testing.T, tfWorkingDir string) {
+	// the state is gone after destroy, see WithLeakCheck
+	SaveImplicitResources(t, tfWorkingDir)
+	UndeployUsingTerraform(t, tfWorkingDir)
+}
+
+// NewRunner returns an integ.Runner for the test app of the integration namespace, using the Terraform Lifecycle.
+// The runner verifies no resources remain after cleanup, see WithLeakCheck.
+func NewRunner(testApp string, opts ...integ.RunnerOption) *integ.Runner {
+	return integ.NewRunner(Lifecycle, testApp, append([]integ.RunnerOption{WithLeakCheck()}, opts...)...)
+}
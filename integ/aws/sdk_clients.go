@@ -0,0 +1,39 @@
+package aws
+
+import (
+	"testing"
+
+	terratestaws "github.com/gruntwork-io/terratest/modules/aws"
+	terratesting "github.com/gruntwork-io/terratest/modules/testing"
+
+	"github.com/terraconstructs/base/integ"
+)
+
+// Register the service clients available to SDK calls in assertion manifests
+func init() {
+	registerSdkClient("cloudwatch", NewCloudWatchClientE)
+	registerSdkClient("cloudwatchevents", NewCloudWatchEventsClientE)
+	registerSdkClient("dynamodb", terratestaws.NewDynamoDBClientE)
+	registerSdkClient("ec2", NewEc2ClientE)
+	registerSdkClient("ecs", terratestaws.NewEcsClientE)
+	registerSdkClient("eventbridge", NewEventBridgeClientE)
+	registerSdkClient("iam", terratestaws.NewIamClientE)
+	registerSdkClient("kinesis", NewKinesisClientE)
+	registerSdkClient("kms", terratestaws.NewKmsClientE)
+	registerSdkClient("lambda", terratestaws.NewLambdaClientE)
+	registerSdkClient("logs", terratestaws.NewCloudWatchLogsClientE)
+	registerSdkClient("cloudwatchlogs", terratestaws.NewCloudWatchLogsClientE)
+	registerSdkClient("s3", terratestaws.NewS3ClientE)
+	registerSdkClient("secretsmanager", terratestaws.NewSecretsManagerClientE)
+	registerSdkClient("servicediscovery", NewServiceDiscoveryClientE)
+	registerSdkClient("sfn", NewSfnclientE)
+	registerSdkClient("stepfunctions", NewSfnclientE)
+	registerSdkClient("sns", terratestaws.NewSnsClientE)
+	registerSdkClient("sqs", terratestaws.NewSqsClientE)
+}
+
+func registerSdkClient[C any](service string, newClientE func(terratesting.TestingT, string) (C, error)) {
+	integ.RegisterSdkClient(service, func(t *testing.T, region string) (any, error) {
+		return newClientE(t, region)
+	})
+}
@@ -2,7 +2,7 @@ package test
 
 import (
 	"encoding/json"
-	"os"
+	"fmt"
 	"path/filepath"
 	"strconv"
 	"testing"
@@ -16,7 +16,6 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/terraconstructs/base/integ"
 	util "github.com/terraconstructs/base/integ/aws"
-	"github.com/terraconstructs/go-synth/executors"
 )
 
 var terratestLogger = loggers.Default
@@ -130,15 +129,12 @@ func validateCallAwsServiceEfs(t *testing.T, tfWorkingDir string, awsRegion stri
 	terraformOptions := test_structure.LoadTerraformOptions(t, tfWorkingDir)
 	stateMachineArn := util.LoadOutputAttribute(t, terraformOptions, "state_machine", "arn")
 	efsAccessPointArn := terraform.OutputRequired(t, terraformOptions, "efs_accesspoint_arn")
-	// sleep for iam propagation
-	time.Sleep(5 * time.Second)
 
 	sampleInput := map[string]interface{}{
 		"pathToArn": efsAccessPointArn,
 		"pathToId":  "MYTAGVALUE",
 	}
-	executionArn := util.StartSfnExecution(t, awsRegion, stateMachineArn, sampleInput)
-	util.WaitForSfnExecutionStatus(t, awsRegion, *executionArn, types.ExecutionStatusSucceeded, 12, 5*time.Second)
+	runStateMachineEventually(t, awsRegion, stateMachineArn, sampleInput, 12, 5*time.Second)
 }
 
 // Validate the sqs-send-message integration test
@@ -146,11 +142,8 @@ func validateSqsSendMessage(t *testing.T, tfWorkingDir string, awsRegion string)
 	terraformOptions := test_structure.LoadTerraformOptions(t, tfWorkingDir)
 	stateMachineArn := util.LoadOutputAttribute(t, terraformOptions, "state_machine", "arn")
 	queueUrl := util.LoadOutputAttribute(t, terraformOptions, "queue", "url")
-	// sleep for iam propagation
-	time.Sleep(5 * time.Second)
 
-	executionArn := util.StartSfnExecution(t, awsRegion, stateMachineArn, nil)
-	util.WaitForSfnExecutionStatus(t, awsRegion, *executionArn, types.ExecutionStatusSucceeded, 12, 5*time.Second)
+	runStateMachineEventually(t, awsRegion, stateMachineArn, nil, 12, 5*time.Second)
 	// validate sqs message
 	resp := util.WaitForQueueMessage(t, awsRegion, queueUrl, 20)
 	terratestLogger.Logf(t, "Message Body: %v", resp.MessageBody)
@@ -219,17 +212,10 @@ func validateStateMachineSucceeds(t *testing.T, tfWorkingDir string, awsRegion s
 	// Load the Terraform Options saved by the earlier deploy_terraform stage
 	terraformOptions := test_structure.LoadTerraformOptions(t, tfWorkingDir)
 	stateMachineArn := util.LoadOutputAttribute(t, terraformOptions, "state_machine", "arn")
-	// sleep for iam propagation
-	time.Sleep(5 * time.Second)
 
 	// https://github.com/aws/aws-cdk/blob/v2.164.1/packages/%40aws-cdk-testing/framework-integ/test/aws-stepfunctions-tasks/test/aws-sdk/integ.call-aws-service-sfn.ts#L35
 	// https://github.com/aws/aws-cdk/blob/v2.164.1/packages/%40aws-cdk-testing/framework-integ/test/aws-stepfunctions-tasks/test/lambda/integ.invoke.ts#L99
-	executionArn := util.StartSfnExecution(t, awsRegion, stateMachineArn, nil)
-	util.WaitForSfnExecutionStatus(t, awsRegion, *executionArn,
-		types.ExecutionStatusSucceeded,
-		3,
-		3*time.Second,
-	)
+	runStateMachineEventually(t, awsRegion, stateMachineArn, nil, 3, 3*time.Second)
 }
 
 // Validate state machine execution succeeds after starting and asserts output
@@ -237,41 +223,37 @@ func validateStateMachineSucceedsWithOutput(t *testing.T, tfWorkingDir string, a
 	// Load the Terraform Options saved by the earlier deploy_terraform stage
 	terraformOptions := test_structure.LoadTerraformOptions(t, tfWorkingDir)
 	stateMachineArn := util.LoadOutputAttribute(t, terraformOptions, "state_machine", "arn")
-	// sleep for iam propagation
-	time.Sleep(5 * time.Second)
-	executionArn := util.StartSfnExecution(t, awsRegion, stateMachineArn, input)
-	result := util.WaitForSfnExecutionStatus(t, awsRegion, *executionArn,
-		types.ExecutionStatusSucceeded,
-		3,
-		3*time.Second,
-	)
-	require.NotNil(t, result.Output)
-	var output map[string]interface{}
-	err := json.Unmarshal([]byte(result.Output), &output)
-	require.NoError(t, err)
-	integ.Assert(t, output, assertions)
+	runStateMachineEventually(t, awsRegion, stateMachineArn, input, 3, 3*time.Second, assertions...)
+}
+
+// runStateMachineEventually starts new executions until one succeeds with the asserted output,
+// executions fail until iam permissions have propagated
+func runStateMachineEventually(t *testing.T, awsRegion, stateMachineArn string, input interface{}, maxRetries int, sleepBetweenRetries time.Duration, assertions ...integ.Assertion) {
+	integ.AssertEventually(t, func() (any, error) {
+		executionArn, err := util.StartSfnExecutionE(t, awsRegion, stateMachineArn, input)
+		if err != nil {
+			return nil, err
+		}
+		result, err := util.WaitForSfnExecutionStatusE(t, awsRegion, *executionArn,
+			types.ExecutionStatusSucceeded,
+			maxRetries,
+			sleepBetweenRetries,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%v (cause: %s)", err, result.Cause)
+		}
+		var output interface{}
+		if result.Output == "" {
+			return output, nil
+		}
+		if err := json.Unmarshal([]byte(result.Output), &output); err != nil {
+			return nil, err
+		}
+		return output, nil
+	}, assertions, &integ.EventuallyOptions{Timeout: 2 * time.Minute, Interval: 5 * time.Second})
 }
 
 // run stepfunctions integration test
 func runStepfunctionsIntegrationTest(t *testing.T, testApp, awsRegion string, validate func(t *testing.T, tfWorkingDir string, awsRegion string)) {
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars := executors.EnvMap(os.Environ())
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars, "handlers")
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, nil)
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validate(t, tfWorkingDir, awsRegion)
-	})
+	util.NewRunner(testApp, integ.WithRegion(awsRegion), integ.WithAssets("handlers")).Run(t, validate)
 }
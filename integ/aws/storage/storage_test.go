@@ -5,8 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
-	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -18,13 +16,13 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/terraconstructs/go-synth/executors"
 
 	"github.com/gruntwork-io/terratest/modules/aws"
 	loggers "github.com/gruntwork-io/terratest/modules/logger"
 	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/terraconstructs/base/integ"
 	util "github.com/terraconstructs/base/integ/aws"
 )
 
@@ -251,31 +249,18 @@ func validateTablePolicy(t *testing.T, tfWorkingDir string, awsRegion string) {
 	terratestLogger.Logf(t, "Table policy validation completed successfully!")
 }
 
+// retryable errors of storage integration tests
+var storageRetryableErrors = map[string]string{
+	// TODO: Scaling Policy Target race condition on resource Id (despite `resource_id = "table/${aws_dynamodb_table.Table_CD117FA1.name}" containing resource reference)
+	".*No scalable target registered for service namespace: dynamodb.*": "Failed due to eventual consistency between AutoScaling and DynamoDb services.",
+}
+
 // run integration test
 func runStorageIntegrationTest(t *testing.T, testApp, awsRegion string, validate func(t *testing.T, tfWorkingDir string, awsRegion string)) {
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars := executors.EnvMap(os.Environ())
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, map[string]string{
-			// TODO: Scaling Policy Target race condition on resource Id (despite `resource_id = "table/${aws_dynamodb_table.Table_CD117FA1.name}" containing resource reference)
-			".*No scalable target registered for service namespace: dynamodb.*": "Failed due to eventual consistency between AutoScaling and DynamoDb services.",
-		})
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validate(t, tfWorkingDir, awsRegion)
-	})
+	util.NewRunner(testApp,
+		integ.WithRegion(awsRegion),
+		integ.WithRetryableErrors(storageRetryableErrors),
+	).Run(t, validate)
 }
 
 // run integration test with load testing
@@ -285,32 +270,13 @@ func runStorageIntegrationTestWithLoadTest(
 	validate func(t *testing.T, tfWorkingDir string, awsRegion string),
 	loadTest func(t *testing.T, tfWorkingDir string, awsRegion string),
 ) {
-	t.Parallel()
-	tfWorkingDir := filepath.Join("tf", testApp)
-	envVars := executors.EnvMap(os.Environ())
-	envVars["AWS_REGION"] = awsRegion
-	envVars["ENVIRONMENT_NAME"] = "test"
-	envVars["STACK_NAME"] = testApp
-
-	defer test_structure.RunTestStage(t, "cleanup_terraform", func() {
-		util.UndeployUsingTerraform(t, tfWorkingDir)
-	})
-
-	test_structure.RunTestStage(t, "synth_app", func() {
-		util.SynthApp(t, testApp, tfWorkingDir, envVars)
-	})
-	test_structure.RunTestStage(t, "deploy_terraform", func() {
-		util.DeployUsingTerraform(t, tfWorkingDir, map[string]string{
-			// TODO: Scaling Policy Target race condition on resource Id (despite `resource_id = "table/${aws_dynamodb_table.Table_CD117FA1.name}" containing resource reference)
-			".*No scalable target registered for service namespace: dynamodb.*": "Failed due to eventual consistency between AutoScaling and DynamoDb services.",
-		})
-	})
-	test_structure.RunTestStage(t, "validate", func() {
-		validate(t, tfWorkingDir, awsRegion)
-	})
-	test_structure.RunTestStage(t, "load_test", func() {
-		loadTest(t, tfWorkingDir, awsRegion)
-	})
+	util.NewRunner(testApp,
+		integ.WithRegion(awsRegion),
+		integ.WithRetryableErrors(storageRetryableErrors),
+		integ.WithStageAfterValidate("load_test", func(t *testing.T, r *integ.Runner) {
+			loadTest(t, r.TfWorkingDir, r.Region)
+		}),
+	).Run(t, validate)
 }
 
 // Utlity Functions //
@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/terraconstructs/base/integ"
+	tftemplate "github.com/terraconstructs/base/integ/template"
+)
+
+// injectRunTags adds the run tags to the default tags of the synthesized stack, so cmd/integ-sweeper
+// finds the resources if cleanup fails. See runCreatedAt for the creation time.
+func injectRunTags(t *testing.T, testApp, tfWorkingDir string, env map[string]string) {
+	tags := integ.RunTags(testApp, env[integ.RunIDEnv], runCreatedAt(t, tfWorkingDir))
+	if err := integ.InjectDefaultTagsE(filepath.Join(tfWorkingDir, tftemplate.StackFile), tags); err != nil {
+		t.Fatal("Failed to inject default tags", err)
+	}
+}
+
+// runCreatedAt returns the creation time of the deployed app. It is saved on the first synth of a run and kept
+// for re-synths, so they do not update the tags of every resource, until retireCreatedAt after a successful cleanup.
+func runCreatedAt(t *testing.T, tfWorkingDir string) time.Time {
+	createdAt := time.Now()
+	if test_structure.IsTestDataPresent(t, formatCreatedAtPath(tfWorkingDir)) {
+		test_structure.LoadTestData(t, formatCreatedAtPath(tfWorkingDir), &createdAt)
+	} else {
+		test_structure.SaveTestData(t, formatCreatedAtPath(tfWorkingDir), true, createdAt)
+	}
+	return createdAt
+}
+
+// retireCreatedAt moves the creation time of the app destroyed by UndeployUsingTerraform to the test data of the leak check,
+// so the next run records its own creation time
+func retireCreatedAt(t *testing.T, tfWorkingDir string) {
+	err := os.Rename(formatCreatedAtPath(tfWorkingDir), formatDestroyedCreatedAtPath(tfWorkingDir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Failed to retire the creation time of %s: %v", tfWorkingDir, err)
+	}
+}
+
+func formatCreatedAtPath(testFolder string) string {
+	return test_structure.FormatTestDataPath(testFolder, "created-at.json")
+}
+
+func formatDestroyedCreatedAtPath(testFolder string) string {
+	return test_structure.FormatTestDataPath(testFolder, "destroyed-created-at.json")
+}
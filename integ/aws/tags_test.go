@@ -0,0 +1,41 @@
+package aws
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+)
+
+func TestRunCreatedAt(t *testing.T) {
+	tfWorkingDir := filepath.Join(t.TempDir(), "tf", "sqs")
+	// left by an earlier run of the app
+	old := time.Date(2026, 10, 2, 9, 12, 3, 0, time.UTC)
+	test_structure.SaveTestData(t, formatCreatedAtPath(tfWorkingDir), true, old)
+
+	// re-synths of the deployed app keep the creation time
+	if createdAt := runCreatedAt(t, tfWorkingDir); !createdAt.Equal(old) {
+		t.Errorf("Expected the creation time %s of the deployed app, got %s", old, createdAt)
+	}
+
+	retireCreatedAt(t, tfWorkingDir)
+	var destroyed time.Time
+	test_structure.LoadTestData(t, formatDestroyedCreatedAtPath(tfWorkingDir), &destroyed)
+	if !destroyed.Equal(old) {
+		t.Errorf("Expected the leak check to use the creation time %s, got %s", old, destroyed)
+	}
+
+	// the next run records its own creation time
+	start := time.Now()
+	createdAt := runCreatedAt(t, tfWorkingDir)
+	if createdAt.Before(start) {
+		t.Errorf("Expected a creation time after %s for the new run, got %s", start, createdAt)
+	}
+	if again := runCreatedAt(t, tfWorkingDir); !again.Equal(createdAt) {
+		t.Errorf("Expected the creation time %s of the new run, got %s", createdAt, again)
+	}
+
+	// the app was never synthesized
+	retireCreatedAt(t, filepath.Join(t.TempDir(), "tf", "sns"))
+}
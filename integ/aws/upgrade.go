@@ -0,0 +1,76 @@
+package aws
+
+import (
+	"os"
+	"testing"
+
+	"github.com/terraconstructs/base/integ"
+)
+
+const (
+	// UpgradeFromVersionEnv overrides the terraconstructs release upgraded from, i.e. UPGRADE_FROM_VERSION=0.1.0
+	UpgradeFromVersionEnv = "UPGRADE_FROM_VERSION"
+	// DefaultUpgradeFromVersion is the npm dist tag of the latest terraconstructs release
+	DefaultUpgradeFromVersion = "latest"
+
+	// Stages added by WithUpgradeFrom
+	StagePinRelease      = "pin_release"
+	StageUpgradeApp      = "upgrade_app"
+	StageValidateUpgrade = "validate_upgrade"
+)
+
+// UpgradeFromVersion returns the terraconstructs release to upgrade from, UPGRADE_FROM_VERSION or the latest release
+func UpgradeFromVersion() string {
+	if version := os.Getenv(UpgradeFromVersionEnv); version != "" {
+		return version
+	}
+	return DefaultUpgradeFromVersion
+}
+
+// WithUpgradeFrom tests upgrading the app from a terraconstructs release to the local lib.
+//
+// The app is synthesized and deployed with version pinned through SaveSynthDependencies, the "upgrade_app" stage
+// re-synthesizes it with the local lib after validate, and the "validate_upgrade" stage plans the upgrade
+// and enforces policy, NoReplacePolicy if nil. This catches logical ID changes that would replace resources on upgrade.
+//
+// The app uses its own working directory (tf/<app>-upgrade) and ENVIRONMENT_NAME (upgrade),
+// so it can run next to the regular test of the app.
+func WithUpgradeFrom(version string, policy *PlanPolicy) integ.RunnerOption {
+	if policy == nil {
+		policy = NoReplacePolicy
+	}
+	return func(r *integ.Runner) {
+		for _, opt := range []integ.RunnerOption{
+			integ.WithTfWorkingDirSuffix("upgrade"),
+			integ.WithEnv(map[string]string{"ENVIRONMENT_NAME": "upgrade"}),
+			integ.WithStageBeforeSynth(StagePinRelease, func(t *testing.T, r *integ.Runner) {
+				terratestLogger.Logf(t, "Pinning terraconstructs %s for %s", version, r.TestApp)
+				setSynthDependency(t, r.TfWorkingDir, terraconstructsPackage, version)
+			}),
+			integ.WithStageAfterValidate(StageUpgradeApp, func(t *testing.T, r *integ.Runner) {
+				setSynthDependency(t, r.TfWorkingDir, terraconstructsPackage, "")
+				r.Synth(t, nil)
+			}),
+			integ.WithStageAfterValidate(StageValidateUpgrade, func(t *testing.T, r *integ.Runner) {
+				ReplanUsingTerraform(t, r.TfWorkingDir, policy)
+			}),
+		} {
+			opt(r)
+		}
+	}
+}
+
+// setSynthDependency updates the saved synth dependencies of the app, an empty version removes the dependency
+func setSynthDependency(t *testing.T, tfWorkingDir, name, version string) {
+	var dependencies map[string]string
+	LoadSynthDependencies(t, tfWorkingDir, &dependencies)
+	if dependencies == nil {
+		dependencies = make(map[string]string)
+	}
+	if version == "" {
+		delete(dependencies, name)
+	} else {
+		dependencies[name] = version
+	}
+	SaveSynthDependencies(t, tfWorkingDir, &dependencies)
+}
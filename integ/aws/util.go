@@ -9,8 +9,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	loggers "github.com/gruntwork-io/terratest/modules/logger"
@@ -19,6 +21,10 @@ import (
 	tfjson "github.com/hashicorp/terraform-json"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
+	"github.com/terraconstructs/base/integ"
+	"github.com/terraconstructs/base/integ/synthcache"
+	"github.com/terraconstructs/base/integ/synthqueue"
+	tftemplate "github.com/terraconstructs/base/integ/template"
 	"github.com/terraconstructs/go-synth"
 	"github.com/terraconstructs/go-synth/executors"
 	"github.com/terraconstructs/go-synth/models"
@@ -33,6 +39,10 @@ const (
 	repoRoot = "../../../"
 	// copy the root as relative Path for bun install
 	relPath = "./terraconstructs"
+	// package name of the local lib in synth apps
+	terraconstructsPackage = "terraconstructs"
+	// time left to fail the test when a synth is canceled on the test deadline
+	synthDeadlineReserve = time.Minute
 )
 
 var (
@@ -56,14 +66,17 @@ var (
 )
 
 // Synth app relative to the integration namespace
+//
+// If the namespace has a golden file for the stack (golden/<tfWorkingDir base>.tf.json),
+// the synthesized stack is compared against it before any deploy. Set UPDATE_GOLDEN=true to (re)generate it.
+// The stack is read from cdktf.out/stacks/<STACK_NAME>, STACK_NAME defaults to testApp.
+//
+// Synthesized stacks are cached by the hash of the app, the compiled lib, the package manifests, the dependencies
+// with their dist tags resolved, the env passed to the synth and the assets, see synthcache.
+// Set FRESH_SYNTH=true to synth with the executor anyway.
 func SynthApp(t *testing.T, testApp, tfWorkingDir string, env map[string]string, additionalAsset ...string) {
-	zapLogger := ForwardingLogger(t, terratestLogger)
-	ctx := context.Background()
 	// path from integ/aws/*/apps/*.ts to repo root src
 	mainPathToSrc := filepath.Join("..", repoRoot, "src")
-	if _, err := os.Stat(filepath.Join(repoRoot, "lib")); err != nil {
-		t.Fatal("No lib folder, run pnpm compile before go test")
-	}
 	mainTsFile := filepath.Join("apps", testApp+".ts")
 	mainTsBytes, err := os.ReadFile(mainTsFile)
 	if err != nil {
@@ -76,7 +89,123 @@ func SynthApp(t *testing.T, testApp, tfWorkingDir string, env map[string]string,
 	if synthDependencies == nil {
 		synthDependencies = make(map[string]string)
 	}
-	synthDependencies["terraconstructs"] = relPath
+	// a pinned terraconstructs release replaces the local lib, see WithUpgradeFrom
+	pinnedVersion, pinned := synthDependencies[terraconstructsPackage]
+	if pinned {
+		terratestLogger.Logf(t, "[INFORMATION] Synth with terraconstructs %s instead of the local lib", pinnedVersion)
+	} else {
+		if _, err := os.Stat(filepath.Join(repoRoot, "lib")); err != nil {
+			t.Fatal("No lib folder, run pnpm compile before go test")
+		}
+		synthDependencies[terraconstructsPackage] = relPath
+	}
+
+	// replace the path to src with relative package "terraconstructs"
+	mainTs := strings.ReplaceAll(string(mainTsBytes), mainPathToSrc, terraconstructsPackage)
+	stackName := env["STACK_NAME"]
+	if stackName == "" {
+		stackName = testApp
+	}
+	inputs := synthcache.Inputs{
+		App:          []byte(mainTs),
+		Manifests:    []string{filepath.Join(repoRoot, "package.json"), filepath.Join(repoRoot, "pnpm-lock.yaml")},
+		Dependencies: synthDependencies,
+		Resolver:     synthResolver(),
+		Env:          env,
+	}
+	if !pinned {
+		inputs.Lib = filepath.Join(repoRoot, "lib")
+	}
+	if _, err := os.Stat(filepath.Join("apps", "cdktf.json")); err == nil {
+		inputs.Assets = append(inputs.Assets, filepath.Join("apps", "cdktf.json"))
+	}
+	for _, assetName := range additionalAsset {
+		inputs.Assets = append(inputs.Assets, filepath.Join("apps", assetName))
+	}
+	cache, key := synthCacheKey(t, inputs)
+	if !restoreCachedStack(t, cache, key, tfWorkingDir) {
+		synthStack(t, mainTs, "cdktf.out/stacks/"+stackName, tfWorkingDir, env, synthDependencies, pinned, additionalAsset)
+		if cache != nil {
+			if err := cache.SaveE(key, tfWorkingDir); err != nil {
+				terratestLogger.Logf(t, "[WARNING] Failed to cache stack of %s: %v", testApp, err)
+			}
+		}
+	}
+	// golden files capture the local lib, without run ID suffixed names
+	switch {
+	case pinned:
+	case env[integ.RunIDEnv] != "":
+		terratestLogger.Logf(t, "[INFORMATION] Skipping golden file check for run %s", env[integ.RunIDEnv])
+	default:
+		matchGolden(t, testApp, tfWorkingDir)
+	}
+	// tag after the golden check, the creation time changes with every run
+	injectRunTags(t, testApp, tfWorkingDir, env)
+}
+
+// synthResolver resolves dist tags of pinned releases once per process, see WithUpgradeFrom
+var synthResolver = sync.OnceValue(synthcache.DefaultResolver)
+
+// pruneSynthCache evicts stale entries of the synth cache once per process
+var pruneSynthCache sync.Once
+
+// synthCacheKey returns the synth cache and the key of inputs, a nil cache disables caching
+func synthCacheKey(t *testing.T, inputs synthcache.Inputs) (*synthcache.Cache, string) {
+	cache, err := synthcache.Default()
+	if err != nil {
+		terratestLogger.Logf(t, "[WARNING] Synth cache disabled: %v", err)
+		return nil, ""
+	}
+	pruneSynthCache.Do(func() {
+		if err := cache.PruneE(); err != nil {
+			terratestLogger.Logf(t, "[WARNING] Failed to prune synth cache %s: %v", cache.Dir, err)
+		}
+	})
+	key, err := inputs.KeyE()
+	if err != nil {
+		terratestLogger.Logf(t, "[WARNING] Synth cache disabled: %v", err)
+		return nil, ""
+	}
+	return cache, key
+}
+
+// restoreCachedStack restores the stack cached for key, it returns false if the app must be synthesized
+func restoreCachedStack(t *testing.T, cache *synthcache.Cache, key, tfWorkingDir string) bool {
+	if cache == nil {
+		return false
+	}
+	if synthcache.Fresh() {
+		terratestLogger.Logf(t, "[INFORMATION] %s is set, ignoring cached stack %s", synthcache.FreshEnv, key)
+		return false
+	}
+	restored, err := cache.RestoreE(key, tfWorkingDir)
+	if err != nil {
+		terratestLogger.Logf(t, "[WARNING] Failed to restore cached stack %s: %v", key, err)
+		return false
+	}
+	if restored {
+		terratestLogger.Logf(t, "[INFORMATION] Restored cached stack %s into %s", key, tfWorkingDir)
+	}
+	return restored
+}
+
+// synthStack synthesizes mainTs with the bun executor and copies stackDir of the synth app to tfWorkingDir.
+//
+// Synths wait for a slot of the process-wide synthqueue scheduler, set SYNTH_CONCURRENCY to change the limit.
+// Waiting and synthesizing stop synthDeadlineReserve before the test times out.
+func synthStack(t *testing.T, mainTs, stackDir, tfWorkingDir string, env, synthDependencies map[string]string, pinned bool, additionalAsset []string) {
+	zapLogger := ForwardingLogger(t, terratestLogger)
+	ctx := integ.DeadlineContext(t, synthDeadlineReserve)
+	scheduler := synthqueue.Default()
+	if scheduler.Running() >= scheduler.Max() {
+		terratestLogger.Logf(t, "[INFORMATION] Waiting for a synth slot, %d running and %d queued", scheduler.Running(), scheduler.Queued())
+	}
+	release, err := scheduler.AcquireE(ctx)
+	if err != nil {
+		t.Fatalf("Synth of %s canceled: %v", tfWorkingDir, err)
+	}
+	defer release()
+	env = withSynthLayer(t, ctx, env, synthDependencies, pinned)
 
 	thisFs := afero.NewOsFs()
 	app := synth.NewApp(executors.NewBunExecutor, zapLogger)
@@ -94,8 +223,8 @@ func SynthApp(t *testing.T, testApp, tfWorkingDir string, env map[string]string,
 			for _, assetName := range additionalAsset {
 				// stat if asset is directory or file
 				relAsset := filepath.Join("apps", assetName)
-				var info os.FileInfo
-				if info, err = os.Stat(relAsset); err != nil {
+				info, err := os.Stat(relAsset)
+				if err != nil {
 					return fmt.Errorf("failed to stat %s: %w", relAsset, err)
 				}
 				// if it is a directory, copy it to the synth app fs
@@ -112,18 +241,76 @@ func SynthApp(t *testing.T, testApp, tfWorkingDir string, env map[string]string,
 					}
 				}
 			}
+			if pinned {
+				return nil
+			}
 			return e.CopyFrom(ctx, thisFs, repoRoot, relPath, defaultCopyOptions)
 		},
 		Dependencies: synthDependencies,
 	})
-	// replace the path to src with relative package "terraconstructs"
-	mainTs := strings.ReplaceAll(string(mainTsBytes), mainPathToSrc, "terraconstructs")
-	err = app.Eval(ctx, thisFs, mainTs, "cdktf.out/stacks/"+testApp, tfWorkingDir)
+	err = app.Eval(ctx, thisFs, mainTs, stackDir, tfWorkingDir)
 	if err != nil {
 		t.Fatal("Failed to synth app", err)
 	}
 }
 
+var (
+	synthLayers     *synthqueue.Layers
+	synthLayersErr  error
+	synthLayersOnce sync.Once
+)
+
+// withSynthLayer pre-installs the synth dependencies in a layer shared by all synths of the process and returns env
+// pointing the executor install to the layer cache. If the layer fails, the executor installs the dependencies itself.
+func withSynthLayer(t *testing.T, ctx context.Context, env, synthDependencies map[string]string, pinned bool) map[string]string {
+	if _, ok := env[synthqueue.InstallCacheEnv]; ok {
+		return env
+	}
+	synthLayersOnce.Do(func() {
+		synthLayers, synthLayersErr = synthqueue.DefaultLayers()
+	})
+	if synthLayersErr != nil {
+		terratestLogger.Logf(t, "[WARNING] Synth layer disabled: %v", synthLayersErr)
+		return env
+	}
+	dependencies := synthDependencies
+	if !pinned {
+		var err error
+		if dependencies, err = synthqueue.LayerDependenciesE(repoRoot, terraconstructsPackage, synthDependencies); err != nil {
+			terratestLogger.Logf(t, "[WARNING] Synth layer disabled: %v", err)
+			return env
+		}
+	}
+	layer, err := synthLayers.PrepareE(ctx, dependencies)
+	if err != nil {
+		terratestLogger.Logf(t, "[WARNING] Failed to prepare synth layer: %v", err)
+		return env
+	}
+	terratestLogger.Logf(t, "[INFORMATION] Installing synth dependencies from layer %s", layer)
+	layerEnv := make(map[string]string, len(env)+1)
+	for k, v := range env {
+		layerEnv[k] = v
+	}
+	for k, v := range synthLayers.Env() {
+		layerEnv[k] = v
+	}
+	return layerEnv
+}
+
+// matchGolden compares the stack synthesized into tfWorkingDir against the golden file of testApp, if any
+func matchGolden(t *testing.T, testApp, tfWorkingDir string) {
+	goldenFile := tftemplate.GoldenFile(testApp)
+	if _, err := os.Stat(goldenFile); err != nil && !tftemplate.UpdatingGolden() {
+		return
+	}
+	if err := tftemplate.FromStack(t, tfWorkingDir).MatchGoldenE(goldenFile); err != nil {
+		t.Fatal(err)
+	}
+	if tftemplate.UpdatingGolden() {
+		terratestLogger.Logf(t, "Updated golden file %s", goldenFile)
+	}
+}
+
 // SaveSynthDependencies serializes and saves map of dependencies at test time to the given path.
 func SaveSynthDependencies(t *testing.T, testFolder string, dependencies *map[string]string) {
 	path := formatSynthDependenciesPath(testFolder)
@@ -145,12 +332,20 @@ func formatSynthDependenciesPath(testFolder string) string {
 	return test_structure.FormatTestDataPath(testFolder, "app-dependencies.json")
 }
 
+// DeployUsingTerraform deploys the synthesized stack in workingDir with the binary of TerraformBinary
 func DeployUsingTerraform(t *testing.T, workingDir string, additionalRetryableErrors map[string]string) {
+	DeployUsingBinary(t, "", workingDir, additionalRetryableErrors)
+}
+
+// DeployUsingBinary deploys the synthesized stack in workingDir with the OpenTofu or Terraform binary,
+// see TerraformBinary. Providers are installed from a plugin cache shared by all tests, see integ.PluginCacheEnvE.
+func DeployUsingBinary(t *testing.T, binary, workingDir string, additionalRetryableErrors map[string]string) {
 	// Construct the terraform options with default retryable errors to handle the most common retryable errors in
 	// terraform testing.
 	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
 		TerraformDir:    workingDir,
-		TerraformBinary: "tofu",
+		TerraformBinary: TerraformBinary(t, binary),
+		EnvVars:         pluginCacheEnv(t),
 	})
 
 	for k, v := range additionalRetryableErrors {
@@ -159,31 +354,98 @@ func DeployUsingTerraform(t *testing.T, workingDir string, additionalRetryableEr
 
 	// Save the Terraform Options struct, so future test stages can use it
 	test_structure.SaveTerraformOptions(t, workingDir, terraformOptions)
-	terraform.InitAndApply(t, terraformOptions)
+	initTerraform(t, terraformOptions)
+	terraform.Apply(t, terraformOptions)
+	integ.ReloadOutputs(workingDir)
+}
+
+var (
+	binariesMu sync.Mutex
+	binaries   = map[string]*integ.Binary{}
+)
+
+// TerraformBinary returns the path of the binary name, or of the INTEG_TF_BINARY binary if name is empty, or else
+// of tofu or terraform, whichever is found on $PATH first. This fails the test if the binary is missing or too old,
+// see integ.FindBinaryE.
+func TerraformBinary(t *testing.T, name string) string {
+	b, err := terraformBinaryE(t, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func terraformBinaryE(t *testing.T, name string) (string, error) {
+	binariesMu.Lock()
+	defer binariesMu.Unlock()
+	if b, ok := binaries[name]; ok {
+		return b.Path, nil
+	}
+	b, err := integ.FindBinaryE(name)
+	if err != nil {
+		return "", err
+	}
+	terratestLogger.Logf(t, "[INFORMATION] Using %s", b)
+	binaries[name] = b
+	return b.Path, nil
+}
+
+// pluginCacheEnv returns the plugin cache environment, empty if the cache is not available
+func pluginCacheEnv(t *testing.T) map[string]string {
+	env, err := integ.PluginCacheEnvE()
+	if err != nil {
+		terratestLogger.Logf(t, "[WARNING] Plugin cache disabled: %v", err)
+		return map[string]string{}
+	}
+	return env
+}
+
+// initTerraform runs init, holding the providers of the working directory in the plugin cache, so concurrent
+// inits do not install the same provider into the cache at once, see integ.LockProvidersE
+func initTerraform(t *testing.T, terraformOptions *terraform.Options) {
+	if err := initTerraformE(t, terraformOptions); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func initTerraformE(t *testing.T, terraformOptions *terraform.Options) error {
+	if pluginCacheDir := terraformOptions.EnvVars[integ.PluginCacheEnv]; pluginCacheDir != "" {
+		unlock, err := integ.LockProvidersE(pluginCacheDir, terraformOptions.TerraformDir)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+	_, err := terraform.InitE(t, terraformOptions)
+	return err
 }
 
 func UndeployUsingTerraform(t *testing.T, workingDir string) {
 	terraformOptions := test_structure.LoadTerraformOptions(t, workingDir)
 	terraform.Destroy(t, terraformOptions)
+	integ.ReloadOutputs(workingDir)
+	retireCreatedAt(t, workingDir)
 }
 
-// ReplaceTerraformResource replaces a Terraform resource in the given working directory by running a terraform apply command
-// with the -replace flag. This is useful for triggering a re-deployment of a resource without changing its configuration.
-// It fails the test if the resource cannot be found or if the apply command fails.
-func ReplaceTerraformResource(t *testing.T, workingDir, resourceType, resourceName string) {
+// ReplaceTerraformResource replaces the Terraform resource matching filter in the given working directory by running a
+// terraform apply command with the -replace flag. This is useful for triggering a re-deployment of a resource without
+// changing its configuration. It fails the test unless exactly one resource matches or if the apply command fails.
+func ReplaceTerraformResource(t *testing.T, workingDir string, filter integ.StateFilter) {
 	terraformOptions := test_structure.LoadTerraformOptions(t, workingDir)
-	// find the resource to replace
-	deploymentResource := FindResourceByType(t, workingDir, resourceType, resourceName)
-	require.NotEmpty(t, deploymentResource, "Expected to find an API Gateway deployment resource")
-	replaceArg := fmt.Sprintf("-replace=%s", deploymentResource)
+	// find the resource to replace by its full address
+	resource := integ.LoadState(t, terraformOptions).FindOne(t, filter)
+	replaceArg := fmt.Sprintf("-replace=%s", resource.Address)
 
-	// trigger a re-deployment of the API Gateway
 	terraform.RunTerraformCommand(t, terraformOptions, terraform.FormatArgs(terraformOptions, "apply", "-input=false", "-auto-approve", replaceArg)...)
+	// outputs may change with the replaced resource
+	integ.ReloadOutputs(workingDir)
 }
 
 // FindResourceByType searches for a resource of a specific type in the given output from terraform list command.
 // resourceName is optional and can be used to further filter the results.
 // It returns the first matching resource or fails the test if no matching resource is found.
+//
+// Deprecated: types and names are matched as substrings, use integ.LoadState with an exact integ.StateFilter instead.
 func FindResourceByType(t *testing.T, workingDir, resourceType, resourceName string) string {
 	terraformOptions := test_structure.LoadTerraformOptions(t, workingDir)
 	resources := terraform.RunTerraformCommand(t, terraformOptions, "state", "list")
@@ -205,12 +467,9 @@ func FindResourceByType(t *testing.T, workingDir, resourceType, resourceName str
 	return ""
 }
 
-// LoadOutputAttribute loads the attribute of a output key from Terraform outputs and ensures it is not empty.
+// LoadOutputAttribute loads the attribute of a output key from the cached Terraform outputs and ensures it is not empty.
 func LoadOutputAttribute(t *testing.T, terraformOptions *terraform.Options, key, attribute string) string {
-	outputs := terraform.OutputMap(t, terraformOptions, key)
-	value := outputs[attribute]
-	require.NotEmpty(t, value, fmt.Sprintf("Output %s.%s should not be empty", key, attribute))
-	return value
+	return integ.LoadOutputs(terraformOptions).Attribute(t, key, attribute)
 }
 
 // URLDecode decodes a URL-encoded string.
@@ -0,0 +1,236 @@
+package integ
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	// BinaryEnv selects the IaC binary, i.e. INTEG_TF_BINARY=terraform, see FindBinaryE
+	BinaryEnv = "INTEG_TF_BINARY"
+	// PluginCacheEnv is the provider plugin cache shared by all working directories, see PluginCacheEnvE
+	PluginCacheEnv = "TF_PLUGIN_CACHE_DIR"
+
+	// without it, init only uses cached providers already recorded in the dependency lock file,
+	// the fresh working directories of tests have none
+	pluginCacheBreakLockFileEnv = "TF_PLUGIN_CACHE_MAY_BREAK_DEPENDENCY_LOCK_FILE"
+)
+
+// DefaultBinaries are looked up on $PATH in order if no binary is selected
+var DefaultBinaries = []string{"tofu", "terraform"}
+
+// MinVersions by product, the oldest releases the harness is tested with
+var MinVersions = map[string]string{
+	"OpenTofu":  "1.6.0",
+	"Terraform": "1.5.0",
+}
+
+var binaryVersionPattern = regexp.MustCompile(`^(OpenTofu|Terraform) v(\S+)`)
+
+// Binary is an OpenTofu or Terraform binary
+type Binary struct {
+	Path    string
+	Product string // "OpenTofu" or "Terraform"
+	Version *version.Version
+}
+
+func (b *Binary) String() string {
+	return fmt.Sprintf("%s %s at %s", b.Product, b.Version, b.Path)
+}
+
+// FindBinaryE looks up the binary name on $PATH, or the INTEG_TF_BINARY binary if name is empty,
+// or else the first of DefaultBinaries. The version of the binary must be at least its MinVersions entry.
+func FindBinaryE(name string) (*Binary, error) {
+	if name == "" {
+		name = os.Getenv(BinaryEnv)
+	}
+	if name == "" {
+		for _, candidate := range DefaultBinaries {
+			if _, err := exec.LookPath(candidate); err == nil {
+				name = candidate
+				break
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("none of %s found on $PATH, install OpenTofu or Terraform, or set %s to the binary",
+				strings.Join(DefaultBinaries, ", "), BinaryEnv)
+		}
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found on $PATH, install it or set %s to another binary: %w", name, BinaryEnv, err)
+	}
+	b, err := binaryVersionE(path)
+	if err != nil {
+		return nil, err
+	}
+	if minVersion, ok := MinVersions[b.Product]; ok && b.Version.LessThan(version.Must(version.NewVersion(minVersion))) {
+		return nil, fmt.Errorf("%s is older than the minimum version %s", b, minVersion)
+	}
+	return b, nil
+}
+
+// binaryVersionE detects the product and version from the first line of `<path> version`, i.e. "OpenTofu v1.8.2"
+func binaryVersionE(path string) (*Binary, error) {
+	cmd := exec.Command(path, "version")
+	// skip the upgrade check of terraform
+	cmd.Env = append(os.Environ(), "CHECKPOINT_DISABLE=1")
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("error running %s version: %v\n%s", path, err, exitErr.Stderr)
+		}
+		return nil, fmt.Errorf("error running %s version: %v", path, err)
+	}
+	firstLine, _, _ := strings.Cut(string(output), "\n")
+	match := binaryVersionPattern.FindStringSubmatch(strings.TrimSpace(firstLine))
+	if match == nil {
+		return nil, fmt.Errorf("error detecting the version of %s, unexpected output %q", path, firstLine)
+	}
+	v, err := version.NewVersion(match[2])
+	if err != nil {
+		return nil, fmt.Errorf("error detecting the version of %s: %v", path, err)
+	}
+	return &Binary{Path: path, Product: match[1], Version: v}, nil
+}
+
+// PluginCacheEnvE returns the environment sharing a provider plugin cache between working directories,
+// so parallel tests download each provider once. The cache is TF_PLUGIN_CACHE_DIR, defaulting to
+// terraconstructs/plugin-cache in the user cache directory.
+func PluginCacheEnvE() (map[string]string, error) {
+	dir := os.Getenv(PluginCacheEnv)
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("error finding the user cache directory, set %s: %v", PluginCacheEnv, err)
+		}
+		dir = filepath.Join(userCacheDir, "terraconstructs", "plugin-cache")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		PluginCacheEnv:              dir,
+		pluginCacheBreakLockFileEnv: "true",
+	}, nil
+}
+
+// RequiredProvidersE returns the sources of the providers the JSON configuration in dir requires, i.e. hashicorp/aws,
+// from `terraform.required_providers` and the `provider` blocks of its *.tf.json files
+func RequiredProvidersE(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf.json"))
+	if err != nil {
+		return nil, err
+	}
+	sources := map[string]bool{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var config struct {
+			Terraform struct {
+				RequiredProviders map[string]json.RawMessage `json:"required_providers"`
+			} `json:"terraform"`
+			Provider map[string]json.RawMessage `json:"provider"`
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("error reading the providers of %s: %v", path, err)
+		}
+		required := map[string]bool{}
+		for name, raw := range config.Terraform.RequiredProviders {
+			var requirement struct {
+				Source string `json:"source"`
+			}
+			// legacy requirements are a version constraint string
+			_ = json.Unmarshal(raw, &requirement)
+			sources[providerSource(name, requirement.Source)] = true
+			required[name] = true
+		}
+		for name := range config.Provider {
+			if !required[name] {
+				sources[providerSource(name, "")] = true
+			}
+		}
+	}
+	result := make([]string, 0, len(sources))
+	for source := range sources {
+		result = append(result, source)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// providerSource returns the namespace and type of the provider source, the registry host does not matter to the lock
+func providerSource(name, source string) string {
+	if source == "" {
+		source = name
+	}
+	parts := strings.Split(strings.ToLower(source), "/")
+	switch len(parts) {
+	case 1:
+		return "hashicorp/" + parts[0]
+	case 3:
+		return parts[1] + "/" + parts[2]
+	}
+	return strings.Join(parts, "/")
+}
+
+// LockProvidersE locks the providers the configuration in dir requires in the plugin cache, so concurrent inits,
+// of this process or others sharing the cache, do not install the same provider at once. Inits of other providers
+// run concurrently, an init whose providers are unknown locks the whole cache. It returns the function releasing the
+// locks, held in the pluginCacheDir.locks directory.
+func LockProvidersE(pluginCacheDir, dir string) (func(), error) {
+	locksDir := filepath.Clean(pluginCacheDir) + ".locks"
+	if err := os.MkdirAll(locksDir, 0o755); err != nil {
+		return nil, err
+	}
+	var files []*os.File
+	unlock := func() {
+		for i := len(files) - 1; i >= 0; i-- {
+			syscall.Flock(int(files[i].Fd()), syscall.LOCK_UN)
+			files[i].Close()
+		}
+	}
+	lock := func(name string, how int) error {
+		f, err := os.OpenFile(filepath.Join(locksDir, name+".lock"), os.O_CREATE|os.O_RDWR, 0o644)
+		if err != nil {
+			return err
+		}
+		if err := syscall.Flock(int(f.Fd()), how); err != nil {
+			f.Close()
+			return err
+		}
+		files = append(files, f)
+		return nil
+	}
+
+	providers, err := RequiredProvidersE(dir)
+	how := syscall.LOCK_SH
+	if err != nil || len(providers) == 0 {
+		how, providers = syscall.LOCK_EX, nil
+	}
+	if err := lock("all", how); err != nil {
+		unlock()
+		return nil, err
+	}
+	// sorted, so inits sharing providers do not wait for each other in a cycle
+	for _, provider := range providers {
+		if err := lock(strings.ReplaceAll(provider, "/", "_"), syscall.LOCK_EX); err != nil {
+			unlock()
+			return nil, err
+		}
+	}
+	return unlock, nil
+}
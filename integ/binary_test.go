@@ -0,0 +1,171 @@
+package integ
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBinaries puts scripts printing the version output of each binary on an isolated $PATH
+func fakeBinaries(t *testing.T, versions map[string]string) string {
+	dir := t.TempDir()
+	for name, output := range versions {
+		script := "#!/bin/sh\necho '" + output + "'\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755))
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv(BinaryEnv, "")
+	return dir
+}
+
+func TestFindBinaryE_Default(t *testing.T) {
+	dir := fakeBinaries(t, map[string]string{
+		"tofu":      "OpenTofu v1.8.2\non linux_amd64",
+		"terraform": "Terraform v1.9.5\non linux_amd64",
+	})
+	b, err := FindBinaryE("")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "tofu"), b.Path)
+	assert.Equal(t, "OpenTofu", b.Product)
+	assert.Equal(t, "1.8.2", b.Version.String())
+	assert.Equal(t, "OpenTofu 1.8.2 at "+filepath.Join(dir, "tofu"), b.String())
+}
+
+func TestFindBinaryE_FallsBackToTerraform(t *testing.T) {
+	dir := fakeBinaries(t, map[string]string{"terraform": "Terraform v1.9.5"})
+	b, err := FindBinaryE("")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "terraform"), b.Path)
+	assert.Equal(t, "Terraform", b.Product)
+}
+
+func TestFindBinaryE_Selected(t *testing.T) {
+	dir := fakeBinaries(t, map[string]string{
+		"tofu":      "OpenTofu v1.8.2",
+		"terraform": "Terraform v1.9.5",
+	})
+	t.Setenv(BinaryEnv, "terraform")
+	b, err := FindBinaryE("")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "terraform"), b.Path)
+
+	// the option wins over the env
+	b, err = FindBinaryE("tofu")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "tofu"), b.Path)
+}
+
+func TestFindBinaryE_NotFound(t *testing.T) {
+	fakeBinaries(t, nil)
+	_, err := FindBinaryE("")
+	assert.EqualError(t, err, "none of tofu, terraform found on $PATH, install OpenTofu or Terraform, or set INTEG_TF_BINARY to the binary")
+
+	t.Setenv(BinaryEnv, "terraform")
+	_, err = FindBinaryE("")
+	assert.ErrorContains(t, err, "terraform not found on $PATH, install it or set INTEG_TF_BINARY to another binary")
+}
+
+func TestFindBinaryE_MinVersion(t *testing.T) {
+	dir := fakeBinaries(t, map[string]string{
+		"tofu":      "OpenTofu v1.5.7",
+		"terraform": "Terraform v1.6.0-beta1",
+	})
+	_, err := FindBinaryE("tofu")
+	assert.EqualError(t, err, "OpenTofu 1.5.7 at "+filepath.Join(dir, "tofu")+" is older than the minimum version 1.6.0")
+	b, err := FindBinaryE("terraform")
+	require.NoError(t, err)
+	assert.Equal(t, "1.6.0-beta1", b.Version.String())
+}
+
+func TestFindBinaryE_UnknownVersion(t *testing.T) {
+	fakeBinaries(t, map[string]string{"tofu": "tofu wrapper"})
+	_, err := FindBinaryE("")
+	assert.ErrorContains(t, err, `unexpected output "tofu wrapper"`)
+}
+
+func TestPluginCacheEnvE(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "plugins")
+	t.Setenv(PluginCacheEnv, dir)
+	env, err := PluginCacheEnvE()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"TF_PLUGIN_CACHE_DIR":                            dir,
+		"TF_PLUGIN_CACHE_MAY_BREAK_DEPENDENCY_LOCK_FILE": "true",
+	}, env)
+	assert.DirExists(t, dir)
+}
+
+// writeStack writes a cdk.tf.json requiring the providers into a new directory
+func writeStack(t *testing.T, stack string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cdk.tf.json"), []byte(stack), 0o644))
+	return dir
+}
+
+func TestRequiredProvidersE(t *testing.T) {
+	dir := writeStack(t, `{
+		"terraform": {"required_providers": {
+			"aws": {"source": "aws", "version": "5.84.0"},
+			"random": {"source": "registry.opentofu.org/hashicorp/random"},
+			"time": "~> 0.12"
+		}},
+		"provider": {"aws": [{"region": "us-east-1"}], "null": [{}]}
+	}`)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "override.tf.json"), []byte(`{"provider": {"archive": [{}]}}`), 0o644))
+	providers, err := RequiredProvidersE(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hashicorp/archive", "hashicorp/aws", "hashicorp/null", "hashicorp/random", "hashicorp/time"}, providers)
+
+	providers, err = RequiredProvidersE(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, providers)
+
+	_, err = RequiredProvidersE(writeStack(t, `{"terraform": [{}]}`))
+	assert.ErrorContains(t, err, "error reading the providers of")
+}
+
+// lockedWithin returns true if LockProvidersE returns within a short wait, releasing the lock
+func lockedWithin(t *testing.T, pluginCacheDir, dir string) bool {
+	locked := make(chan func(), 1)
+	go func() {
+		unlock, err := LockProvidersE(pluginCacheDir, dir)
+		assert.NoError(t, err)
+		locked <- unlock
+	}()
+	select {
+	case unlock := <-locked:
+		unlock()
+		return true
+	case <-time.After(200 * time.Millisecond):
+		// release the lock once acquired
+		go func() { (<-locked)() }()
+		return false
+	}
+}
+
+func TestLockProvidersE(t *testing.T) {
+	pluginCacheDir := filepath.Join(t.TempDir(), "plugins")
+	aws := writeStack(t, `{"terraform": {"required_providers": {"aws": {"source": "aws"}}}}`)
+	awsRandom := writeStack(t, `{"terraform": {"required_providers": {"aws": {"source": "hashicorp/aws"}, "random": {"source": "hashicorp/random"}}}}`)
+	random := writeStack(t, `{"terraform": {"required_providers": {"random": {"source": "hashicorp/random"}}}}`)
+	unknown := t.TempDir()
+
+	unlock, err := LockProvidersE(pluginCacheDir, aws)
+	require.NoError(t, err)
+	assert.DirExists(t, pluginCacheDir+".locks")
+	assert.True(t, lockedWithin(t, pluginCacheDir, random), "inits of other providers run concurrently")
+	assert.False(t, lockedWithin(t, pluginCacheDir, awsRandom), "inits sharing a provider wait")
+	assert.False(t, lockedWithin(t, pluginCacheDir, unknown), "inits of unknown providers wait for all others")
+	unlock()
+	assert.True(t, lockedWithin(t, pluginCacheDir, awsRandom))
+
+	unlock, err = LockProvidersE(pluginCacheDir, unknown)
+	require.NoError(t, err)
+	assert.False(t, lockedWithin(t, pluginCacheDir, random), "inits of unknown providers lock the whole cache")
+	unlock()
+	assert.True(t, lockedWithin(t, pluginCacheDir, random))
+}
@@ -51,6 +51,36 @@ help: ## Print out every target with a description
 	SKIP_synth_app=true SKIP_deploy_terraform=true SKIP_validate=true make $*
 .PHONY: %-cleanup-only
 
+## %-resume:                  Resume the last run after its succeeded stages, or at cleanup (i.e. foo-resume)
+%-resume:
+	INTEG_RESUME=true make $*
+.PHONY: %-resume
+
+## %-status:                  Print the stage journal of the last run without running it (i.e. foo-status)
+%-status:
+	INTEG_STATUS=true make $*
+.PHONY: %-status
+
+## %-break-lock:              Take over the lock of the working directory left by a killed run (i.e. foo-break-lock)
+%-break-lock:
+	INTEG_BREAK_LOCK=true make $*
+.PHONY: %-break-lock
+
+## %-unique:                  Run with a generated run ID, isolating names and state (i.e. foo-unique)
+%-unique:
+	INTEG_RUN_ID=auto make $*
+.PHONY: %-unique
+
+## %-fresh-synth:             Synth with the executor, ignoring cached stacks (i.e. foo-fresh-synth)
+%-fresh-synth:
+	FRESH_SYNTH=true make $*
+.PHONY: %-fresh-synth
+
+## %-update-golden:          Synth only and regenerate golden files (i.e. foo-update-golden)
+%-update-golden:
+	UPDATE_GOLDEN=true SKIP_deploy_terraform=true SKIP_validate=true SKIP_cleanup_terraform=true make $*
+.PHONY: %-update-golden
+
 clean: ## clean up temporary files (tf/*, apps/cdktf.out, /tmp/go-synth-*)
 	rm -rf tf/*
 	rm -rf apps/cdktf.out
@@ -0,0 +1,28 @@
+package integ
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// ErrTestDeadline is the cause of contexts canceled by DeadlineContext before the test times out
+var ErrTestDeadline = fmt.Errorf("test deadline reached")
+
+// DeadlineContext returns a context canceled reserve before the `go test -timeout` deadline of t, and when the test ends.
+// Work bound to the context stops with ErrTestDeadline as cause, leaving reserve to fail the test or clean up,
+// instead of the test binary panicking on the timeout. Without deadline, the context is only canceled when the test ends.
+func DeadlineContext(t *testing.T, reserve time.Duration) context.Context {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	t.Cleanup(func() {
+		cancel(context.Canceled)
+	})
+	deadline, ok := t.Deadline()
+	if !ok {
+		return ctx
+	}
+	ctx, cancelTimeout := context.WithDeadlineCause(ctx, deadline.Add(-reserve), ErrTestDeadline)
+	t.Cleanup(cancelTimeout)
+	return ctx
+}
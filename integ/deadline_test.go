@@ -0,0 +1,30 @@
+package integ
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadlineContext_Reserve(t *testing.T) {
+	if _, ok := t.Deadline(); !ok {
+		t.Skip("requires a go test -timeout")
+	}
+	// a reserve beyond the deadline cancels right away
+	ctx := DeadlineContext(t, 100*365*24*time.Hour)
+	<-ctx.Done()
+	assert.ErrorIs(t, context.Cause(ctx), ErrTestDeadline)
+}
+
+func TestDeadlineContext_TestEnd(t *testing.T) {
+	var ctx context.Context
+	t.Run("sub", func(t *testing.T) {
+		ctx = DeadlineContext(t, 0)
+		require.NoError(t, ctx.Err())
+	})
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.NotErrorIs(t, context.Cause(ctx), ErrTestDeadline)
+}
@@ -0,0 +1,160 @@
+package integ
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// jmesTag is the struct tag holding the JMESPath query of a field, i.e.
+//
+//	type queueOutputs struct {
+//		Url     string `jmes:"queue.url,required"`
+//		Timeout int    `jmes:"queue.visibilityTimeout,default=30"`
+//	}
+const jmesTag = "jmes"
+
+// DecodeE fills the fields of dst, a pointer to a struct, from the outputs using the `jmes` struct tags.
+// Fields tagged `required` must resolve to a value, `default=<value>` is used for missing values.
+// All missing and mistyped fields are reported in a single error.
+func (o *Outputs) DecodeE(t *testing.T, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode target must be a non-nil pointer to a struct, got %T", dst)
+	}
+	values, err := o.ValuesE(t)
+	if err != nil {
+		return err
+	}
+
+	var combinedErr error
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag, ok := field.Tag.Lookup(jmesTag)
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		query, required, defaultValue, hasDefault, err := parseJmesTag(tag)
+		if err == nil {
+			err = decodeField(rv.Field(i), values, query, required, defaultValue, hasDefault)
+		}
+		if err != nil {
+			combinedErr = multierror.Append(combinedErr, fmt.Errorf("field %s (%s): %v", field.Name, tag, err))
+		}
+	}
+	return combinedErr
+}
+
+// parseJmesTag splits `query[,required][,default=value]`, the default value must be last and may contain commas
+func parseJmesTag(tag string) (query string, required bool, defaultValue string, hasDefault bool, err error) {
+	parts := strings.Split(tag, ",")
+	query = strings.TrimSpace(parts[0])
+	if query == "" {
+		return "", false, "", false, fmt.Errorf("missing JMESPath query")
+	}
+	for i := 1; i < len(parts); i++ {
+		option := strings.TrimSpace(parts[i])
+		switch {
+		case option == "required":
+			required = true
+		case strings.HasPrefix(option, "default="):
+			defaultValue = strings.TrimPrefix(strings.Join(parts[i:], ","), "default=")
+			hasDefault = true
+			i = len(parts)
+		default:
+			return "", false, "", false, fmt.Errorf("unknown tag option %q", option)
+		}
+	}
+	if required && hasDefault {
+		return "", false, "", false, fmt.Errorf("required fields can not have a default")
+	}
+	return query, required, defaultValue, hasDefault, nil
+}
+
+func decodeField(fv reflect.Value, values map[string]any, query string, required bool, defaultValue string, hasDefault bool) error {
+	value, err := SearchJMESPath(query, values)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		switch {
+		case required:
+			return fmt.Errorf("required output is missing")
+		case hasDefault:
+			if err := assignValue(fv, defaultValue); err != nil {
+				return fmt.Errorf("invalid default: %v", err)
+			}
+		}
+		return nil
+	}
+	return assignValue(fv, value)
+}
+
+// assignValue sets fv to value, strings are parsed for boolean and numeric fields
+// as Terraform outputs often hold numbers as strings
+func assignValue(fv reflect.Value, value any) error {
+	target := fv
+	for target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+	if s, ok := value.(string); ok {
+		switch target.Kind() {
+		case reflect.String:
+			target.SetString(s)
+			return nil
+		case reflect.Bool:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return fmt.Errorf("cannot decode %q into %s", s, target.Type())
+			}
+			target.SetBool(b)
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(s, 10, target.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("cannot decode %q into %s", s, target.Type())
+			}
+			target.SetInt(n)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(s, 10, target.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("cannot decode %q into %s", s, target.Type())
+			}
+			target.SetUint(n)
+			return nil
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(s, target.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("cannot decode %q into %s", s, target.Type())
+			}
+			target.SetFloat(f)
+			return nil
+		case reflect.Slice, reflect.Map, reflect.Struct:
+			// defaults of complex types are JSON
+			if err := json.Unmarshal([]byte(s), target.Addr().Interface()); err == nil {
+				return nil
+			}
+		}
+	}
+
+	// Go type assertions fail on this, so we use JSON marshalling
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, target.Addr().Interface()); err != nil {
+		return fmt.Errorf("cannot decode %s into %s", formatValue(value), target.Type())
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package integ
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var decodeOutputs = map[string]any{
+	"queue": map[string]any{
+		"url":               "https://sqs.us-east-1.amazonaws.com/123456789012/orders",
+		"arn":               "arn:aws:sqs:us-east-1:123456789012:orders",
+		"visibilityTimeout": "30",
+		"fifo":              "false",
+	},
+	"function": map[string]any{
+		"name":       "echo",
+		"memorySize": float64(128),
+		"layers":     []any{"arn:aws:lambda:us-east-1:123456789012:layer:shared:1"},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	var dst struct {
+		QueueUrl          string            `jmes:"queue.url,required"`
+		QueueRegion       string            `jmes:"arn_parse(queue.arn).region"`
+		VisibilityTimeout int               `jmes:"queue.visibilityTimeout"`
+		Fifo              bool              `jmes:"queue.fifo"`
+		DlqUrl            *string           `jmes:"queue.dlqUrl"`
+		MaxReceiveCount   int               `jmes:"queue.maxReceiveCount,default=5"`
+		Tags              map[string]string `jmes:"queue.tags,default={\"env\":\"test\",\"team\":\"a\"}"`
+		FunctionName      *string           `jmes:"function.name"`
+		MemorySize        int32             `jmes:"function.memorySize"`
+		Layers            []string          `jmes:"function.layers"`
+		Ignored           string
+		Skipped           string `jmes:"-"`
+	}
+	err := StaticOutputs(decodeOutputs).DecodeE(t, &dst)
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://sqs.us-east-1.amazonaws.com/123456789012/orders", dst.QueueUrl)
+	assert.Equal(t, "us-east-1", dst.QueueRegion)
+	assert.Equal(t, 30, dst.VisibilityTimeout)
+	assert.False(t, dst.Fifo)
+	assert.Nil(t, dst.DlqUrl)
+	assert.Equal(t, 5, dst.MaxReceiveCount)
+	assert.Equal(t, map[string]string{"env": "test", "team": "a"}, dst.Tags)
+	assert.Equal(t, ptr("echo"), dst.FunctionName)
+	assert.Equal(t, int32(128), dst.MemorySize)
+	assert.Equal(t, []string{"arn:aws:lambda:us-east-1:123456789012:layer:shared:1"}, dst.Layers)
+}
+
+func TestDecode_Errors(t *testing.T) {
+	var dst struct {
+		Url         string `jmes:"queue.url,required"`
+		DlqUrl      string `jmes:"queue.dlqUrl,required"`
+		Timeout     bool   `jmes:"queue.visibilityTimeout"`
+		Name        int    `jmes:"function.name"`
+		Layers      string `jmes:"function.layers"`
+		Retries     int    `jmes:"queue.retries,default=many"`
+		Invalid     string `jmes:"queue.[,required"`
+		Conflicting string `jmes:"queue.url,required,default=x"`
+		Unknown     string `jmes:"queue.url,optional"`
+	}
+	err := StaticOutputs(decodeOutputs).DecodeE(t, &dst)
+	require.Error(t, err)
+	// all fields are reported together
+	for _, expected := range []string{
+		`field DlqUrl (queue.dlqUrl,required): required output is missing`,
+		`field Timeout (queue.visibilityTimeout): cannot decode "30" into bool`,
+		`field Name (function.name): cannot decode "echo" into int`,
+		`field Layers (function.layers): cannot decode [arn:aws:lambda:us-east-1:123456789012:layer:shared:1] ([]interface {}) into string`,
+		`field Retries (queue.retries,default=many): invalid default: cannot decode "many" into int`,
+		`field Invalid (queue.[,required): `,
+		`field Conflicting (queue.url,required,default=x): required fields can not have a default`,
+		`field Unknown (queue.url,optional): unknown tag option "optional"`,
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+	assert.NotContains(t, err.Error(), "field Url ")
+	assert.Equal(t, "https://sqs.us-east-1.amazonaws.com/123456789012/orders", dst.Url)
+}
+
+func TestDecode_InvalidTarget(t *testing.T) {
+	var s string
+	assert.EqualError(t, StaticOutputs(decodeOutputs).DecodeE(t, &s), "decode target must be a non-nil pointer to a struct, got *string")
+	assert.Error(t, StaticOutputs(decodeOutputs).DecodeE(t, struct{}{}))
+}
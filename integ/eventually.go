@@ -0,0 +1,162 @@
+package integ
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// EventuallyOptions configures the retries of AssertEventually
+type EventuallyOptions struct {
+	Timeout     time.Duration // Total time to keep retrying, defaults to 1 minute
+	Interval    time.Duration // Wait before the second attempt, defaults to 2 seconds
+	MaxInterval time.Duration // Upper bound of the wait between attempts, defaults to 20 seconds
+	Backoff     float64       // Multiplier applied to the wait after each attempt, defaults to 1.5
+	Jitter      float64       // Fraction of the wait to randomly add or remove (0-1), 0 disables jitter
+}
+
+// DefaultEventuallyOptions are used when AssertEventually is called without options
+var DefaultEventuallyOptions = EventuallyOptions{
+	Timeout:     time.Minute,
+	Interval:    2 * time.Second,
+	MaxInterval: 20 * time.Second,
+	Backoff:     1.5,
+	Jitter:      0.1,
+}
+
+// withDefaults fills zero values with DefaultEventuallyOptions
+func (o *EventuallyOptions) withDefaults() EventuallyOptions {
+	if o == nil {
+		return DefaultEventuallyOptions
+	}
+	result := *o
+	if result.Timeout <= 0 {
+		result.Timeout = DefaultEventuallyOptions.Timeout
+	}
+	if result.Interval <= 0 {
+		result.Interval = DefaultEventuallyOptions.Interval
+	}
+	if result.MaxInterval <= 0 {
+		result.MaxInterval = DefaultEventuallyOptions.MaxInterval
+	}
+	if result.Backoff < 1 {
+		result.Backoff = DefaultEventuallyOptions.Backoff
+	}
+	return result
+}
+
+// EventuallyTimeoutError is returned when the assertions did not pass before the timeout
+type EventuallyTimeoutError struct {
+	Attempts int
+	Elapsed  time.Duration
+	LastErr  error // The provider error or failed assertions of the last attempt
+}
+
+func (err EventuallyTimeoutError) Error() string {
+	return fmt.Sprintf("assertions did not pass after %d attempts in %s, last failure:\n%v", err.Attempts, err.Elapsed.Round(time.Second), err.LastErr)
+}
+
+func (err EventuallyTimeoutError) Unwrap() error {
+	return err.LastErr
+}
+
+// EventuallyStoppedError is returned when the run of the test is stopped between attempts, see RunContext
+type EventuallyStoppedError struct {
+	Attempts int
+	Cause    error // i.e. ErrTestDeadline or ErrInterrupted
+	LastErr  error // The provider error or failed assertions of the last attempt
+}
+
+func (err EventuallyStoppedError) Error() string {
+	return fmt.Sprintf("stopped after %d attempts: %v, last failure:\n%v", err.Attempts, err.Cause, err.LastErr)
+}
+
+func (err EventuallyStoppedError) Unwrap() []error {
+	return []error{err.Cause, err.LastErr}
+}
+
+// AssertEventually re-fetches the input from provider and asserts it against the provided assertions
+// until all assertions pass or the timeout is reached. Use this for eventually consistent values,
+// i.e. waiting for IAM propagation or event delivery. Fails the test with the last failure on timeout.
+func AssertEventually(t *testing.T, provider func() (any, error), assertions []Assertion, opts *EventuallyOptions) {
+	if err := AssertEventuallyE(t, provider, assertions, opts); err != nil {
+		t.Errorf("failed assertions: %v", err)
+	}
+}
+
+// AssertEventuallyE re-fetches the input from provider and asserts it against the provided assertions
+// until all assertions pass or the timeout is reached. Returns an EventuallyTimeoutError on timeout.
+// The timeout is capped at the `go test -timeout` deadline, so the test fails with the last failure instead of panicking.
+// Retries end with an EventuallyStoppedError when the Runner stops the validation stage, see RunContext.
+func AssertEventuallyE(t *testing.T, provider func() (any, error), assertions []Assertion, opts *EventuallyOptions) error {
+	o := opts.withDefaults()
+	stop := RunContext(t)
+	start := time.Now()
+	deadline := eventuallyDeadline(t, start, o.Timeout)
+	interval := o.Interval
+	attempts := 0
+	for {
+		attempts++
+		lastErr := attemptAssert(provider, assertions)
+		if lastErr == nil {
+			return nil
+		}
+
+		wait := jitter(interval, o.Jitter)
+		if time.Now().Add(wait).After(deadline) {
+			return EventuallyTimeoutError{
+				Attempts: attempts,
+				Elapsed:  time.Since(start),
+				LastErr:  lastErr,
+			}
+		}
+		t.Logf("Attempt %d failed, retrying in %s: %v", attempts, wait.Round(time.Millisecond), lastErr)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-stop.Done():
+			timer.Stop()
+			return EventuallyStoppedError{
+				Attempts: attempts,
+				Cause:    context.Cause(stop),
+				LastErr:  lastErr,
+			}
+		}
+
+		interval = time.Duration(float64(interval) * o.Backoff)
+		if interval > o.MaxInterval {
+			interval = o.MaxInterval
+		}
+	}
+}
+
+// eventuallyDeadline returns start plus timeout, or the deadline of t if it is earlier
+func eventuallyDeadline(t *testing.T, start time.Time, timeout time.Duration) time.Time {
+	deadline := start.Add(timeout)
+	if testDeadline, ok := t.Deadline(); ok && testDeadline.Before(deadline) {
+		return testDeadline
+	}
+	return deadline
+}
+
+func attemptAssert(provider func() (any, error), assertions []Assertion) error {
+	input, err := provider()
+	if err != nil {
+		return fmt.Errorf("error fetching input: %w", err)
+	}
+	return AssertE(input, assertions)
+}
+
+// jitter randomly adds or removes up to fraction of d
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	delta := (rand.Float64()*2 - 1) * fraction * float64(d)
+	return d + time.Duration(delta)
+}
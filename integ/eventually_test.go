@@ -0,0 +1,122 @@
+package integ
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastEventuallyOptions = &EventuallyOptions{
+	Timeout:     500 * time.Millisecond,
+	Interval:    10 * time.Millisecond,
+	MaxInterval: 50 * time.Millisecond,
+	Backoff:     2,
+	Jitter:      0.5,
+}
+
+func TestAssertEventually_Success(t *testing.T) {
+	calls := 0
+	AssertEventually(t, func() (any, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("AccessDeniedException: not yet propagated")
+		}
+		return map[string]any{"status": calls}, nil
+	}, []Assertion{
+		{
+			Path:        "status",
+			GreaterThan: ptr(2.0),
+		},
+	}, fastEventuallyOptions)
+	assert.Equal(t, 3, calls)
+}
+
+func TestAssertEventually_Timeout(t *testing.T) {
+	calls := 0
+	err := AssertEventuallyE(t, func() (any, error) {
+		calls++
+		return map[string]any{"status": "PENDING"}, nil
+	}, []Assertion{
+		{
+			Path:   "status",
+			Equals: "ACTIVE",
+		},
+	}, fastEventuallyOptions)
+	require.Error(t, err)
+
+	var timeoutErr EventuallyTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, calls, timeoutErr.Attempts)
+	assert.Greater(t, calls, 1)
+	// the last failing assertion is reported instead of a generic retry error
+	assert.Contains(t, err.Error(), `error asserting value at 'status': expected "ACTIVE" (string), got "PENDING" (string)`)
+}
+
+func TestAssertEventually_ProviderError(t *testing.T) {
+	err := AssertEventuallyE(t, func() (any, error) {
+		return nil, errors.New("ResourceNotFoundException")
+	}, []Assertion{{Path: "status", Exists: true}}, fastEventuallyOptions)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error fetching input: ResourceNotFoundException")
+}
+
+func TestAssertEventually_Stopped(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	runContexts.Store(t, context.Context(ctx))
+	t.Cleanup(func() {
+		runContexts.Delete(t)
+	})
+	time.AfterFunc(100*time.Millisecond, func() {
+		cancel(ErrInterrupted)
+	})
+
+	start := time.Now()
+	err := AssertEventuallyE(t, func() (any, error) {
+		return map[string]any{"status": "PENDING"}, nil
+	}, []Assertion{{Path: "status", Equals: "ACTIVE"}}, &EventuallyOptions{Timeout: time.Minute, Interval: 20 * time.Millisecond})
+	assert.Less(t, time.Since(start), 10*time.Second)
+	var stoppedErr EventuallyStoppedError
+	require.ErrorAs(t, err, &stoppedErr)
+	assert.Greater(t, stoppedErr.Attempts, 1)
+	assert.ErrorIs(t, err, ErrInterrupted)
+	assert.Contains(t, err.Error(), `expected "ACTIVE" (string), got "PENDING" (string)`)
+}
+
+func TestRunContext(t *testing.T) {
+	assert.Equal(t, context.Background(), RunContext(t))
+}
+
+func TestEventuallyDeadline(t *testing.T) {
+	start := time.Now()
+	assert.Equal(t, start.Add(time.Second), eventuallyDeadline(t, start, time.Second))
+
+	testDeadline, ok := t.Deadline()
+	if !ok {
+		t.Skip("requires a go test -timeout")
+	}
+	// the timeout does not outlast the test
+	assert.Equal(t, testDeadline, eventuallyDeadline(t, start, 100*365*24*time.Hour))
+}
+
+func TestEventuallyOptions_Defaults(t *testing.T) {
+	var nilOpts *EventuallyOptions
+	assert.Equal(t, DefaultEventuallyOptions, nilOpts.withDefaults())
+
+	o := (&EventuallyOptions{Timeout: 5 * time.Minute}).withDefaults()
+	assert.Equal(t, 5*time.Minute, o.Timeout)
+	assert.Equal(t, DefaultEventuallyOptions.Interval, o.Interval)
+	assert.Equal(t, float64(0), o.Jitter)
+}
+
+func TestJitter(t *testing.T) {
+	assert.Equal(t, time.Second, jitter(time.Second, 0))
+	for i := 0; i < 100; i++ {
+		d := jitter(time.Second, 0.2)
+		assert.GreaterOrEqual(t, d, 800*time.Millisecond)
+		assert.LessOrEqual(t, d, 1200*time.Millisecond)
+	}
+}
@@ -0,0 +1,105 @@
+package integ
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const (
+	// CleanupBudgetEnv overrides the time reserved to clean up before the `go test -timeout` deadline, i.e. INTEG_CLEANUP_BUDGET=20m
+	CleanupBudgetEnv = "INTEG_CLEANUP_BUDGET"
+	// MinCleanupBudget is the least time reserved to clean up by DefaultCleanupBudget
+	MinCleanupBudget = 2 * time.Minute
+)
+
+// ErrInterrupted is the cause of runs stopped by SIGINT or SIGTERM
+var ErrInterrupted = errors.New("interrupted by signal")
+
+var (
+	interrupted     = make(chan struct{})
+	interruptedOnce sync.Once
+
+	// stop contexts of the running validation and after cleanup stages by test, see RunContext
+	runContexts sync.Map
+)
+
+// Interrupted returns a channel closed on the first SIGINT or SIGTERM of the test process, so runners stop and clean up.
+// The signal handler is installed on the first call, a second signal terminates the process right away.
+func Interrupted() <-chan struct{} {
+	interruptedOnce.Do(func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			signal.Stop(signals)
+			close(interrupted)
+		}()
+	})
+	return interrupted
+}
+
+// DefaultCleanupBudget reserves a quarter of the time left until the `go test -timeout` deadline of t to clean up,
+// at least MinCleanupBudget, i.e. 3m45s of a 15m timeout. Raise it for apps with slow deletes, i.e. CloudFront.
+func DefaultCleanupBudget(t *testing.T) time.Duration {
+	deadline, ok := t.Deadline()
+	if !ok {
+		return MinCleanupBudget
+	}
+	return max(MinCleanupBudget, time.Until(deadline)/4)
+}
+
+// CleanupBudgetE returns CleanupBudgetEnv if set, otherwise budget
+func CleanupBudgetE(budget time.Duration) (time.Duration, error) {
+	v := os.Getenv(CleanupBudgetEnv)
+	if v == "" {
+		return budget, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a duration like 15m", CleanupBudgetEnv, v)
+	}
+	return d, nil
+}
+
+// StopContext returns a context canceled budget before the test deadline with ErrTestDeadline as cause,
+// or on SIGINT or SIGTERM with ErrInterrupted. If the deadline leaves less than budget, it is canceled at the deadline.
+func StopContext(t *testing.T, budget time.Duration) context.Context {
+	ctx := DeadlineContext(t, budget)
+	if errors.Is(context.Cause(ctx), ErrTestDeadline) {
+		deadline, _ := t.Deadline()
+		t.Logf("[WARNING] Less than the cleanup budget %s left until the test deadline %s, raise -timeout or lower %s",
+			budget, deadline.Format(time.RFC3339), CleanupBudgetEnv)
+		ctx = DeadlineContext(t, 0)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	t.Cleanup(func() {
+		cancel(context.Canceled)
+	})
+	// install the signal handler before returning, not in the goroutine
+	interrupted := Interrupted()
+	go func() {
+		select {
+		case <-interrupted:
+			cancel(ErrInterrupted)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx
+}
+
+// RunContext returns the context of the validation or after cleanup stage the Runner is running on t, canceled with
+// the cause when the run is stopped, or context.Background outside of these stages. AssertEventually stops retrying
+// once it is done, long running validations pass it on to their calls or check it.
+func RunContext(t *testing.T) context.Context {
+	if ctx, ok := runContexts.Load(t); ok {
+		return ctx.(context.Context)
+	}
+	return context.Background()
+}
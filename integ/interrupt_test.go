@@ -0,0 +1,149 @@
+package integ
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupBudgetE(t *testing.T) {
+	budget, err := CleanupBudgetE(time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, budget)
+
+	t.Setenv(CleanupBudgetEnv, "20m")
+	budget, err = CleanupBudgetE(time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 20*time.Minute, budget)
+
+	t.Setenv(CleanupBudgetEnv, "twenty")
+	_, err = CleanupBudgetE(time.Minute)
+	assert.EqualError(t, err, `invalid INTEG_CLEANUP_BUDGET "twenty", expected a duration like 15m`)
+}
+
+func TestDefaultCleanupBudget(t *testing.T) {
+	deadline, ok := t.Deadline()
+	if !ok {
+		assert.Equal(t, MinCleanupBudget, DefaultCleanupBudget(t))
+		return
+	}
+	left := time.Until(deadline)
+	budget := DefaultCleanupBudget(t)
+	assert.GreaterOrEqual(t, budget, MinCleanupBudget)
+	assert.LessOrEqual(t, budget, max(MinCleanupBudget, left/4))
+}
+
+func TestStopContext_BudgetExceedsDeadline(t *testing.T) {
+	if _, ok := t.Deadline(); !ok {
+		t.Skip("requires a go test -timeout")
+	}
+	// without time to spare, the run is not stopped before the deadline
+	ctx := StopContext(t, 100*365*24*time.Hour)
+	assert.NoError(t, ctx.Err())
+}
+
+// runnerHelperEnv runs TestRunner_Stop as a helper process, the stopped runner fails its test
+const runnerHelperEnv = "INTEG_RUNNER_HELPER"
+
+// runStoppedRunner runs an app whose validation retries until the run is stopped by the helper mode
+func runStoppedRunner(t *testing.T, mode string) {
+	tfWorkingDir := filepath.Join(os.Getenv("INTEG_RUNNER_HELPER_DIR"), "sqs")
+	lifecycle := &fakeLifecycle{}
+	budget := time.Second
+	if mode == "deadline" {
+		deadline, ok := t.Deadline()
+		require.True(t, ok)
+		budget = time.Until(deadline) - 200*time.Millisecond
+	}
+	r := NewRunner(lifecycle, "sqs", WithTfWorkingDir(tfWorkingDir), WithCleanupBudget(budget),
+		WithStageAfterValidate("rename_app", func(t *testing.T, r *Runner) {
+			lifecycle.calls = append(lifecycle.calls, "rename_app")
+		}),
+		WithStageAfterCleanup("verify_cleanup", func(t *testing.T, r *Runner) {
+			// after cleanup stages still run, with the stopped run context
+			if RunContext(t).Err() == nil {
+				t.Error("verify_cleanup runs with the stopped run context")
+			}
+			lifecycle.calls = append(lifecycle.calls, "verify_cleanup")
+		}),
+	)
+	t.Cleanup(func() {
+		fmt.Printf("calls: %q\n", lifecycle.calls)
+	})
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, func(t *testing.T, tfWorkingDir string, awsRegion string) {
+			lifecycle.calls = append(lifecycle.calls, "validate")
+			if mode == "signal" {
+				// the runner installed the signal handler
+				require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGINT))
+			}
+			AssertEventually(t, func() (any, error) {
+				return map[string]any{"status": "PENDING"}, nil
+			}, []Assertion{{Path: "status", Equals: "ACTIVE"}}, &EventuallyOptions{Timeout: time.Hour, Interval: 50 * time.Millisecond})
+		})
+	})
+}
+
+func TestRunner_Stop(t *testing.T) {
+	if mode := os.Getenv(runnerHelperEnv); mode != "" {
+		runStoppedRunner(t, mode)
+		return
+	}
+	for mode, cause := range map[string]string{"deadline": ErrTestDeadline.Error(), "signal": ErrInterrupted.Error()} {
+		t.Run(mode, func(t *testing.T) {
+			dir := t.TempDir()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestRunner_Stop$", "-test.v", "-test.timeout=1m")
+			cmd.Env = append(os.Environ(), runnerHelperEnv+"="+mode, "INTEG_RUNNER_HELPER_DIR="+dir)
+			output, err := cmd.CombinedOutput()
+			require.Error(t, err, "the stopped run fails:\n%s", output)
+			assert.Contains(t, string(output), fmt.Sprintf("Stopped stage 'validate' of sqs early: %s", cause))
+			assert.Contains(t, string(output), "failed assertions: stopped after")
+			assert.NotContains(t, string(output), "after Test")
+			assert.Contains(t, string(output), fmt.Sprintf("calls: %q", []string{
+				"synth sqs " + filepath.Join(dir, "sqs") + " test",
+				"deploy " + filepath.Join(dir, "sqs"),
+				"validate",
+				"destroy " + filepath.Join(dir, "sqs"),
+				"verify_cleanup",
+			}))
+			// the app was cleaned up
+			assert.NoFileExists(t, filepath.Join(dir, StateHeldFile))
+
+			j, err := LoadJournalE(filepath.Join(dir, "sqs"))
+			require.NoError(t, err)
+			assert.Equal(t, StageFailed, j.Entry(StageValidate).Status)
+			assert.Nil(t, j.Entry("rename_app"))
+			assert.True(t, j.Completed())
+		})
+	}
+}
+
+func TestRunner_StateHeld(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(SkipStageEnvPrefix+StageCleanup, "true")
+	r := NewRunner(&fakeLifecycle{}, "sqs", WithTfWorkingDir(filepath.Join(dir, "sqs")))
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, nil)
+	})
+	entries, err := LoadHeldStateE(filepath.Join(dir, StateHeldFile))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "sqs", entries[0].App)
+	assert.Equal(t, filepath.Join(dir, "sqs"), entries[0].TfWorkingDir)
+
+	// the cleanup-only run releases the app
+	t.Setenv(SkipStageEnvPrefix+StageCleanup, "")
+	t.Setenv(SkipStageEnvPrefix+StageSynth, "true")
+	t.Setenv(SkipStageEnvPrefix+StageDeploy, "true")
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, nil)
+	})
+	assert.NoFileExists(t, filepath.Join(dir, StateHeldFile))
+}
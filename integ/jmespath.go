@@ -0,0 +1,152 @@
+package integ
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/jmespath/go-jmespath"
+)
+
+// jmesFunction is a custom function registered on every JMESPath expression compiled by CompileJMESPath
+type jmesFunction struct {
+	name    string
+	args    string // comma separated argument types, i.e. "string,any"
+	handler func([]interface{}) (interface{}, error)
+}
+
+// jmesFunctions decode values which AWS APIs return as encoded strings,
+// so they can be asserted on without decoding them by hand.
+var jmesFunctions = []jmesFunction{
+	// from_json(@) parses a JSON string, i.e. the SQS `Policy` attribute or a dashboard body
+	{name: "from_json", args: "any", handler: jpfFromJSON},
+	// url_decode(@) decodes a URL-encoded string, i.e. IAM policy documents
+	{name: "url_decode", args: "any", handler: jpfURLDecode},
+	// base64_decode(@) decodes a base64 string, i.e. Lambda `LogResult` tails
+	{name: "base64_decode", args: "any", handler: jpfBase64Decode},
+	// to_number(@) converts numeric strings and SDK number pointers to a number, or null
+	{name: "to_number", args: "any", handler: jpfToNumber},
+	// regex_match(@, 'pattern') returns true if the string matches the regular expression
+	{name: "regex_match", args: "any,string", handler: jpfRegexMatch},
+	// arn_parse(@) splits an ARN into partition, service, region, accountId and resource
+	{name: "arn_parse", args: "any", handler: jpfArnParse},
+}
+
+// CompileJMESPath compiles a JMESPath expression with the custom functions registered.
+// This is the JMESPath runtime shared by AssertE and TerraformOutputJMES.
+func CompileJMESPath(expression string) (*jmespath.JMESPath, error) {
+	p, err := jmespath.Compile(expression)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range jmesFunctions {
+		if err := p.RegisterFunction(f.name, f.args, false, f.handler); err != nil {
+			return nil, fmt.Errorf("error registering JMESPath function %s: %v", f.name, err)
+		}
+	}
+	return p, nil
+}
+
+// SearchJMESPath compiles the expression and searches the data
+func SearchJMESPath(expression string, data any) (any, error) {
+	p, err := CompileJMESPath(expression)
+	if err != nil {
+		return nil, err
+	}
+	return p.Search(data)
+}
+
+// stringArg returns the argument as a string, dereferencing SDK string pointers
+func stringArg(name string, arg interface{}) (string, error) {
+	s, ok := normalizeValue(arg).(string)
+	if !ok {
+		return "", fmt.Errorf("%s: expected a string argument, got %T", name, arg)
+	}
+	return s, nil
+}
+
+func jpfFromJSON(arguments []interface{}) (interface{}, error) {
+	s, err := stringArg("from_json", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	if err := json.Unmarshal([]byte(s), &result); err != nil {
+		return nil, fmt.Errorf("from_json: invalid JSON: %v", err)
+	}
+	return result, nil
+}
+
+func jpfURLDecode(arguments []interface{}) (interface{}, error) {
+	s, err := stringArg("url_decode", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := url.QueryUnescape(s)
+	if err != nil {
+		return nil, fmt.Errorf("url_decode: %v", err)
+	}
+	return decoded, nil
+}
+
+func jpfBase64Decode(arguments []interface{}) (interface{}, error) {
+	s, err := stringArg("base64_decode", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("base64_decode: %v", err)
+	}
+	return string(decoded), nil
+}
+
+// jpfToNumber extends the builtin to_number with SDK numeric types (i.e. *int32), anything else is null
+func jpfToNumber(arguments []interface{}) (interface{}, error) {
+	switch v := normalizeValue(arguments[0]).(type) {
+	case float64:
+		return v, nil
+	case string:
+		conv, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, nil
+		}
+		return conv, nil
+	default:
+		return nil, nil
+	}
+}
+
+func jpfRegexMatch(arguments []interface{}) (interface{}, error) {
+	s, ok := normalizeValue(arguments[0]).(string)
+	if !ok {
+		return false, nil
+	}
+	re, err := regexp.Compile(arguments[1].(string))
+	if err != nil {
+		return nil, fmt.Errorf("regex_match: invalid regexp '%s': %v", arguments[1], err)
+	}
+	return re.MatchString(s), nil
+}
+
+func jpfArnParse(arguments []interface{}) (interface{}, error) {
+	s, err := stringArg("arn_parse", arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := arn.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("arn_parse: %v", err)
+	}
+	return map[string]interface{}{
+		"partition": parsed.Partition,
+		"service":   parsed.Service,
+		"region":    parsed.Region,
+		"accountId": parsed.AccountID,
+		"resource":  parsed.Resource,
+	}, nil
+}
@@ -0,0 +1,135 @@
+package integ
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJMESPathFunctions(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		query         string
+		expectedValue interface{}
+		description   string
+	}{
+		{
+			fixture:       "iam-get-role.json",
+			query:         "Role.AssumeRolePolicyDocument | url_decode(@) | from_json(@).Statement[0].Principal.Service",
+			expectedValue: "lambda.amazonaws.com",
+			description:   "url encoded IAM policy",
+		},
+		{
+			fixture:       "iam-get-role.json",
+			query:         "arn_parse(Role.Arn)",
+			expectedValue: map[string]interface{}{"partition": "aws", "service": "iam", "region": "", "accountId": "123456789012", "resource": "role/TestRole-a1b2c3d4"},
+			description:   "arn parse",
+		},
+		{
+			fixture:       "sqs-queue-attributes.json",
+			query:         "from_json(Attributes.Policy).Statement[?Effect=='Allow'].Action | [0]",
+			expectedValue: "sqs:SendMessage",
+			description:   "json string SQS policy",
+		},
+		{
+			fixture:       "sqs-queue-attributes.json",
+			query:         "to_number(Attributes.VisibilityTimeout)",
+			expectedValue: float64(30),
+			description:   "numeric string",
+		},
+		{
+			fixture:       "sqs-queue-attributes.json",
+			query:         "arn_parse(Attributes.QueueArn).[region, accountId]",
+			expectedValue: []interface{}{"us-east-1", "123456789012"},
+			description:   "arn parse multiselect",
+		},
+		{
+			fixture:       "lambda-invoke.json",
+			query:         "regex_match(base64_decode(LogResult), 'INFO\\tHello from Lambda')",
+			expectedValue: true,
+			description:   "base64 Lambda log tail",
+		},
+		{
+			fixture:       "lambda-invoke.json",
+			query:         "regex_match(ExecutedVersion, '^\\d+$')",
+			expectedValue: false,
+			description:   "regex no match",
+		},
+		{
+			fixture:       "cloudwatch-dashboard.json",
+			query:         "from_json(DashboardBody).widgets[].properties.metrics[][1]",
+			expectedValue: []interface{}{"GetRecords.Records"},
+			description:   "dashboard body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			input := loadJSONFixture(t, tt.fixture)
+			result, err := SearchJMESPath(tt.query, input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedValue, result,
+				"JMESPath query %q should return %v, got %v", tt.query, tt.expectedValue, result)
+		})
+	}
+}
+
+func TestJMESPathFunctions_SDKTypes(t *testing.T) {
+	// SDK outputs hold pointers, which the builtin functions do not accept
+	input := map[string]any{
+		"Arn":     ptr("arn:aws:lambda:us-east-1:123456789012:function:echo"),
+		"Timeout": ptr(int32(30)),
+	}
+	result, err := SearchJMESPath("[arn_parse(Arn).service, to_number(Timeout)]", input)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"lambda", float64(30)}, result)
+}
+
+func TestJMESPathFunctions_Errors(t *testing.T) {
+	for _, query := range []string{
+		"from_json('not json')",
+		"base64_decode('%%%')",
+		"url_decode('%zz')",
+		"arn_parse('not-an-arn')",
+		"regex_match('a', '(')",
+		"from_json(`42`)",
+	} {
+		t.Run(query, func(t *testing.T) {
+			_, err := SearchJMESPath(query, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAssert_JMESPathFunctions(t *testing.T) {
+	Assert(t, loadJSONFixture(t, "sqs-queue-attributes.json"), []Assertion{
+		{
+			Path:   "to_number(Attributes.ApproximateNumberOfMessages)",
+			Equals: 0,
+		},
+		{
+			Path: "Attributes.Policy",
+			Matcher: SerializedJSON(ObjectLike(map[string]any{
+				"Statement": ArrayWith([]any{
+					ObjectLike(map[string]any{"Principal": map[string]any{"Service": "sns.amazonaws.com"}}),
+				}),
+			})),
+		},
+		{
+			Path:   "from_json(Attributes.Policy).Statement[].Resource | [0] | arn_parse(@).service",
+			Equals: "sqs",
+		},
+	})
+}
+
+func loadJSONFixture(t *testing.T, name string) any {
+	data, err := os.ReadFile(filepath.Join("fixtures", "jmespath-functions", name))
+	require.NoError(t, err)
+	var result any
+	require.NoError(t, json.Unmarshal(data, &result))
+	return result
+}
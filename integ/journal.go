@@ -0,0 +1,189 @@
+package integ
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+)
+
+const (
+	// ResumeEnv resumes the last run of the app from its journal, skipping the stages that succeeded,
+	// i.e. INTEG_RESUME=true make queue
+	ResumeEnv = "INTEG_RESUME"
+	// StatusEnv prints the journal of the last run of the app instead of running it, i.e. INTEG_STATUS=true make queue
+	StatusEnv = "INTEG_STATUS"
+
+	// journalTestData is the test data the stages of the last run are recorded in, next to the saved Terraform options
+	journalTestData = "journal.json"
+)
+
+// StageStatus is the outcome of a stage in the Journal
+type StageStatus string
+
+const (
+	// StageStarted stages did not finish, the test was interrupted or is still running
+	StageStarted   StageStatus = "started"
+	StageSucceeded StageStatus = "succeeded"
+	StageFailed    StageStatus = "failed"
+)
+
+// JournalEntry records the last execution of a stage
+type JournalEntry struct {
+	Stage      string      `json:"stage"`
+	Status     StageStatus `json:"status"`
+	StartedAt  time.Time   `json:"startedAt"`
+	FinishedAt time.Time   `json:"finishedAt,omitzero"`
+}
+
+// Journal records the stages of the last run of an app in the order they first ran.
+// Stages skipped with `SKIP_<stage>` keep their entry, so `make queue-validate-only` adds to the journal of the run.
+type Journal struct {
+	App     string         `json:"app"`
+	RunID   string         `json:"runId,omitempty"`
+	Entries []JournalEntry `json:"entries"`
+}
+
+// Resuming returns true if INTEG_RESUME is set
+func Resuming() bool {
+	resume, _ := strconv.ParseBool(os.Getenv(ResumeEnv))
+	return resume
+}
+
+// PrintingStatus returns true if INTEG_STATUS is set
+func PrintingStatus() bool {
+	status, _ := strconv.ParseBool(os.Getenv(StatusEnv))
+	return status
+}
+
+// Entry returns the entry of stage, nil if it did not run
+func (j *Journal) Entry(stage string) *JournalEntry {
+	for i := range j.Entries {
+		if j.Entries[i].Stage == stage {
+			return &j.Entries[i]
+		}
+	}
+	return nil
+}
+
+// Succeeded returns true if stage succeeded
+func (j *Journal) Succeeded(stage string) bool {
+	e := j.Entry(stage)
+	return e != nil && e.Status == StageSucceeded
+}
+
+// Completed returns true if the app was cleaned up, a resumed run starts over
+func (j *Journal) Completed() bool {
+	return j.Succeeded(StageCleanup)
+}
+
+// CleanupStarted returns true if the cleanup stage started without succeeding, the app may be partly destroyed
+func (j *Journal) CleanupStarted() bool {
+	return j.Entry(StageCleanup) != nil && !j.Completed()
+}
+
+// SkipOnResume returns true if a resumed run skips stage: stages that succeeded, and all stages
+// before cleanup once cleanup started. cleanup is true for the cleanup stage and the stages after it.
+func (j *Journal) SkipOnResume(stage string, cleanup bool) bool {
+	if j.Succeeded(stage) {
+		return true
+	}
+	return j.CleanupStarted() && !cleanup
+}
+
+// Start records stage as started at now
+func (j *Journal) Start(stage string, now time.Time) {
+	entry := JournalEntry{Stage: stage, Status: StageStarted, StartedAt: now.UTC()}
+	if e := j.Entry(stage); e != nil {
+		*e = entry
+		return
+	}
+	j.Entries = append(j.Entries, entry)
+}
+
+// Finish records the status of stage at now
+func (j *Journal) Finish(stage string, status StageStatus, now time.Time) {
+	e := j.Entry(stage)
+	if e == nil {
+		j.Start(stage, now)
+		e = j.Entry(stage)
+	}
+	e.Status = status
+	e.FinishedAt = now.UTC()
+}
+
+// SaveE writes the journal to the test data of testFolder
+func (j *Journal) SaveE(testFolder string) error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := formatJournalPath(testFolder)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadJournalE returns the journal recorded in the test data of testFolder, or nil if there is none
+func LoadJournalE(testFolder string) (*Journal, error) {
+	data, err := os.ReadFile(formatJournalPath(testFolder))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var j Journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("error decoding journal of %s: %v", testFolder, err)
+	}
+	return &j, nil
+}
+
+// Status renders the journal as a table with a hint on what a resumed run does, i.e.
+//
+//	STAGE             STATUS     STARTED               DURATION
+//	synth_app         succeeded  2026-10-16T09:12:03Z  1m4s
+//	deploy_terraform  succeeded  2026-10-16T09:13:07Z  3m41s
+//	validate          failed     2026-10-16T09:16:48Z  12s
+func (j *Journal) Status(tfWorkingDir string) string {
+	var buf bytes.Buffer
+	run := ""
+	if j.RunID != "" {
+		run = " run " + j.RunID
+	}
+	fmt.Fprintf(&buf, "Last run of %s%s in %s:\n", j.App, run, tfWorkingDir)
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STAGE\tSTATUS\tSTARTED\tDURATION")
+	for _, e := range j.Entries {
+		duration := "-"
+		if !e.FinishedAt.IsZero() {
+			duration = e.FinishedAt.Sub(e.StartedAt).Round(time.Second).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Stage, e.Status, e.StartedAt.Format(time.RFC3339), duration)
+	}
+	tw.Flush()
+	switch {
+	case j.Completed():
+		fmt.Fprintln(&buf, "The app was cleaned up, the next run starts over.")
+	case j.CleanupStarted():
+		fmt.Fprintf(&buf, "The cleanup did not succeed, %s=true runs the cleanup again.\n", ResumeEnv)
+	case j.Entry(StageDeploy) == nil:
+		fmt.Fprintf(&buf, "The app was not deployed, %s=true skips the succeeded stages.\n", ResumeEnv)
+	default:
+		fmt.Fprintf(&buf, "The app may still be deployed, %s=true skips the succeeded stages.\n", ResumeEnv)
+	}
+	return buf.String()
+}
+
+func formatJournalPath(testFolder string) string {
+	return test_structure.FormatTestDataPath(testFolder, journalTestData)
+}
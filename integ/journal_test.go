@@ -0,0 +1,98 @@
+package integ
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var journalStart = time.Date(2026, 10, 16, 9, 12, 3, 0, time.UTC)
+
+func TestJournal_StartFinish(t *testing.T) {
+	j := &Journal{App: "sqs"}
+	j.Start(StageSynth, journalStart)
+	j.Finish(StageSynth, StageSucceeded, journalStart.Add(64*time.Second))
+	j.Start(StageDeploy, journalStart.Add(time.Minute))
+	assert.True(t, j.Succeeded(StageSynth))
+	assert.False(t, j.Succeeded(StageDeploy))
+	assert.Equal(t, StageStarted, j.Entry(StageDeploy).Status)
+
+	// rerunning a stage replaces its entry in place
+	j.Start(StageSynth, journalStart.Add(2*time.Minute))
+	assert.Equal(t, StageStarted, j.Entry(StageSynth).Status)
+	assert.True(t, j.Entry(StageSynth).FinishedAt.IsZero())
+	assert.Equal(t, StageSynth, j.Entries[0].Stage)
+	assert.Len(t, j.Entries, 2)
+}
+
+func TestJournal_SkipOnResume(t *testing.T) {
+	j := &Journal{Entries: []JournalEntry{
+		{Stage: StageSynth, Status: StageSucceeded},
+		{Stage: StageDeploy, Status: StageSucceeded},
+		{Stage: StageValidate, Status: StageFailed},
+	}}
+	assert.True(t, j.SkipOnResume(StageSynth, false))
+	assert.True(t, j.SkipOnResume(StageDeploy, false))
+	assert.False(t, j.SkipOnResume(StageValidate, false))
+	assert.False(t, j.SkipOnResume("rename_app", false))
+	assert.False(t, j.SkipOnResume(StageCleanup, true))
+	assert.False(t, j.Completed())
+
+	// an interrupted cleanup resumes at cleanup
+	j.Entries = append(j.Entries, JournalEntry{Stage: StageCleanup, Status: StageStarted})
+	assert.True(t, j.CleanupStarted())
+	assert.True(t, j.SkipOnResume(StageValidate, false))
+	assert.True(t, j.SkipOnResume("rename_app", false))
+	assert.False(t, j.SkipOnResume(StageCleanup, true))
+	assert.False(t, j.SkipOnResume("verify_cleanup", true))
+
+	j.Finish(StageCleanup, StageSucceeded, journalStart)
+	assert.True(t, j.Completed())
+	assert.False(t, j.CleanupStarted())
+}
+
+func TestJournal_SaveE(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sqs")
+	j, err := LoadJournalE(dir)
+	require.NoError(t, err)
+	assert.Nil(t, j)
+
+	saved := &Journal{App: "sqs", RunID: "4f2a9c"}
+	saved.Start(StageSynth, journalStart)
+	saved.Finish(StageSynth, StageSucceeded, journalStart.Add(time.Minute))
+	saved.Start(StageDeploy, journalStart.Add(time.Minute))
+	require.NoError(t, saved.SaveE(dir))
+	assert.FileExists(t, filepath.Join(dir, ".test-data", "journal.json"))
+
+	loaded, err := LoadJournalE(dir)
+	require.NoError(t, err)
+	assert.Equal(t, saved, loaded)
+}
+
+func TestJournal_Status(t *testing.T) {
+	j := &Journal{App: "sqs", RunID: "4f2a9c"}
+	j.Start(StageSynth, journalStart)
+	j.Finish(StageSynth, StageSucceeded, journalStart.Add(64*time.Second))
+	j.Start(StageDeploy, journalStart.Add(64*time.Second))
+	j.Finish(StageDeploy, StageSucceeded, journalStart.Add(285*time.Second))
+	j.Start(StageValidate, journalStart.Add(285*time.Second))
+	j.Finish(StageValidate, StageFailed, journalStart.Add(297*time.Second))
+	j.Start(StageCleanup, journalStart.Add(300*time.Second))
+
+	assert.Equal(t, `Last run of sqs run 4f2a9c in tf/sqs-4f2a9c:
+STAGE              STATUS     STARTED               DURATION
+synth_app          succeeded  2026-10-16T09:12:03Z  1m4s
+deploy_terraform   succeeded  2026-10-16T09:13:07Z  3m41s
+validate           failed     2026-10-16T09:16:48Z  12s
+cleanup_terraform  started    2026-10-16T09:17:03Z  -
+The cleanup did not succeed, INTEG_RESUME=true runs the cleanup again.
+`, j.Status("tf/sqs-4f2a9c"))
+
+	j.Entries = j.Entries[:3]
+	assert.Contains(t, j.Status("tf/sqs-4f2a9c"), "The app may still be deployed, INTEG_RESUME=true skips the succeeded stages.")
+	j.Entries = j.Entries[:1]
+	assert.Contains(t, j.Status("tf/sqs-4f2a9c"), "The app was not deployed")
+}
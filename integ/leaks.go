@@ -0,0 +1,114 @@
+package integ
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Kinds of implicit resources
+const (
+	KindLogGroup         = "log-group"
+	KindNetworkInterface = "network-interface"
+)
+
+// ImplicitResource is created by AWS on behalf of a managed resource, outside of the Terraform state,
+// i.e. the `/aws/lambda/<function>` log group of a Lambda function. `tofu destroy` does not delete them.
+type ImplicitResource struct {
+	Kind   string `json:"kind"`   // KindLogGroup or KindNetworkInterface
+	ID     string `json:"id"`     // log group name, or description prefix of the network interfaces
+	Region string `json:"region"` // empty for the region of the app
+	Owner  string `json:"owner"`  // address of the resource creating it
+}
+
+// ImplicitResourceRule derives the implicit resources of a state resource
+type ImplicitResourceRule func(r *StateResource) []ImplicitResource
+
+// ImplicitResourceRules by resource type, add rules for resources creating implicit resources
+var ImplicitResourceRules = map[string]ImplicitResourceRule{
+	"aws_lambda_function": func(r *StateResource) []ImplicitResource {
+		name, err := AttrE[string](r, "function_name")
+		if err != nil {
+			return nil
+		}
+		region := arnRegion(r)
+		logGroup := "/aws/lambda/" + name
+		if custom, err := AttrE[string](r, "logging_config[0].log_group"); err == nil && custom != "" {
+			logGroup = custom
+		}
+		resources := []ImplicitResource{{Kind: KindLogGroup, ID: logGroup, Region: region, Owner: r.Address}}
+		if subnets, err := AttrE[[]string](r, "vpc_config[0].subnet_ids"); err == nil && len(subnets) > 0 {
+			resources = append(resources, ImplicitResource{
+				Kind:   KindNetworkInterface,
+				ID:     "AWS Lambda VPC ENI-" + name,
+				Region: region,
+				Owner:  r.Address,
+			})
+		}
+		return resources
+	},
+	"aws_cloudfront_function": func(r *StateResource) []ImplicitResource {
+		name, err := AttrE[string](r, "name")
+		if err != nil {
+			return nil
+		}
+		// CloudFront functions log to us-east-1
+		return []ImplicitResource{{Kind: KindLogGroup, ID: "/aws/cloudfront/function/" + name, Region: "us-east-1", Owner: r.Address}}
+	},
+}
+
+// ImplicitResources returns the implicit resources of the managed resources in state, sorted by owner
+func ImplicitResources(state *State) []ImplicitResource {
+	var resources []ImplicitResource
+	for _, r := range state.Find(StateFilter{}) {
+		if rule, ok := ImplicitResourceRules[r.Type]; ok {
+			resources = append(resources, rule(r)...)
+		}
+	}
+	return resources
+}
+
+// arnRegion returns the region of the resource arn attribute, empty if unknown
+func arnRegion(r *StateResource) string {
+	arn, err := AttrE[string](r, "arn")
+	if err != nil {
+		return ""
+	}
+	if sections := strings.SplitN(arn, ":", 5); len(sections) == 5 {
+		return sections[3]
+	}
+	return ""
+}
+
+// Leak is a resource of the app remaining after cleanup
+type Leak struct {
+	Kind   string // resource type, i.e. "sqs:queue", or the implicit resource kind
+	ID     string // ARN, log group name or network interface ID
+	Region string
+	Source string // "tagged" for resources with the run tags, the owner address for implicit resources
+}
+
+// LeakReport renders leaks as a table sorted by kind and ID, empty if there are no leaks
+func LeakReport(leaks []Leak) string {
+	if len(leaks) == 0 {
+		return ""
+	}
+	sorted := append([]Leak(nil), leaks...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Kind != sorted[j].Kind {
+			return sorted[i].Kind < sorted[j].Kind
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tREGION\tSOURCE\tRESOURCE")
+	for _, l := range sorted {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", l.Kind, l.Region, l.Source, l.ID)
+	}
+	tw.Flush()
+	fmt.Fprintf(&buf, "%d resources remain after cleanup.\n", len(leaks))
+	return buf.String()
+}
@@ -0,0 +1,35 @@
+package integ
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImplicitResources(t *testing.T) {
+	state, err := LoadStateFileE("fixtures/state/leaks.json")
+	require.NoError(t, err)
+
+	// data sources are not owned by the app, data.aws_lambda_function.Imported has no implicit resources
+	assert.Equal(t, []ImplicitResource{
+		{Kind: KindLogGroup, ID: "/aws/cloudfront/function/url-rewrite-spa-RewriteFunction", Region: "us-east-1", Owner: "aws_cloudfront_function.RewriteFunction_6A1B7B2C"},
+		{Kind: KindLogGroup, ID: "/aws/lambda/sns-lambda-Echo", Region: "eu-west-1", Owner: "aws_lambda_function.Echo_8F3C1A2B"},
+		{Kind: KindLogGroup, ID: "/custom/vpc-handler", Region: "eu-west-1", Owner: "module.vpc.aws_lambda_function.Handler_1F2E3D4C"},
+		{Kind: KindNetworkInterface, ID: "AWS Lambda VPC ENI-vpc-Handler", Region: "eu-west-1", Owner: "module.vpc.aws_lambda_function.Handler_1F2E3D4C"},
+	}, ImplicitResources(state))
+
+	assert.Empty(t, ImplicitResources(NewState(nil)))
+}
+
+func TestLeakReport(t *testing.T) {
+	assert.Empty(t, LeakReport(nil))
+	assert.Equal(t, `KIND       REGION     SOURCE                             RESOURCE
+log-group  eu-west-1  aws_lambda_function.Echo_8F3C1A2B  /aws/lambda/sns-lambda-Echo
+sqs:queue  eu-west-1  tagged                             arn:aws:sqs:eu-west-1:123456789012:sns-lambda-Queue
+2 resources remain after cleanup.
+`, LeakReport([]Leak{
+		{Kind: "sqs:queue", ID: "arn:aws:sqs:eu-west-1:123456789012:sns-lambda-Queue", Region: "eu-west-1", Source: "tagged"},
+		{Kind: KindLogGroup, ID: "/aws/lambda/sns-lambda-Echo", Region: "eu-west-1", Source: "aws_lambda_function.Echo_8F3C1A2B"},
+	}))
+}
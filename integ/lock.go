@@ -0,0 +1,214 @@
+package integ
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+)
+
+const (
+	// BreakLockEnv takes over the lock of the working directory held by another run, i.e. INTEG_BREAK_LOCK=true make queue.
+	// Only set it if the holder is gone, i.e. a killed test binary on another host or container.
+	BreakLockEnv = "INTEG_BREAK_LOCK"
+
+	// lockTestData is the test data the lock of the working directory is recorded in, next to the saved Terraform options
+	lockTestData = "lock.json"
+)
+
+// LockHolder is the run holding the lock of a working directory
+type LockHolder struct {
+	App       string    `json:"app"`
+	RunID     string    `json:"runId,omitempty"`
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+func (h *LockHolder) String() string {
+	return fmt.Sprintf("pid %d on %s since %s", h.PID, h.Host, h.StartedAt.Format(time.RFC3339))
+}
+
+func (h *LockHolder) same(other LockHolder) bool {
+	return h.PID == other.PID && h.Host == other.Host && h.StartedAt.Equal(other.StartedAt)
+}
+
+// Stale returns true if the holder ran on this host and its process is gone, i.e. a test binary killed on timeout
+func (h *LockHolder) Stale() bool {
+	host, err := os.Hostname()
+	if err != nil || h.Host != host {
+		return false
+	}
+	return !processRunning(h.PID)
+}
+
+// LockedError is returned by LockWorkingDirE if another run holds the lock
+type LockedError struct {
+	Dir    string
+	Holder LockHolder
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s is locked by %s (app %s), wait for it to finish or set %s=true if it is gone",
+		e.Dir, e.Holder.String(), e.Holder.App, BreakLockEnv)
+}
+
+// WorkingDirLock is an advisory lock of a Terraform working directory, see LockWorkingDirE
+type WorkingDirLock struct {
+	Dir    string
+	Holder LockHolder
+	// Replaced is the stale or broken lock taken over, nil if the working directory was not locked
+	Replaced *LockHolder
+}
+
+// BreakingLock returns true if INTEG_BREAK_LOCK is set
+func BreakingLock() bool {
+	breakLock, _ := strconv.ParseBool(os.Getenv(BreakLockEnv))
+	return breakLock
+}
+
+// LockWorkingDirE locks tfWorkingDir for app, so concurrent test binaries do not share its state and test data.
+// The lock is a file in the test data, created exclusively. A lock left by a process of this host that is gone
+// is taken over, any other lock is only taken over with breakLock, otherwise a *LockedError is returned.
+func LockWorkingDirE(tfWorkingDir, app, runID string, breakLock bool) (*WorkingDirLock, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("error locking %s: %v", tfWorkingDir, err)
+	}
+	lock := &WorkingDirLock{
+		Dir: tfWorkingDir,
+		Holder: LockHolder{
+			App:       app,
+			RunID:     runID,
+			PID:       os.Getpid(),
+			Host:      host,
+			StartedAt: time.Now().UTC(),
+		},
+	}
+	data, err := json.MarshalIndent(lock.Holder, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	path := formatLockPath(tfWorkingDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	// the lock may be released or taken over by another run between the attempts
+	for attempt := 0; attempt < 3; attempt++ {
+		err := createExclusive(path, data)
+		if err == nil {
+			return lock, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("error locking %s: %v", tfWorkingDir, err)
+		}
+		holder, err := LoadLockHolderE(tfWorkingDir)
+		if err != nil {
+			return nil, err
+		}
+		if holder == nil {
+			continue
+		}
+		// only the lock seen first is taken over, not the lock of a run that took it over in the meantime
+		if lock.Replaced != nil || (!breakLock && !holder.Stale()) {
+			return nil, &LockedError{Dir: tfWorkingDir, Holder: *holder}
+		}
+		removed, err := removeLockE(tfWorkingDir, *holder)
+		if err != nil {
+			return nil, fmt.Errorf("error removing the lock of %s: %v", tfWorkingDir, err)
+		}
+		// another run taking over the same lock removed it first
+		if removed {
+			lock.Replaced = holder
+		}
+	}
+	return nil, fmt.Errorf("error locking %s, the lock changed while acquiring it", tfWorkingDir)
+}
+
+// UnlockE releases the lock, unless another run took it over in the meantime
+func (l *WorkingDirLock) UnlockE() error {
+	_, err := removeLockE(l.Dir, l.Holder)
+	return err
+}
+
+// LoadLockHolderE returns the holder of the lock of tfWorkingDir, or nil if it is not locked
+func LoadLockHolderE(tfWorkingDir string) (*LockHolder, error) {
+	data, err := os.ReadFile(formatLockPath(tfWorkingDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var holder LockHolder
+	if err := json.Unmarshal(data, &holder); err != nil {
+		return nil, fmt.Errorf("error decoding lock of %s, remove it if no test is running: %v", tfWorkingDir, err)
+	}
+	return &holder, nil
+}
+
+// removeLockE removes the lock of tfWorkingDir if it is still held by holder and returns false otherwise.
+// Runs taking over or releasing a lock check and remove it one at a time, holding an exclusive flock on a file next to
+// the lock, so a run never removes a lock created by another run after the check. A killed run releases the flock.
+func removeLockE(tfWorkingDir string, holder LockHolder) (bool, error) {
+	path := formatLockPath(tfWorkingDir)
+	f, err := os.OpenFile(path+".takeover", os.O_CREATE|os.O_RDWR, 0o644)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return false, err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	current, err := LoadLockHolderE(tfWorkingDir)
+	if err != nil || current == nil || !current.same(holder) {
+		return false, err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	return true, nil
+}
+
+// createExclusive fails with os.ErrExist if path exists. The data is written to a temporary file and linked,
+// so other runs never read a partial lock.
+func createExclusive(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Link(f.Name(), path)
+}
+
+// processRunning sends signal 0 to pid, a process of another user is running too
+func processRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+func formatLockPath(testFolder string) string {
+	return test_structure.FormatTestDataPath(testFolder, lockTestData)
+}
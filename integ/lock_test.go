@@ -0,0 +1,140 @@
+package integ
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockWorkingDirE(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tf", "sqs")
+	lock, err := LockWorkingDirE(dir, "sqs", "", false)
+	require.NoError(t, err)
+	assert.Nil(t, lock.Replaced)
+	assert.Equal(t, os.Getpid(), lock.Holder.PID)
+
+	holder, err := LoadLockHolderE(dir)
+	require.NoError(t, err)
+	require.NotNil(t, holder)
+	assert.True(t, holder.same(lock.Holder))
+	assert.False(t, holder.Stale())
+
+	// the holder is running, even in the same process
+	_, err = LockWorkingDirE(dir, "sqs", "", false)
+	var locked *LockedError
+	require.ErrorAs(t, err, &locked)
+	assert.True(t, locked.Holder.same(lock.Holder))
+	assert.Contains(t, err.Error(), dir+" is locked by "+lock.Holder.String())
+	assert.Contains(t, err.Error(), BreakLockEnv+"=true")
+
+	require.NoError(t, lock.UnlockE())
+	holder, err = LoadLockHolderE(dir)
+	require.NoError(t, err)
+	assert.Nil(t, holder)
+	require.NoError(t, lock.UnlockE())
+
+	lock, err = LockWorkingDirE(dir, "sqs", "", false)
+	require.NoError(t, err)
+	require.NoError(t, lock.UnlockE())
+}
+
+// exitedPID returns the pid of a process of this host that is gone
+func exitedPID(t *testing.T) int {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	require.NoError(t, cmd.Run())
+	return cmd.Process.Pid
+}
+
+func saveTestLock(t *testing.T, dir string, holder LockHolder) {
+	data, err := json.Marshal(holder)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(formatLockPath(dir)), 0o755))
+	require.NoError(t, os.WriteFile(formatLockPath(dir), data, 0o644))
+}
+
+func TestLockWorkingDirE_Stale(t *testing.T) {
+	host, err := os.Hostname()
+	require.NoError(t, err)
+	dir := filepath.Join(t.TempDir(), "tf", "sqs")
+	stale := LockHolder{App: "sqs", PID: exitedPID(t), Host: host, StartedAt: time.Date(2026, 10, 16, 9, 12, 3, 0, time.UTC)}
+	saveTestLock(t, dir, stale)
+
+	lock, err := LockWorkingDirE(dir, "sqs", "", false)
+	require.NoError(t, err)
+	require.NotNil(t, lock.Replaced)
+	assert.Equal(t, stale, *lock.Replaced)
+	holder, err := LoadLockHolderE(dir)
+	require.NoError(t, err)
+	assert.True(t, holder.same(lock.Holder))
+}
+
+func TestLockWorkingDirE_StaleRace(t *testing.T) {
+	host, err := os.Hostname()
+	require.NoError(t, err)
+	dir := filepath.Join(t.TempDir(), "tf", "sqs")
+	stale := LockHolder{App: "sqs", PID: exitedPID(t), Host: host, StartedAt: time.Date(2026, 10, 16, 9, 12, 3, 0, time.UTC)}
+	saveTestLock(t, dir, stale)
+
+	// run A takes over the stale lock seen by both runs
+	lock, err := LockWorkingDirE(dir, "sqs", "", false)
+	require.NoError(t, err)
+	require.NotNil(t, lock.Replaced)
+
+	// run B removing the stale lock it loaded before does not remove the lock of run A
+	removed, err := removeLockE(dir, stale)
+	require.NoError(t, err)
+	assert.False(t, removed)
+	holder, err := LoadLockHolderE(dir)
+	require.NoError(t, err)
+	require.NotNil(t, holder)
+	assert.True(t, holder.same(lock.Holder))
+}
+
+func TestLockWorkingDirE_Break(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tf", "sqs")
+	// the process of another host is not checked
+	other := LockHolder{App: "sqs", PID: exitedPID(t), Host: "ci-runner-7", StartedAt: time.Date(2026, 10, 16, 9, 12, 3, 0, time.UTC)}
+	saveTestLock(t, dir, other)
+	assert.False(t, other.Stale())
+
+	_, err := LockWorkingDirE(dir, "sqs", "", false)
+	assert.EqualError(t, err, dir+" is locked by pid "+strconv.Itoa(other.PID)+" on ci-runner-7 since 2026-10-16T09:12:03Z (app sqs), "+
+		"wait for it to finish or set INTEG_BREAK_LOCK=true if it is gone")
+
+	t.Setenv(BreakLockEnv, "true")
+	lock, err := LockWorkingDirE(dir, "sqs", "", BreakingLock())
+	require.NoError(t, err)
+	require.NotNil(t, lock.Replaced)
+	assert.Equal(t, other, *lock.Replaced)
+
+	// the broken lock is not released by its holder
+	taken := &WorkingDirLock{Dir: dir, Holder: other}
+	require.NoError(t, taken.UnlockE())
+	holder, err := LoadLockHolderE(dir)
+	require.NoError(t, err)
+	assert.True(t, holder.same(lock.Holder))
+}
+
+func TestRunner_Lock(t *testing.T) {
+	inTempDir(t)
+	lifecycle := &fakeLifecycle{}
+	var holder *LockHolder
+	runParallel(t, func(t *testing.T) {
+		NewRunner(lifecycle, "sqs").Run(t, func(t *testing.T, tfWorkingDir, awsRegion string) {
+			var err error
+			holder, err = LoadLockHolderE(tfWorkingDir)
+			require.NoError(t, err)
+		})
+	})
+	require.NotNil(t, holder)
+	assert.Equal(t, "sqs", holder.App)
+	assert.Equal(t, os.Getpid(), holder.PID)
+	assert.NoFileExists(t, formatLockPath(filepath.Join("tf", "sqs")))
+}
@@ -0,0 +1,246 @@
+package integ
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/go-multierror"
+	"gopkg.in/yaml.v3"
+)
+
+// AssertionManifest declares the assertions of an integration test app as data,
+// so non-Go contributors can add coverage and upstream CDK integ assertions port one-to-one.
+//
+// The manifest lives next to the app as `apps/<app>.assertions.yaml` (or `.json`).
+// It is rendered as a text/template before parsing, the `output` function searches
+// the Terraform outputs using JMESPath, i.e. `QueueUrl: '{{ output "queue.url" }}'`.
+type AssertionManifest struct {
+	Assertions []ManifestRun `yaml:"assertions"`
+}
+
+// ManifestRun declares the input to read, either Terraform outputs or an SDK call, and the assertions on it
+type ManifestRun struct {
+	Name    string              `yaml:"name"`
+	Output  string              `yaml:"output"`  // JMESPath over all Terraform outputs, use "@" for all outputs
+	SdkCall *SdkCall            `yaml:"sdkCall"` // SDK call to read the input from
+	Expect  []ManifestAssertion `yaml:"expect"`
+}
+
+// ManifestAssertion is the data representation of an Assertion.
+//
+// `match` holds a structural matcher, matchers are objects with a single `$` prefixed key:
+// $exact, $objectLike, $objectEquals, $arrayWith, $arrayEquals, $absent, $anyValue, $not,
+// $serializedJson and $stringLikeRegexp. Matchers can be nested.
+type ManifestAssertion struct {
+	Path        string    `yaml:"path"`
+	Exists      bool      `yaml:"exists"`
+	Regexp      *string   `yaml:"regexp"`
+	Equals      any       `yaml:"equals"`
+	NotEquals   any       `yaml:"notEquals"`
+	GreaterThan *float64  `yaml:"greaterThan"`
+	LessThan    *float64  `yaml:"lessThan"`
+	Length      *int      `yaml:"length"`
+	Contains    any       `yaml:"contains"`
+	OneOf       []any     `yaml:"oneOf"`
+	TypeIs      ValueType `yaml:"typeIs"`
+	Match       any       `yaml:"match"`
+}
+
+// AssertionRun is a ManifestRun converted to assertions
+type AssertionRun struct {
+	Name       string
+	Output     string
+	SdkCall    *SdkCall
+	Assertions []Assertion
+}
+
+// manifestExtensions in order of precedence
+var manifestExtensions = []string{".assertions.yaml", ".assertions.yml", ".assertions.json"}
+
+// FindAssertionManifest returns the path of the assertion manifest for the test app, if any
+func FindAssertionManifest(testApp string) (string, bool) {
+	for _, ext := range manifestExtensions {
+		path := filepath.Join("apps", testApp+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// LoadAssertionManifestE reads and renders the manifest at path, outputs are the Terraform outputs
+// available to the `output` template function.
+func LoadAssertionManifestE(path string, outputs map[string]any) (*AssertionManifest, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(filepath.Base(path)).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"output": func(query string) (any, error) {
+				value, err := SearchJMESPath(query, outputs)
+				if err == nil && value == nil {
+					err = fmt.Errorf("no Terraform output found for %q", query)
+				}
+				return value, err
+			},
+		}).
+		Parse(string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing manifest template %s: %v", path, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return nil, fmt.Errorf("error rendering manifest template %s: %v", path, err)
+	}
+
+	// JSON is valid YAML, so one decoder handles both formats
+	var manifest AssertionManifest
+	decoder := yaml.NewDecoder(&buf)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("error decoding manifest %s: %v", path, err)
+	}
+	return &manifest, nil
+}
+
+// Runs converts the manifest into assertion runs, validating every run and matcher
+func (m *AssertionManifest) Runs() ([]AssertionRun, error) {
+	var combinedErr error
+	runs := make([]AssertionRun, 0, len(m.Assertions))
+	for i, r := range m.Assertions {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("assertions[%d]", i)
+		}
+		if (r.Output == "") == (r.SdkCall == nil) {
+			combinedErr = multierror.Append(combinedErr, fmt.Errorf("%s: exactly one of output or sdkCall is required", name))
+			continue
+		}
+		run := AssertionRun{Name: name, Output: r.Output, SdkCall: r.SdkCall}
+		for j, e := range r.Expect {
+			a, err := e.toAssertion()
+			if err != nil {
+				combinedErr = multierror.Append(combinedErr, fmt.Errorf("%s: expect[%d]: %v", name, j, err))
+				continue
+			}
+			run.Assertions = append(run.Assertions, a)
+		}
+		runs = append(runs, run)
+	}
+	return runs, combinedErr
+}
+
+func (e ManifestAssertion) toAssertion() (Assertion, error) {
+	a := Assertion{
+		Path:           e.Path,
+		Exists:         e.Exists,
+		ExpectedRegexp: e.Regexp,
+		Equals:         e.Equals,
+		NotEquals:      e.NotEquals,
+		GreaterThan:    e.GreaterThan,
+		LessThan:       e.LessThan,
+		Length:         e.Length,
+		Contains:       e.Contains,
+		OneOf:          e.OneOf,
+		TypeIs:         e.TypeIs,
+	}
+	if e.Match != nil {
+		pattern, err := decodePattern(e.Match)
+		if err != nil {
+			return a, err
+		}
+		m, ok := pattern.(Matcher)
+		if !ok {
+			// plain values are matched exactly
+			m = Exact(pattern)
+		}
+		a.Matcher = m
+	}
+	return a, nil
+}
+
+// decodePattern replaces objects with a single `$` prefixed key by the corresponding Matcher
+func decodePattern(value any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 1 {
+			for key, arg := range v {
+				if strings.HasPrefix(key, "$") {
+					return decodeMatcher(key, arg)
+				}
+			}
+		}
+		result := make(map[string]any, len(v))
+		for key, elem := range v {
+			decoded, err := decodePattern(elem)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = decoded
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, elem := range v {
+			decoded, err := decodePattern(elem)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = decoded
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+func decodeMatcher(key string, arg any) (Matcher, error) {
+	pattern, err := decodePattern(arg)
+	if err != nil {
+		return nil, err
+	}
+	switch key {
+	case "$exact":
+		return Exact(pattern), nil
+	case "$objectLike", "$objectEquals":
+		obj, ok := pattern.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s expects an object, got %T", key, arg)
+		}
+		if key == "$objectLike" {
+			return ObjectLike(obj), nil
+		}
+		return ObjectEquals(obj), nil
+	case "$arrayWith", "$arrayEquals":
+		arr, ok := pattern.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s expects an array, got %T", key, arg)
+		}
+		if key == "$arrayWith" {
+			return ArrayWith(arr), nil
+		}
+		return ArrayEquals(arr), nil
+	case "$absent":
+		return Absent(), nil
+	case "$anyValue":
+		return AnyValue(), nil
+	case "$not":
+		return Not(pattern), nil
+	case "$serializedJson":
+		return SerializedJSON(pattern), nil
+	case "$stringLikeRegexp":
+		s, ok := pattern.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s expects a string, got %T", key, arg)
+		}
+		return StringLikeRegexp(s), nil
+	default:
+		return nil, fmt.Errorf("unknown matcher %s", key)
+	}
+}
@@ -0,0 +1,112 @@
+package integ
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var manifestOutputs = map[string]any{
+	"region": "us-east-1",
+	"queue": map[string]any{
+		"url":  "https://sqs.us-east-1.amazonaws.com/123456789012/orders.fifo",
+		"arn":  "arn:aws:sqs:us-east-1:123456789012:orders.fifo",
+		"fifo": true,
+	},
+}
+
+func TestLoadAssertionManifest_YAML(t *testing.T) {
+	manifest, err := LoadAssertionManifestE(filepath.Join("fixtures", "assertion-manifests", "queue.assertions.yaml"), manifestOutputs)
+	require.NoError(t, err)
+	runs, err := manifest.Runs()
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+
+	assert.Equal(t, "outputs", runs[0].Name)
+	assert.Equal(t, "queue", runs[0].Output)
+	require.Len(t, runs[0].Assertions, 2)
+	assert.Equal(t, `^https://sqs\.us-east-1\.amazonaws\.com/`, *runs[0].Assertions[0].ExpectedRegexp)
+	input, err := SearchJMESPath(runs[0].Output, manifestOutputs)
+	require.NoError(t, err)
+	Assert(t, input, runs[0].Assertions)
+
+	call := runs[1].SdkCall
+	require.NotNil(t, call)
+	assert.Equal(t, "sqs", call.Service)
+	assert.Equal(t, "GetQueueAttributes", call.Api)
+	assert.Equal(t, "https://sqs.us-east-1.amazonaws.com/123456789012/orders.fifo", call.Parameters["QueueUrl"])
+	Assert(t, map[string]any{
+		"Attributes": map[string]any{
+			"KmsMasterKeyId":    "alias/aws/sqs",
+			"VisibilityTimeout": "30",
+			"RedrivePolicy":     `{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:123456789012:dlq","maxReceiveCount":5}`,
+		},
+	}, runs[1].Assertions)
+}
+
+func TestLoadAssertionManifest_JSON(t *testing.T) {
+	manifest, err := LoadAssertionManifestE(filepath.Join("fixtures", "assertion-manifests", "queue.assertions.json"), manifestOutputs)
+	require.NoError(t, err)
+	runs, err := manifest.Runs()
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, TypeBoolean, runs[0].Assertions[0].TypeIs)
+	Assert(t, manifestOutputs["queue"], runs[0].Assertions)
+}
+
+func TestLoadAssertionManifest_MatcherFailure(t *testing.T) {
+	manifest, err := LoadAssertionManifestE(filepath.Join("fixtures", "assertion-manifests", "queue.assertions.yaml"), manifestOutputs)
+	require.NoError(t, err)
+	runs, err := manifest.Runs()
+	require.NoError(t, err)
+
+	err = AssertE(map[string]any{
+		"Attributes": map[string]any{
+			"KmsMasterKeyId":    "alias/aws/sqs",
+			"VisibilityTimeout": "30",
+			"RedrivePolicy":     `{"maxReceiveCount":3}`,
+		},
+	}, runs[1].Assertions)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected 5 but received 3 at /(serializedJson)/maxReceiveCount")
+}
+
+func TestLoadAssertionManifest_Invalid(t *testing.T) {
+	manifest, err := LoadAssertionManifestE(filepath.Join("fixtures", "assertion-manifests", "invalid.assertions.yaml"), manifestOutputs)
+	require.NoError(t, err)
+	_, err = manifest.Runs()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no input: exactly one of output or sdkCall is required")
+	assert.Contains(t, err.Error(), "bad matcher: expect[0]: $arrayWith expects an array, got string")
+	assert.Contains(t, err.Error(), "bad matcher: expect[1]: unknown matcher $startsWith")
+}
+
+func TestLoadAssertionManifest_Errors(t *testing.T) {
+	_, err := LoadAssertionManifestE(filepath.Join("fixtures", "assertion-manifests", "missing.assertions.yaml"), manifestOutputs)
+	assert.Error(t, err)
+
+	_, err = LoadAssertionManifestE(filepath.Join("fixtures", "assertion-manifests", "queue.assertions.yaml"), nil)
+	assert.Error(t, err, "output queries on missing outputs should fail rendering")
+}
+
+func TestDecodePattern(t *testing.T) {
+	pattern, err := decodePattern(map[string]any{
+		"Statement": map[string]any{
+			"$arrayWith": []any{
+				map[string]any{"$objectLike": map[string]any{"Effect": "Allow"}},
+			},
+		},
+		"Version": map[string]any{"$not": map[string]any{"$absent": nil}},
+	})
+	require.NoError(t, err)
+	m := ObjectLike(pattern.(map[string]any))
+	assert.False(t, m.Test(map[string]any{
+		"Version":   "2012-10-17",
+		"Statement": []any{map[string]any{"Effect": "Allow", "Action": "sqs:*"}},
+	}).HasFailed())
+	assert.True(t, m.Test(map[string]any{
+		"Statement": []any{map[string]any{"Effect": "Deny"}},
+	}).HasFailed())
+}
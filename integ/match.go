@@ -0,0 +1,388 @@
+package integ
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ref https://github.com/aws/aws-cdk/blob/v2.161.1/packages/aws-cdk-lib/assertions/lib/match.ts
+
+// Matcher is a structural matcher which can be tested against any JMESPath result,
+// i.e. nested maps and slices from SDK outputs or JSON decoded policy documents.
+type Matcher interface {
+	// Name of the matcher, used in failure reports
+	Name() string
+	// Test the actual value against the matcher
+	Test(actual any) *MatchResult
+}
+
+// MatchFailure is a single failure of a Matcher test
+type MatchFailure struct {
+	Matcher Matcher
+	Path    []string // Path from the root of the tested value to the failure
+	Message string
+}
+
+// MatchResult is the result of a Matcher test
+type MatchResult struct {
+	Target   any
+	failures []MatchFailure
+}
+
+func newMatchResult(target any) *MatchResult {
+	return &MatchResult{Target: target}
+}
+
+// HasFailed returns true if the Matcher test recorded any failures
+func (r *MatchResult) HasFailed() bool {
+	return len(r.failures) > 0
+}
+
+// FailCount returns the number of failures recorded
+func (r *MatchResult) FailCount() int {
+	return len(r.failures)
+}
+
+// Failures returns the failures annotated with the path at which they occurred
+func (r *MatchResult) Failures() []string {
+	result := make([]string, 0, len(r.failures))
+	for _, f := range r.failures {
+		result = append(result, fmt.Sprintf("%s at /%s (using %s matcher)", f.Message, strings.Join(f.Path, "/"), f.Matcher.Name()))
+	}
+	return result
+}
+
+func (r *MatchResult) recordFailure(matcher Matcher, message string) {
+	r.failures = append(r.failures, MatchFailure{Matcher: matcher, Path: []string{}, Message: message})
+}
+
+// compose the failures of an inner result, prefixing their path with id
+func (r *MatchResult) compose(id string, inner *MatchResult) {
+	for _, f := range inner.failures {
+		r.failures = append(r.failures, MatchFailure{
+			Matcher: f.Matcher,
+			Path:    append([]string{id}, f.Path...),
+			Message: f.Message,
+		})
+	}
+}
+
+// Exact deep matches the pattern, objects must match all keys
+func Exact(pattern any) Matcher {
+	return &literalMatch{name: "exact", pattern: pattern}
+}
+
+// ObjectLike matches an object which contains the keys of pattern, nested objects are matched partially
+func ObjectLike(pattern map[string]any) Matcher {
+	return &objectMatch{name: "objectLike", pattern: pattern, partial: true}
+}
+
+// ObjectEquals matches an object with exactly the keys of pattern
+func ObjectEquals(pattern map[string]any) Matcher {
+	return &objectMatch{name: "objectEquals", pattern: pattern}
+}
+
+// ArrayWith matches an array which contains the pattern elements as a subsequence, in order
+func ArrayWith(pattern []any) Matcher {
+	return &arrayMatch{name: "arrayWith", pattern: pattern, subsequence: true}
+}
+
+// ArrayEquals matches an array with exactly the pattern elements
+func ArrayEquals(pattern []any) Matcher {
+	return &arrayMatch{name: "arrayEquals", pattern: pattern}
+}
+
+// Absent matches if the value is not present, i.e. an object key which is missing
+func Absent() Matcher {
+	return &absentMatch{}
+}
+
+// AnyValue matches any non-nil value
+func AnyValue() Matcher {
+	return &anyMatch{}
+}
+
+// Not matches if the pattern does not match
+func Not(pattern any) Matcher {
+	return &notMatch{pattern: pattern}
+}
+
+// SerializedJSON matches a string containing JSON which, when parsed, matches pattern
+func SerializedJSON(pattern any) Matcher {
+	return &serializedJSONMatch{pattern: pattern}
+}
+
+// StringLikeRegexp matches a string against the regular expression pattern
+func StringLikeRegexp(pattern string) Matcher {
+	return &stringLikeRegexpMatch{pattern: pattern}
+}
+
+// toMatcher returns pattern if it is a Matcher, or a literal match for it otherwise
+func toMatcher(name string, pattern any, partialObjects bool) Matcher {
+	if m, ok := pattern.(Matcher); ok {
+		return m
+	}
+	return &literalMatch{name: name, pattern: pattern, partialObjects: partialObjects}
+}
+
+func isAbsent(pattern any) bool {
+	_, ok := pattern.(*absentMatch)
+	return ok
+}
+
+type literalMatch struct {
+	name           string
+	pattern        any
+	partialObjects bool
+}
+
+func (m *literalMatch) Name() string { return m.name }
+
+func (m *literalMatch) Test(actual any) *MatchResult {
+	if inner, ok := m.pattern.(Matcher); ok {
+		return inner.Test(actual)
+	}
+	if elements, ok := patternElements(m.pattern); ok {
+		return (&arrayMatch{name: m.name, pattern: elements, partialObjects: m.partialObjects}).Test(actual)
+	}
+	if entries, ok := patternEntries(m.pattern); ok {
+		return (&objectMatch{name: m.name, pattern: entries, partial: m.partialObjects}).Test(actual)
+	}
+
+	result := newMatchResult(actual)
+	expected := normalizeValue(m.pattern)
+	got := normalizeValue(actual)
+	if typeOf(expected) != typeOf(got) {
+		result.recordFailure(m, fmt.Sprintf("Expected type %s but received %s", typeOf(expected), typeOf(got)))
+		return result
+	}
+	if !reflect.DeepEqual(expected, got) {
+		result.recordFailure(m, fmt.Sprintf("Expected %s but received %s", renderValue(expected), renderValue(got)))
+	}
+	return result
+}
+
+type arrayMatch struct {
+	name           string
+	pattern        []any
+	subsequence    bool
+	partialObjects bool
+}
+
+func (m *arrayMatch) Name() string { return m.name }
+
+func (m *arrayMatch) Test(actual any) *MatchResult {
+	result := newMatchResult(actual)
+	got, ok := normalizeValue(actual).([]any)
+	if !ok {
+		result.recordFailure(m, fmt.Sprintf("Expected type array but received %s", typeOf(normalizeValue(actual))))
+		return result
+	}
+	if !m.subsequence {
+		if len(got) != len(m.pattern) {
+			result.recordFailure(m, fmt.Sprintf("Expected array of length %d but received %d", len(m.pattern), len(got)))
+			return result
+		}
+		for i, p := range m.pattern {
+			result.compose(strconv.Itoa(i), toMatcher(m.name, p, m.partialObjects).Test(got[i]))
+		}
+		return result
+	}
+
+	// every pattern element must match an actual element, in order
+	patternIdx := 0
+	for actualIdx := 0; patternIdx < len(m.pattern) && actualIdx < len(got); actualIdx++ {
+		matcher := toMatcher(m.name, m.pattern[patternIdx], m.partialObjects)
+		if !matcher.Test(got[actualIdx]).HasFailed() {
+			patternIdx++
+		}
+	}
+	if patternIdx < len(m.pattern) {
+		result.recordFailure(m, fmt.Sprintf("Could not match %s pattern %d (%s) in %s",
+			m.name, patternIdx, renderPattern(m.pattern[patternIdx]), renderValue(got)))
+	}
+	return result
+}
+
+type objectMatch struct {
+	name    string
+	pattern map[string]any
+	partial bool
+}
+
+func (m *objectMatch) Name() string { return m.name }
+
+func (m *objectMatch) Test(actual any) *MatchResult {
+	result := newMatchResult(actual)
+	got, ok := normalizeValue(actual).(map[string]any)
+	if !ok {
+		result.recordFailure(m, fmt.Sprintf("Expected type object but received %s", typeOf(normalizeValue(actual))))
+		return result
+	}
+	if !m.partial {
+		for _, key := range sortedKeys(got) {
+			if _, ok := m.pattern[key]; !ok {
+				result.compose(key, failureResult(m, fmt.Sprintf("Unexpected key %s", key)))
+			}
+		}
+	}
+	for _, key := range sortedKeys(m.pattern) {
+		patternValue := m.pattern[key]
+		value, present := got[key]
+		if !present {
+			if !isAbsent(patternValue) {
+				result.compose(key, failureResult(m, fmt.Sprintf("Missing key %s", key)))
+			}
+			continue
+		}
+		result.compose(key, toMatcher(m.name, patternValue, m.partial).Test(value))
+	}
+	return result
+}
+
+type absentMatch struct{}
+
+func (m *absentMatch) Name() string { return "absent" }
+
+func (m *absentMatch) Test(actual any) *MatchResult {
+	result := newMatchResult(actual)
+	if got := normalizeValue(actual); got != nil {
+		result.recordFailure(m, fmt.Sprintf("Received %s, but key should be absent", renderValue(got)))
+	}
+	return result
+}
+
+type anyMatch struct{}
+
+func (m *anyMatch) Name() string { return "anyValue" }
+
+func (m *anyMatch) Test(actual any) *MatchResult {
+	result := newMatchResult(actual)
+	if normalizeValue(actual) == nil {
+		result.recordFailure(m, "Expected a value but found none")
+	}
+	return result
+}
+
+type notMatch struct {
+	pattern any
+}
+
+func (m *notMatch) Name() string { return "not" }
+
+func (m *notMatch) Test(actual any) *MatchResult {
+	result := newMatchResult(actual)
+	if !toMatcher(m.Name(), m.pattern, false).Test(actual).HasFailed() {
+		result.recordFailure(m, fmt.Sprintf("Found unexpected match: %s", renderValue(normalizeValue(actual))))
+	}
+	return result
+}
+
+type serializedJSONMatch struct {
+	pattern any
+}
+
+func (m *serializedJSONMatch) Name() string { return "serializedJson" }
+
+func (m *serializedJSONMatch) Test(actual any) *MatchResult {
+	result := newMatchResult(actual)
+	s, ok := normalizeValue(actual).(string)
+	if !ok {
+		result.recordFailure(m, fmt.Sprintf("Expected JSON as a string but found %s", typeOf(normalizeValue(actual))))
+		return result
+	}
+	var parsed any
+	if err := json.Unmarshal([]byte(s), &parsed); err != nil {
+		result.recordFailure(m, fmt.Sprintf("Invalid JSON string: %s", s))
+		return result
+	}
+	result.compose("("+m.Name()+")", toMatcher(m.Name(), m.pattern, false).Test(parsed))
+	return result
+}
+
+type stringLikeRegexpMatch struct {
+	pattern string
+}
+
+func (m *stringLikeRegexpMatch) Name() string { return "stringLikeRegexp" }
+
+func (m *stringLikeRegexpMatch) Test(actual any) *MatchResult {
+	result := newMatchResult(actual)
+	s, ok := normalizeValue(actual).(string)
+	if !ok {
+		result.recordFailure(m, fmt.Sprintf("Expected a string, but got %s", typeOf(normalizeValue(actual))))
+		return result
+	}
+	re, err := regexp.Compile(m.pattern)
+	if err != nil {
+		result.recordFailure(m, fmt.Sprintf("Invalid regexp '%s': %v", m.pattern, err))
+		return result
+	}
+	if !re.MatchString(s) {
+		result.recordFailure(m, fmt.Sprintf("String '%s' did not match pattern '%s'", s, m.pattern))
+	}
+	return result
+}
+
+func failureResult(m Matcher, message string) *MatchResult {
+	result := newMatchResult(nil)
+	result.recordFailure(m, message)
+	return result
+}
+
+// patternElements returns the elements of a slice pattern without normalizing nested matchers
+func patternElements(pattern any) ([]any, bool) {
+	v := reflect.ValueOf(pattern)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	elements := make([]any, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elements[i] = v.Index(i).Interface()
+	}
+	return elements, true
+}
+
+// patternEntries returns the entries of a map pattern without normalizing nested matchers
+func patternEntries(pattern any) (map[string]any, bool) {
+	v := reflect.ValueOf(pattern)
+	if v.Kind() != reflect.Map {
+		return nil, false
+	}
+	entries := make(map[string]any, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		entries[fmt.Sprintf("%v", iter.Key().Interface())] = iter.Value().Interface()
+	}
+	return entries, true
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderValue renders a normalized value as JSON for failure reports
+func renderValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+func renderPattern(pattern any) string {
+	if m, ok := pattern.(Matcher); ok {
+		return m.Name() + " matcher"
+	}
+	return renderValue(normalizeValue(pattern))
+}
@@ -0,0 +1,174 @@
+package integ
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch_ObjectLike(t *testing.T) {
+	result := ObjectLike(map[string]any{
+		"Version": "2012-10-17",
+		"Statement": ArrayWith([]any{
+			ObjectLike(map[string]any{
+				"Effect": "Allow",
+				"Action": []any{"sqs:SendMessage", "sqs:GetQueueAttributes", "sqs:GetQueueUrl"},
+			}),
+			// array elements are matched exactly unless wrapped in ObjectLike
+			map[string]any{
+				"Effect":   "Deny",
+				"Action":   "sqs:DeleteQueue",
+				"Resource": "*",
+			},
+		}),
+	}).Test(testPolicy)
+	assert.False(t, result.HasFailed(), result.Failures())
+}
+
+func TestMatch_ObjectLikeFailures(t *testing.T) {
+	result := ObjectLike(map[string]any{
+		"Version": "2008-10-17",
+		"Id":      AnyValue(),
+		"Statement": ArrayEquals([]any{
+			ObjectLike(map[string]any{"Effect": "Deny"}),
+			ObjectLike(map[string]any{"Effect": 1}),
+		}),
+	}).Test(testPolicy)
+	require.True(t, result.HasFailed())
+	assert.Equal(t, []string{
+		"Missing key Id at /Id (using objectLike matcher)",
+		`Expected "Deny" but received "Allow" at /Statement/0/Effect (using objectLike matcher)`,
+		"Expected type number but received string at /Statement/1/Effect (using objectLike matcher)",
+		`Expected "2008-10-17" but received "2012-10-17" at /Version (using objectLike matcher)`,
+	}, result.Failures())
+}
+
+func TestMatch_ObjectEquals(t *testing.T) {
+	pattern := ObjectEquals(map[string]any{
+		"Effect": "Allow",
+		"Principal": map[string]any{
+			"Service": "sqs.amazonaws.com",
+		},
+	})
+	assert.False(t, pattern.Test(map[string]any{
+		"Effect":    "Allow",
+		"Principal": map[string]any{"Service": "sqs.amazonaws.com"},
+	}).HasFailed())
+
+	result := pattern.Test(map[string]any{
+		"Effect":    "Allow",
+		"Principal": map[string]any{"Service": "sqs.amazonaws.com", "AWS": "*"},
+		"Sid":       "Extra",
+	})
+	assert.Equal(t, []string{
+		"Unexpected key Sid at /Sid (using objectEquals matcher)",
+		"Unexpected key AWS at /Principal/AWS (using objectEquals matcher)",
+	}, result.Failures())
+}
+
+func TestMatch_ArrayWith(t *testing.T) {
+	actual := []string{"a", "b", "c", "d"}
+	assert.False(t, ArrayWith([]any{"b", "d"}).Test(actual).HasFailed())
+	// elements must appear in order
+	assert.True(t, ArrayWith([]any{"d", "b"}).Test(actual).HasFailed())
+	assert.True(t, ArrayWith([]any{"e"}).Test(actual).HasFailed())
+	assert.True(t, ArrayWith([]any{"a"}).Test("a").HasFailed())
+}
+
+func TestMatch_Absent(t *testing.T) {
+	assert.False(t, ObjectLike(map[string]any{
+		"Condition": Absent(),
+	}).Test(testPolicy["Statement"].([]any)[0]).HasFailed())
+
+	result := ObjectLike(map[string]any{
+		"Effect": Absent(),
+	}).Test(testPolicy["Statement"].([]any)[0])
+	assert.Equal(t, []string{
+		`Received "Allow", but key should be absent at /Effect (using absent matcher)`,
+	}, result.Failures())
+}
+
+func TestMatch_SerializedJSON(t *testing.T) {
+	sqsAttributes := map[string]string{
+		"Policy": `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"sqs:*","Resource":"arn:aws:sqs:us-east-1:123456789012:queue"}]}`,
+	}
+	assert.False(t, ObjectLike(map[string]any{
+		"Policy": SerializedJSON(ObjectLike(map[string]any{
+			"Statement": ArrayWith([]any{
+				ObjectLike(map[string]any{"Resource": StringLikeRegexp(`^arn:aws:sqs:[^:]+:\d{12}:queue$`)}),
+			}),
+		})),
+	}).Test(sqsAttributes).HasFailed())
+
+	result := ObjectLike(map[string]any{
+		"Policy": SerializedJSON(ObjectLike(map[string]any{"Version": "2008-10-17"})),
+	}).Test(sqsAttributes)
+	assert.Equal(t, []string{
+		`Expected "2008-10-17" but received "2012-10-17" at /Policy/(serializedJson)/Version (using objectLike matcher)`,
+	}, result.Failures())
+
+	assert.True(t, SerializedJSON(AnyValue()).Test("not json").HasFailed())
+	assert.True(t, SerializedJSON(AnyValue()).Test(42).HasFailed())
+}
+
+func TestMatch_Not(t *testing.T) {
+	assert.False(t, Not("Deny").Test("Allow").HasFailed())
+	assert.True(t, Not(StringLikeRegexp("^Al")).Test("Allow").HasFailed())
+}
+
+func TestMatch_Exact(t *testing.T) {
+	assert.False(t, Exact(map[string]any{"count": 2}).Test(map[string]any{"count": int32(2)}).HasFailed())
+	// exact does not allow partial objects
+	assert.True(t, Exact(map[string]any{"count": 2}).Test(map[string]any{"count": 2, "extra": true}).HasFailed())
+}
+
+func TestAssert_Matcher(t *testing.T) {
+	Assert(t, testObject, []Assertion{
+		{
+			Path: "request",
+			Matcher: ObjectLike(map[string]any{
+				"method": "GET",
+				"headers": map[string]any{
+					"host": map[string]any{"value": StringLikeRegexp(`example\.com$`)},
+				},
+				"body": Absent(),
+			}),
+		},
+		{
+			Path:    "request.body",
+			Matcher: Absent(),
+		},
+	})
+
+	err := AssertE(testObject, []Assertion{
+		{
+			Path:    "request.querystring.arg",
+			Matcher: ObjectLike(map[string]any{"value": "val2"}),
+		},
+		{
+			Path:    "request.body",
+			Matcher: AnyValue(),
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `error matching value at 'request.querystring.arg': Expected "val2" but received "val1" at /value (using objectLike matcher)`)
+	assert.Contains(t, err.Error(), "value at 'request.body' is nil")
+}
+
+// Test data, example JSON decoded policy document
+var testPolicy = map[string]any{
+	"Version": "2012-10-17",
+	"Statement": []any{
+		map[string]any{
+			"Effect":   "Allow",
+			"Action":   []any{"sqs:SendMessage", "sqs:GetQueueAttributes", "sqs:GetQueueUrl"},
+			"Resource": "arn:aws:sqs:us-east-1:123456789012:queue",
+		},
+		map[string]any{
+			"Effect":   "Deny",
+			"Action":   "sqs:DeleteQueue",
+			"Resource": "*",
+		},
+	},
+}
@@ -0,0 +1,207 @@
+package integ
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValueType is the JSON type of a JMESPath result, used by Assertion.TypeIs
+type ValueType string
+
+const (
+	TypeString  ValueType = "string"
+	TypeNumber  ValueType = "number"
+	TypeBoolean ValueType = "boolean"
+	TypeArray   ValueType = "array"
+	TypeObject  ValueType = "object"
+	TypeNull    ValueType = "null"
+)
+
+// hasTypedMatchers returns true if any of the typed or structural matchers is set on the assertion
+func (a Assertion) hasTypedMatchers() bool {
+	return a.Equals != nil ||
+		a.NotEquals != nil ||
+		a.GreaterThan != nil ||
+		a.LessThan != nil ||
+		a.Length != nil ||
+		a.Contains != nil ||
+		a.OneOf != nil ||
+		a.TypeIs != "" ||
+		a.Matcher != nil
+}
+
+// matchTyped evaluates the typed matchers of the assertion against the JMESPath result.
+// Values are compared in their JSON shape, so `200`, `int32(200)` and `float64(200)` are equal.
+func (a Assertion) matchTyped(value any) []error {
+	var errs []error
+	actual := normalizeValue(value)
+	if a.Equals != nil && !valuesEqual(actual, a.Equals) {
+		errs = append(errs, fmt.Errorf("expected %s, got %s", formatValue(a.Equals), formatValue(value)))
+	}
+	if a.NotEquals != nil && valuesEqual(actual, a.NotEquals) {
+		errs = append(errs, fmt.Errorf("expected value not equal to %s, got %s", formatValue(a.NotEquals), formatValue(value)))
+	}
+	if a.GreaterThan != nil {
+		if n, ok := actual.(float64); !ok || n <= *a.GreaterThan {
+			errs = append(errs, fmt.Errorf("expected a number greater than %v, got %s", *a.GreaterThan, formatValue(value)))
+		}
+	}
+	if a.LessThan != nil {
+		if n, ok := actual.(float64); !ok || n >= *a.LessThan {
+			errs = append(errs, fmt.Errorf("expected a number less than %v, got %s", *a.LessThan, formatValue(value)))
+		}
+	}
+	if a.Length != nil {
+		if err := assertLength(actual, *a.Length); err != nil {
+			errs = append(errs, fmt.Errorf("%v, got %s", err, formatValue(value)))
+		}
+	}
+	if a.Contains != nil && !valueContains(actual, a.Contains) {
+		errs = append(errs, fmt.Errorf("expected value containing %s, got %s", formatValue(a.Contains), formatValue(value)))
+	}
+	if a.OneOf != nil {
+		found := false
+		for _, candidate := range a.OneOf {
+			if valuesEqual(actual, candidate) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Errorf("expected one of %v, got %s", a.OneOf, formatValue(value)))
+		}
+	}
+	if a.TypeIs != "" {
+		if actualType := typeOf(actual); actualType != a.TypeIs {
+			errs = append(errs, fmt.Errorf("expected type %s, got %s %s", a.TypeIs, actualType, formatValue(value)))
+		}
+	}
+	return errs
+}
+
+func assertLength(actual any, expected int) error {
+	var length int
+	switch v := actual.(type) {
+	case string:
+		length = utf8.RuneCountInString(v)
+	case []any:
+		length = len(v)
+	case map[string]any:
+		length = len(v)
+	default:
+		return fmt.Errorf("expected a string, array or object of length %d", expected)
+	}
+	if length != expected {
+		return fmt.Errorf("expected length %d but was %d", expected, length)
+	}
+	return nil
+}
+
+// valueContains checks for a substring, an array element or an object key
+func valueContains(actual any, expected any) bool {
+	switch v := actual.(type) {
+	case string:
+		s, ok := normalizeValue(expected).(string)
+		return ok && strings.Contains(v, s)
+	case []any:
+		for _, elem := range v {
+			if valuesEqual(elem, expected) {
+				return true
+			}
+		}
+	case map[string]any:
+		key, ok := normalizeValue(expected).(string)
+		if !ok {
+			return false
+		}
+		_, found := v[key]
+		return found
+	}
+	return false
+}
+
+// valuesEqual compares two values in their JSON shape
+func valuesEqual(a, b any) bool {
+	return reflect.DeepEqual(normalizeValue(a), normalizeValue(b))
+}
+
+// typeOf returns the JSON type of a normalized value
+func typeOf(v any) ValueType {
+	switch v.(type) {
+	case nil:
+		return TypeNull
+	case string:
+		return TypeString
+	case float64:
+		return TypeNumber
+	case bool:
+		return TypeBoolean
+	case []any:
+		return TypeArray
+	default:
+		return TypeObject
+	}
+}
+
+// formatValue prints a value with its Go type for error messages
+func formatValue(v any) string {
+	if s, ok := normalizeValue(v).(string); ok {
+		return fmt.Sprintf("%q (%T)", s, v)
+	}
+	return fmt.Sprintf("%v (%T)", normalizeValue(v), v)
+}
+
+// normalizeValue converts a JMESPath result to its JSON shape: pointers are dereferenced,
+// numbers become float64, slices become []any and maps become map[string]any.
+// Structs (i.e. SDK output types) are converted through JSON marshalling.
+func normalizeValue(value any) any {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Slice, reflect.Array:
+		result := make([]any, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result[i] = normalizeValue(v.Index(i).Interface())
+		}
+		return result
+	case reflect.Map:
+		result := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			result[fmt.Sprintf("%v", iter.Key().Interface())] = normalizeValue(iter.Value().Interface())
+		}
+		return result
+	default:
+		// Go type assertions fail on SDK structs, so we use JSON marshalling
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return v.Interface()
+		}
+		var result any
+		if err := json.Unmarshal(data, &result); err != nil {
+			return v.Interface()
+		}
+		return result
+	}
+}
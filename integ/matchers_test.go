@@ -0,0 +1,162 @@
+package integ
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssert_TypedMatchers_Success(t *testing.T) {
+	Assert(t, testObject, []Assertion{
+		{
+			Path:   "status",
+			Equals: 200,
+		},
+		{
+			Path:      "status",
+			NotEquals: "200",
+		},
+		{
+			Path:        "status",
+			GreaterThan: ptr(199.0),
+			LessThan:    ptr(300.0),
+		},
+		{
+			Path:   "request.cookies.loggedIn.value",
+			Equals: false,
+			TypeIs: TypeBoolean,
+		},
+		{
+			Path:   "request.headers.accept.multiValue[2].value",
+			Equals: int64(1),
+		},
+		{
+			Path:   "request.querystring.arg.multiValue",
+			Length: ptr(2),
+			TypeIs: TypeArray,
+		},
+		{
+			Path:   "request.uri",
+			Length: ptr(11),
+		},
+		{
+			Path:     "request.uri",
+			Contains: "index",
+		},
+		{
+			Path:     "array",
+			Contains: "val2",
+		},
+		{
+			Path:     "request.headers",
+			Contains: "host",
+			TypeIs:   TypeObject,
+		},
+		{
+			Path:  "request.method",
+			OneOf: []any{"GET", "HEAD"},
+		},
+		{
+			Path:   "array",
+			Equals: []any{"val1", "val2"},
+		},
+		{
+			Path:   "request.querystring.arg.multiValue[0]",
+			Equals: map[string]any{"value": "val1"},
+		},
+		{
+			Path:   "request.headers.authorization",
+			TypeIs: TypeNull,
+		},
+	})
+}
+
+func TestAssert_TypedMatchers_Failure(t *testing.T) {
+	tests := []struct {
+		name        string
+		assertion   Assertion
+		errContains string
+	}{
+		{
+			name:        "equals wrong type",
+			assertion:   Assertion{Path: "status", Equals: "200"},
+			errContains: `expected "200" (string), got 200 (int)`,
+		},
+		{
+			name:        "not equals",
+			assertion:   Assertion{Path: "request.method", NotEquals: "GET"},
+			errContains: `expected value not equal to "GET" (string)`,
+		},
+		{
+			name:        "greater than",
+			assertion:   Assertion{Path: "status", GreaterThan: ptr(200.0)},
+			errContains: "expected a number greater than 200, got 200 (int)",
+		},
+		{
+			name:        "less than non numeric",
+			assertion:   Assertion{Path: "request.uri", LessThan: ptr(1.0)},
+			errContains: `expected a number less than 1, got "/index.html" (string)`,
+		},
+		{
+			name:        "length",
+			assertion:   Assertion{Path: "array", Length: ptr(3)},
+			errContains: "expected length 3 but was 2",
+		},
+		{
+			name:        "length of number",
+			assertion:   Assertion{Path: "status", Length: ptr(3)},
+			errContains: "expected a string, array or object of length 3",
+		},
+		{
+			name:        "contains",
+			assertion:   Assertion{Path: "array", Contains: "val3"},
+			errContains: `expected value containing "val3" (string)`,
+		},
+		{
+			name:        "one of",
+			assertion:   Assertion{Path: "request.method", OneOf: []any{"POST", "PUT"}},
+			errContains: `expected one of [POST PUT], got "GET" (string)`,
+		},
+		{
+			name:        "type is",
+			assertion:   Assertion{Path: "request.querystring.test.value", TypeIs: TypeString},
+			errContains: "expected type string, got boolean true (bool)",
+		},
+		{
+			name:        "missing value",
+			assertion:   Assertion{Path: "request.querystring.foo", Equals: "bar"},
+			errContains: "value at 'request.querystring.foo' is nil",
+		},
+		{
+			name:        "null value with equals",
+			assertion:   Assertion{Path: "request.headers.authorization", TypeIs: TypeNull, Equals: "x"},
+			errContains: `expected "x" (string), got <nil>`,
+		},
+		{
+			name:        "absent value with regexp",
+			assertion:   Assertion{Path: "request.body", ExpectedRegexp: ptr("^x$"), Matcher: Absent()},
+			errContains: "value at 'request.body' is nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AssertE(testObject, []Assertion{tt.assertion})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.errContains)
+			}
+		})
+	}
+}
+
+func TestNormalizeValue(t *testing.T) {
+	type sdkOutput struct {
+		Name  *string
+		Count *int32
+	}
+	assert.Equal(t, float64(3), normalizeValue(ptr(int32(3))))
+	assert.Equal(t, []any{"a", "b"}, normalizeValue([]string{"a", "b"}))
+	assert.Equal(t, map[string]any{"Name": "queue", "Count": float64(2)},
+		normalizeValue(sdkOutput{Name: ptr("queue"), Count: ptr(int32(2))}))
+	assert.Nil(t, normalizeValue((*string)(nil)))
+}
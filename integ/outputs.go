@@ -0,0 +1,147 @@
+package integ
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// OutputsLoader fetches all Terraform outputs, i.e. using `terraform output -json`
+type OutputsLoader func(t *testing.T) (map[string]any, error)
+
+// Outputs caches Terraform outputs, they are fetched once on first use and served from memory
+// until Reload is called after a stage changes state.
+type Outputs struct {
+	Strict bool // Fail queries and attribute lookups which do not resolve to a value
+
+	mu     sync.Mutex
+	load   OutputsLoader
+	values map[string]any
+}
+
+// NewOutputs returns an Outputs cache using load to fetch the outputs
+func NewOutputs(load OutputsLoader) *Outputs {
+	return &Outputs{load: load}
+}
+
+// StaticOutputs returns an Outputs cache serving values, i.e. for tests
+func StaticOutputs(values map[string]any) *Outputs {
+	return NewOutputs(func(t *testing.T) (map[string]any, error) {
+		return values, nil
+	})
+}
+
+// ValuesE returns all outputs, fetching them if they are not cached yet
+func (o *Outputs) ValuesE(t *testing.T) (map[string]any, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.values != nil {
+		return o.values, nil
+	}
+	values, err := o.load(t)
+	if err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = map[string]any{}
+	}
+	o.values = values
+	return values, nil
+}
+
+// Reload drops the cached outputs, they are fetched again on next use
+func (o *Outputs) Reload() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.values = nil
+}
+
+// Query searches the outputs using JMESPath. This fails the test on any errors
+func (o *Outputs) Query(t *testing.T, query string) any {
+	value, err := o.QueryE(t, query)
+	require.NoError(t, err)
+	return value
+}
+
+// QueryE searches the outputs using JMESPath. In Strict mode, queries without result return an error.
+func (o *Outputs) QueryE(t *testing.T, query string) (any, error) {
+	values, err := o.ValuesE(t)
+	if err != nil {
+		return nil, err
+	}
+	value, err := SearchJMESPath(query, values)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil && o.Strict {
+		return nil, fmt.Errorf("output query %q has no result, available outputs: %v", query, sortedKeys(values))
+	}
+	return value, nil
+}
+
+// Attribute returns the attribute of an output key and ensures it is not empty. This fails the test on any errors
+func (o *Outputs) Attribute(t *testing.T, key, attribute string) string {
+	value, err := o.AttributeE(t, key, attribute)
+	require.NoError(t, err)
+	require.NotEmpty(t, value, fmt.Sprintf("Output %s.%s should not be empty", key, attribute))
+	return value
+}
+
+// AttributeE returns the attribute of an output key as string.
+// In Strict mode, missing keys and attributes return an error, otherwise an empty string.
+func (o *Outputs) AttributeE(t *testing.T, key, attribute string) (string, error) {
+	values, err := o.ValuesE(t)
+	if err != nil {
+		return "", err
+	}
+	output, ok := values[key]
+	if !ok {
+		if o.Strict {
+			return "", fmt.Errorf("output %q not found, available outputs: %v", key, sortedKeys(values))
+		}
+		return "", nil
+	}
+	outputMap, ok := output.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("output %q is not an object, got %T", key, output)
+	}
+	value, ok := outputMap[attribute]
+	if !ok || value == nil {
+		if o.Strict {
+			return "", fmt.Errorf("output %q has no attribute %q, available attributes: %v", key, attribute, sortedKeys(outputMap))
+		}
+		return "", nil
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
+// OutputsJMES searches the cached outputs using JMESPath and converts to the desired type. This fails the test on any errors
+func OutputsJMES[T any](t *testing.T, o *Outputs, query string) T {
+	result, err := OutputsJMESE[T](t, o, query)
+	require.NoError(t, err)
+	return result
+}
+
+// OutputsJMESE searches the cached outputs using JMESPath and converts to the desired type.
+func OutputsJMESE[T any](t *testing.T, o *Outputs, query string) (T, error) {
+	var result T
+	value, err := o.QueryE(t, query)
+	if err != nil {
+		return result, err
+	}
+	// Go type assertions fail on this, so we use JSON marshalling
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return result, fmt.Errorf("failed to marshal value to JSON: %v", err)
+	}
+	if err := json.Unmarshal(jsonData, &result); err != nil {
+		return result, fmt.Errorf("failed to convert output query %q to %T: %v", query, result, err)
+	}
+	return result, nil
+}
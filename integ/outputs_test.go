@@ -0,0 +1,83 @@
+package integ
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingOutputs returns an Outputs cache over fixed values, counting the loads
+func countingOutputs(loads *int) *Outputs {
+	return NewOutputs(func(t *testing.T) (map[string]any, error) {
+		*loads++
+		return map[string]any{
+			"our_star": "Sun",
+			"stars":    []any{"Sun", "Sirius", "Betelgeuse"},
+			"queue": map[string]any{
+				"url":  "https://sqs.us-east-1.amazonaws.com/123456789012/orders",
+				"arn":  "arn:aws:sqs:us-east-1:123456789012:orders",
+				"size": float64(3),
+			},
+		}, nil
+	})
+}
+
+func TestOutputs_LoadOnce(t *testing.T) {
+	loads := 0
+	o := countingOutputs(&loads)
+
+	assert.Equal(t, "Sun", o.Query(t, "our_star"))
+	assert.Equal(t, "Betelgeuse", o.Query(t, "stars[2]"))
+	assert.Equal(t, "https://sqs.us-east-1.amazonaws.com/123456789012/orders", o.Attribute(t, "queue", "url"))
+	assert.Equal(t, "3", o.Attribute(t, "queue", "size"))
+	assert.Equal(t, []string{"Sun", "Sirius", "Betelgeuse"}, OutputsJMES[[]string](t, o, "stars"))
+	assert.Equal(t, 1, loads)
+
+	o.Reload()
+	assert.Equal(t, "arn:aws:sqs:us-east-1:123456789012:orders", o.Attribute(t, "queue", "arn"))
+	assert.Equal(t, 2, loads)
+}
+
+func TestOutputs_Strict(t *testing.T) {
+	loads := 0
+	o := countingOutputs(&loads)
+
+	value, err := o.QueryE(t, "missing")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+	attr, err := o.AttributeE(t, "missing", "url")
+	require.NoError(t, err)
+	assert.Empty(t, attr)
+
+	o.Strict = true
+	_, err = o.QueryE(t, "missing")
+	assert.EqualError(t, err, `output query "missing" has no result, available outputs: [our_star queue stars]`)
+	_, err = o.AttributeE(t, "missing", "url")
+	assert.EqualError(t, err, `output "missing" not found, available outputs: [our_star queue stars]`)
+	_, err = o.AttributeE(t, "queue", "name")
+	assert.EqualError(t, err, `output "queue" has no attribute "name", available attributes: [arn size url]`)
+}
+
+func TestOutputs_Errors(t *testing.T) {
+	loads := 0
+	o := countingOutputs(&loads)
+	_, err := o.AttributeE(t, "our_star", "name")
+	assert.EqualError(t, err, `output "our_star" is not an object, got string`)
+	_, err = OutputsJMESE[int](t, o, "our_star")
+	assert.ErrorContains(t, err, `failed to convert output query "our_star" to int`)
+
+	failing := NewOutputs(func(t *testing.T) (map[string]any, error) {
+		return nil, errors.New("no state")
+	})
+	_, err = failing.QueryE(t, "our_star")
+	assert.EqualError(t, err, "no state")
+}
+
+func TestStaticOutputs(t *testing.T) {
+	o := StaticOutputs(nil)
+	values, err := o.ValuesE(t)
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
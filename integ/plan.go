@@ -0,0 +1,56 @@
+package integ
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// AssertPlan asserts the JSON representation of a Terraform plan (`tofu show -json <planfile>`) using JMESPath.
+// This allows checking construct wiring without deploying, i.e. `resource_changes[?type=='aws_sqs_queue'].change.after`.
+func AssertPlan(t *testing.T, plan *tfjson.Plan, assertions []Assertion) {
+	if err := AssertPlanE(plan, assertions); err != nil {
+		t.Errorf("failed plan assertions: %v", err)
+	}
+}
+
+// AssertPlanE asserts the JSON representation of a Terraform plan using JMESPath.
+func AssertPlanE(plan *tfjson.Plan, assertions []Assertion) error {
+	input, err := PlanJSON(plan)
+	if err != nil {
+		return err
+	}
+	return AssertE(input, assertions)
+}
+
+// PlanJSON converts the plan to its JSON representation for JMESPath searches
+func PlanJSON(plan *tfjson.Plan) (map[string]any, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("plan is nil")
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling plan: %v", err)
+	}
+	var result map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("error unmarshalling plan: %v", err)
+	}
+	return result, nil
+}
+
+// LoadPlanE reads a plan in JSON format, i.e. written by `tofu show -json <planfile> > plan.json`
+func LoadPlanE(path string) (*tfjson.Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var plan tfjson.Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("error parsing plan %s: %v", path, err)
+	}
+	return &plan, nil
+}
@@ -0,0 +1,72 @@
+package integ
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertPlan(t *testing.T) {
+	plan, err := LoadPlanE(filepath.Join("fixtures", "plan", "sqs.json"))
+	require.NoError(t, err)
+
+	AssertPlan(t, plan, []Assertion{
+		{
+			Path:   "length(resource_changes[?type=='aws_sqs_queue'])",
+			Equals: 2,
+		},
+		{
+			Path:   "resource_changes[?type=='aws_sqs_queue'].change.actions[]",
+			Length: ptr(2),
+		},
+		{
+			Path: "resource_changes[?type=='aws_sqs_queue'].change.after",
+			Matcher: ArrayWith([]any{
+				ObjectLike(map[string]any{
+					"kms_master_key_id": "alias/aws/sqs",
+					"name_prefix":       StringLikeRegexp("sqsQueue$"),
+				}),
+			}),
+		},
+		{
+			Path: "resource_changes[?type=='aws_sqs_queue_policy'] | [0].change.after.policy",
+			Matcher: SerializedJSON(ObjectLike(map[string]any{
+				"Statement": ArrayWith([]any{
+					ObjectLike(map[string]any{"Effect": "Deny"}),
+				}),
+			})),
+		},
+		{
+			Path:   "resource_changes[?type=='aws_sqs_queue' && name=='Queue_4A7E3555'] | [0].change.after_unknown.redrive_policy",
+			Equals: true,
+		},
+		{
+			Path:   "output_changes.QueueUrl.actions[0]",
+			Equals: "create",
+		},
+	})
+}
+
+func TestAssertPlan_Failure(t *testing.T) {
+	plan, err := LoadPlanE(filepath.Join("fixtures", "plan", "sqs.json"))
+	require.NoError(t, err)
+
+	err = AssertPlanE(plan, []Assertion{
+		{
+			Path:   "resource_changes[?type=='aws_sqs_queue'] | [0].change.after.fifo_queue",
+			Equals: true,
+		},
+	})
+	assert.ErrorContains(t, err, "expected true (bool), got false (bool)")
+
+	assert.EqualError(t, AssertPlanE(nil, nil), "plan is nil")
+}
+
+func TestLoadPlan_Invalid(t *testing.T) {
+	_, err := LoadPlanE(filepath.Join("fixtures", "plan", "missing.json"))
+	assert.Error(t, err)
+	_, err = LoadPlanE(filepath.Join("fixtures", "jmespath-functions", "lambda-invoke.json"))
+	assert.ErrorContains(t, err, "error parsing plan")
+}
@@ -0,0 +1,113 @@
+package integ
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+)
+
+const (
+	// RunIDEnv sets the run ID of the integration tests, either an ID or AutoRunID to generate one,
+	// i.e. INTEG_RUN_ID=auto make queue or INTEG_RUN_ID=4f2a9c make queue-cleanup-only
+	RunIDEnv = "INTEG_RUN_ID"
+	// AutoRunID generates a new run ID, or resumes the last recorded run if the synth stage is skipped
+	AutoRunID = "auto"
+
+	// runIDsTestData is the test data folder the runs of an app are recorded in, next to the saved Terraform options
+	runIDsTestData = "run-ids"
+	// noRunIDRecord records a run without run ID, it is not a valid run ID
+	noRunIDRecord = "_"
+)
+
+// runRecord is the record of a run of an app, see SaveRunIDE
+type runRecord struct {
+	RunID     string    `json:"runId"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// run IDs end up in resource names, keep them short and lower case
+var runIDPattern = regexp.MustCompile(`^[a-z0-9]{1,12}$`)
+
+// NewRunID returns a random run ID
+func NewRunID() string {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("error generating run ID: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// ValidateRunIDE checks the run ID is safe to use in resource names
+func ValidateRunIDE(runID string) error {
+	if !runIDPattern.MatchString(runID) {
+		return fmt.Errorf("invalid run ID %q, expected 1 to 12 lower case letters or digits", runID)
+	}
+	return nil
+}
+
+// RunTfWorkingDir returns the Terraform working directory of the run, i.e. tf/sqs-4f2a9c
+func RunTfWorkingDir(tfWorkingDir, runID string) string {
+	if runID == "" {
+		return tfWorkingDir
+	}
+	return tfWorkingDir + "-" + runID
+}
+
+// SaveRunIDE records a run of the app started now in the test data of testFolder, an empty runID records a run without run ID.
+// Every run ID has its own record, so concurrent runs of the app do not overwrite each other's record.
+func SaveRunIDE(testFolder, runID string) error {
+	data, err := json.Marshal(runRecord{RunID: runID, StartedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	path := formatRunIDPath(testFolder, runID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadRunIDE returns the run ID of the last run started in testFolder, or an empty string if there is none or it had no run ID
+func LoadRunIDE(testFolder string) (string, error) {
+	dir := filepath.Dir(formatRunIDPath(testFolder, ""))
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var last runRecord
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return "", err
+		}
+		var record runRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return "", fmt.Errorf("error decoding run %s of %s: %v", entry.Name(), testFolder, err)
+		}
+		if record.StartedAt.After(last.StartedAt) {
+			last = record
+		}
+	}
+	return last.RunID, nil
+}
+
+func formatRunIDPath(testFolder, runID string) string {
+	if runID == "" {
+		runID = noRunIDRecord
+	}
+	return test_structure.FormatTestDataPath(testFolder, filepath.Join(runIDsTestData, runID+".json"))
+}
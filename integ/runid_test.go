@@ -0,0 +1,130 @@
+package integ
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRunID(t *testing.T) {
+	runID := NewRunID()
+	assert.Len(t, runID, 6)
+	assert.NoError(t, ValidateRunIDE(runID))
+	assert.NotEqual(t, runID, NewRunID())
+}
+
+func TestValidateRunIDE(t *testing.T) {
+	assert.NoError(t, ValidateRunIDE("ci1234"))
+	for _, runID := range []string{"", "CI1234", "ci-1234", "ci_1234", "a1b2c3d4e5f6g"} {
+		assert.Error(t, ValidateRunIDE(runID), runID)
+	}
+}
+
+func TestRunTfWorkingDir(t *testing.T) {
+	tfWorkingDir := filepath.Join("tf", "sqs")
+	assert.Equal(t, tfWorkingDir, RunTfWorkingDir(tfWorkingDir, ""))
+	assert.Equal(t, tfWorkingDir+"-4f2a9c", RunTfWorkingDir(tfWorkingDir, "4f2a9c"))
+}
+
+func TestSaveRunIDE(t *testing.T) {
+	testFolder := filepath.Join(t.TempDir(), "sqs")
+	runID, err := LoadRunIDE(testFolder)
+	require.NoError(t, err)
+	assert.Empty(t, runID)
+
+	require.NoError(t, SaveRunIDE(testFolder, "4f2a9c"))
+	data, err := os.ReadFile(filepath.Join(testFolder, ".test-data", "run-ids", "4f2a9c.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"runId":"4f2a9c"`)
+	runID, err = LoadRunIDE(testFolder)
+	require.NoError(t, err)
+	assert.Equal(t, "4f2a9c", runID)
+
+	// the last run started has no run ID
+	require.NoError(t, SaveRunIDE(testFolder, ""))
+	require.NoError(t, SaveRunIDE(testFolder, ""))
+	runID, err = LoadRunIDE(testFolder)
+	require.NoError(t, err)
+	assert.Empty(t, runID)
+
+	// a concurrent run keeps the record of the other run
+	require.NoError(t, SaveRunIDE(testFolder, "ci1234"))
+	runID, err = LoadRunIDE(testFolder)
+	require.NoError(t, err)
+	assert.Equal(t, "ci1234", runID)
+	assert.FileExists(t, filepath.Join(testFolder, ".test-data", "run-ids", "4f2a9c.json"))
+	require.NoError(t, SaveRunIDE(testFolder, "4f2a9c"))
+	runID, err = LoadRunIDE(testFolder)
+	require.NoError(t, err)
+	assert.Equal(t, "4f2a9c", runID)
+}
+
+func TestRunner_RunID(t *testing.T) {
+	lifecycle := &fakeLifecycle{}
+	t.Setenv(RunIDEnv, "")
+	tfWorkingDir := filepath.Join(t.TempDir(), "sqs")
+	r := NewRunner(lifecycle, "sqs", WithTfWorkingDir(tfWorkingDir), WithRunID(AutoRunID))
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, nil)
+	})
+
+	require.NotEmpty(t, r.RunID)
+	runTfWorkingDir := tfWorkingDir + "-" + r.RunID
+	assert.Equal(t, runTfWorkingDir, r.TfWorkingDir)
+	assert.Equal(t, []string{
+		"synth sqs " + runTfWorkingDir + " test-" + r.RunID,
+		"deploy " + runTfWorkingDir,
+		"destroy " + runTfWorkingDir,
+	}, lifecycle.calls)
+	env := lifecycle.envs[0]
+	assert.Equal(t, "sqs-"+r.RunID, env["STACK_NAME"])
+	assert.Equal(t, r.RunID, env[RunIDEnv])
+
+	recorded, err := LoadRunIDE(tfWorkingDir)
+	require.NoError(t, err)
+	assert.Equal(t, r.RunID, recorded)
+}
+
+func TestRunner_RunIDResume(t *testing.T) {
+	tfWorkingDir := filepath.Join(t.TempDir(), "sqs")
+	require.NoError(t, SaveRunIDE(tfWorkingDir, "4f2a9c"))
+	t.Setenv(SkipStageEnvPrefix+StageSynth, "true")
+	t.Setenv(SkipStageEnvPrefix+StageDeploy, "true")
+
+	t.Run("recorded", func(t *testing.T) {
+		t.Setenv(RunIDEnv, "")
+		lifecycle := &fakeLifecycle{}
+		r := NewRunner(lifecycle, "sqs", WithTfWorkingDir(tfWorkingDir))
+		runParallel(t, func(t *testing.T) {
+			r.Run(t, nil)
+		})
+		assert.Equal(t, "4f2a9c", r.RunID)
+		assert.Equal(t, []string{"destroy " + tfWorkingDir + "-4f2a9c"}, lifecycle.calls)
+	})
+
+	t.Run("targeted", func(t *testing.T) {
+		t.Setenv(RunIDEnv, "ci1234")
+		lifecycle := &fakeLifecycle{}
+		r := NewRunner(lifecycle, "sqs", WithTfWorkingDir(tfWorkingDir), WithRunID(AutoRunID))
+		runParallel(t, func(t *testing.T) {
+			r.Run(t, nil)
+		})
+		assert.Equal(t, "ci1234", r.RunID)
+		assert.Equal(t, []string{"destroy " + tfWorkingDir + "-ci1234"}, lifecycle.calls)
+	})
+}
+
+func TestRunner_RunIDNotRecorded(t *testing.T) {
+	r := NewRunner(&fakeLifecycle{}, "sqs", WithTfWorkingDir(filepath.Join(t.TempDir(), "sqs")), WithRunID(AutoRunID))
+	t.Setenv(RunIDEnv, "")
+	t.Setenv(SkipStageEnvPrefix+StageSynth, "true")
+	_, err := r.resolveRunIDE()
+	assert.ErrorContains(t, err, "no run of sqs recorded in")
+
+	t.Setenv(RunIDEnv, "Not-Valid")
+	_, err = r.resolveRunIDE()
+	assert.ErrorContains(t, err, `invalid run ID "Not-Valid"`)
+}
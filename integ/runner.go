@@ -0,0 +1,528 @@
+package integ
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+)
+
+// Stages of the integration test lifecycle, set `SKIP_<stage>` to skip a stage, i.e. SKIP_cleanup_terraform=true
+const (
+	StageSynth    = "synth_app"
+	StageDeploy   = "deploy_terraform"
+	StageValidate = "validate"
+	StageCleanup  = "cleanup_terraform"
+
+	// SkipStageEnvPrefix is the prefix of the environment variables skipping stages, see RunStage
+	SkipStageEnvPrefix = test_structure.SKIP_STAGE_ENV_VAR_PREFIX
+)
+
+// Lifecycle synthesizes, deploys and destroys integration test apps.
+// integ/aws provides the implementation using SynthApp and Terraform.
+type Lifecycle interface {
+	Synth(t *testing.T, testApp, tfWorkingDir string, env map[string]string, assets ...string)
+	Deploy(t *testing.T, tfWorkingDir string, retryableErrors map[string]string)
+	Destroy(t *testing.T, tfWorkingDir string)
+}
+
+// ValidateFunc validates the deployed app
+type ValidateFunc func(t *testing.T, tfWorkingDir string, awsRegion string)
+
+// StageFunc runs an extra stage of the Runner, i.e. re-synthesizing the app with a different environment name
+type StageFunc func(t *testing.T, r *Runner)
+
+// Runner runs the synth → deploy → validate → cleanup stages of an integration test app,
+// with optional extra stages before synth, before and after validate and after cleanup.
+type Runner struct {
+	TestApp         string
+	TfWorkingDir    string            // defaults to tf/<TestApp>
+	Region          string            // AWS_REGION of the app, defaults to us-east-1
+	Env             map[string]string // Environment of the app synth, see NewRunner for defaults
+	Assets          []string          // Assets copied from the apps folder to the synth app, i.e. "handlers"
+	RetryableErrors map[string]string // Additional errors retried by terraform apply
+	RunID           string            // ID of the run, set by Run if a run ID is requested, see WithRunID
+	CleanupBudget   time.Duration     // time reserved to clean up before the go test deadline, defaults to DefaultCleanupBudget
+
+	lifecycle   Lifecycle
+	envOverride map[string]string
+	runID       string
+	dirSuffix   string   // suffix of TfWorkingDir applied by Run, see WithTfWorkingDirSuffix
+	journal     *Journal // stages of this run, see Run
+	resumeFrom  *Journal // journal of the resumed run, nil unless resuming
+	stop        context.Context
+	stopped     bool
+	setup       []namedStage
+	before      []namedStage
+	after       []namedStage
+	cleanup     []namedStage
+}
+
+type namedStage struct {
+	name string
+	fn   StageFunc
+}
+
+// RunnerOption configures a Runner
+type RunnerOption func(r *Runner)
+
+// WithRegion sets the AWS region of the app
+func WithRegion(region string) RunnerOption {
+	return func(r *Runner) {
+		r.Region = region
+	}
+}
+
+// WithEnv overrides environment variables of the app synth, applied after the defaults
+func WithEnv(env map[string]string) RunnerOption {
+	return func(r *Runner) {
+		for k, v := range env {
+			r.envOverride[k] = v
+		}
+	}
+}
+
+// WithAssets adds assets copied from the apps folder to the synth app
+func WithAssets(assets ...string) RunnerOption {
+	return func(r *Runner) {
+		r.Assets = append(r.Assets, assets...)
+	}
+}
+
+// WithRetryableErrors adds error patterns retried by terraform apply, mapped to the reason logged
+func WithRetryableErrors(retryableErrors map[string]string) RunnerOption {
+	return func(r *Runner) {
+		for k, v := range retryableErrors {
+			r.RetryableErrors[k] = v
+		}
+	}
+}
+
+// WithTfWorkingDir sets the Terraform working directory of the app
+func WithTfWorkingDir(tfWorkingDir string) RunnerOption {
+	return func(r *Runner) {
+		r.TfWorkingDir = tfWorkingDir
+	}
+}
+
+// WithTfWorkingDirSuffix suffixes the Terraform working directory with "-<suffix>", i.e. tf/sqs-upgrade.
+// The suffix is applied when the app runs, so it is kept whatever the order of WithTfWorkingDir and this option.
+func WithTfWorkingDirSuffix(suffix string) RunnerOption {
+	return func(r *Runner) {
+		r.dirSuffix = suffix
+	}
+}
+
+// WithLifecycle replaces the Lifecycle of the runner, i.e. to deploy with another binary
+func WithLifecycle(lifecycle Lifecycle) RunnerOption {
+	return func(r *Runner) {
+		r.lifecycle = lifecycle
+	}
+}
+
+// WithCleanupBudget reserves budget before the go test deadline to clean up the app, instead of DefaultCleanupBudget.
+// The INTEG_CLEANUP_BUDGET environment variable takes precedence.
+func WithCleanupBudget(budget time.Duration) RunnerOption {
+	return func(r *Runner) {
+		r.CleanupBudget = budget
+	}
+}
+
+// WithRunID isolates the run of the app from concurrent runs, either with the given ID or AutoRunID to generate one.
+// The INTEG_RUN_ID environment variable takes precedence, see Run.
+func WithRunID(runID string) RunnerOption {
+	return func(r *Runner) {
+		r.runID = runID
+	}
+}
+
+// WithStageBeforeSynth runs an extra named stage before synth, i.e. to pin synth dependencies
+func WithStageBeforeSynth(name string, fn StageFunc) RunnerOption {
+	return func(r *Runner) {
+		r.setup = append(r.setup, namedStage{name, fn})
+	}
+}
+
+// WithStageBeforeValidate runs an extra named stage after deploy
+func WithStageBeforeValidate(name string, fn StageFunc) RunnerOption {
+	return func(r *Runner) {
+		r.before = append(r.before, namedStage{name, fn})
+	}
+}
+
+// WithStageAfterValidate runs an extra named stage after validate, stages run in the order they are added
+func WithStageAfterValidate(name string, fn StageFunc) RunnerOption {
+	return func(r *Runner) {
+		r.after = append(r.after, namedStage{name, fn})
+	}
+}
+
+// WithStageAfterCleanup runs an extra named stage after the cleanup stage, i.e. to verify nothing remains.
+// The stage does not run if cleanup is skipped or fails.
+func WithStageAfterCleanup(name string, fn StageFunc) RunnerOption {
+	return func(r *Runner) {
+		r.cleanup = append(r.cleanup, namedStage{name, fn})
+	}
+}
+
+// NewRunner returns a Runner for testApp. The app environment defaults to the process environment with
+// AWS_REGION set to the Region, ENVIRONMENT_NAME to "test" and STACK_NAME to testApp, see WithEnv to override them.
+func NewRunner(lifecycle Lifecycle, testApp string, opts ...RunnerOption) *Runner {
+	r := &Runner{
+		TestApp:         testApp,
+		TfWorkingDir:    filepath.Join("tf", testApp),
+		Region:          "us-east-1",
+		RetryableErrors: map[string]string{},
+		lifecycle:       lifecycle,
+		envOverride:     map[string]string{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.Env = envMap(os.Environ())
+	r.Env["AWS_REGION"] = r.Region
+	r.Env["ENVIRONMENT_NAME"] = "test"
+	r.Env["STACK_NAME"] = testApp
+	for k, v := range r.envOverride {
+		r.Env[k] = v
+	}
+	return r
+}
+
+// Run runs the stages of the app in parallel with other tests, the cleanup stage runs even if a stage fails.
+// validate may be nil to only check the app deploys.
+//
+// If a run ID is requested (INTEG_RUN_ID or WithRunID), STACK_NAME and ENVIRONMENT_NAME are suffixed with it and the
+// app uses its own working directory (tf/<app>-<run ID>). Each run is recorded in the test data of tf/<app>, so runs
+// skipping synth (i.e. `make queue-cleanup-only`) resume the last run started unless INTEG_RUN_ID targets another one.
+//
+// The stages are recorded in a Journal next to the saved Terraform options. With INTEG_RESUME=true, the last run
+// continues after the stages that succeeded, or at cleanup if an earlier cleanup did not succeed.
+// INTEG_STATUS=true logs the journal of the last run and skips the test.
+//
+// Run stops the app CleanupBudget before the `go test -timeout` deadline, or on SIGINT or SIGTERM, and cleans it up:
+// stages not started yet are skipped, running validation stages stop at their next retry (see RunContext) and the test fails.
+// Deployed apps are listed in StateHeldFile next to the working directory until their cleanup succeeds.
+//
+// The working directory is locked for the whole run, a run of the same app in another test binary fails with
+// the pid, host and start time of the lock holder. Locks of test binaries gone from this host are taken over,
+// INTEG_BREAK_LOCK=true takes over any lock.
+func (r *Runner) Run(t *testing.T, validate ValidateFunc) {
+	t.Parallel()
+
+	if r.dirSuffix != "" {
+		r.TfWorkingDir += "-" + r.dirSuffix
+		r.dirSuffix = ""
+	}
+	runID, err := r.resolveRunIDE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PrintingStatus() {
+		if runID != "" {
+			r.applyRunID(runID)
+		}
+		r.printStatus(t)
+		return
+	}
+	tfWorkingDir := r.TfWorkingDir
+	if runID != "" {
+		t.Logf("Running %s with run ID %s", r.TestApp, runID)
+		r.applyRunID(runID)
+	}
+	r.lockWorkingDir(t)
+	// the record of the run ID is only written by the run holding its working directory
+	if err := SaveRunIDE(tfWorkingDir, runID); err != nil {
+		t.Fatalf("error recording run ID of %s: %v", r.TestApp, err)
+	}
+	r.startJournal(t)
+
+	budget := r.CleanupBudget
+	if budget == 0 {
+		budget = DefaultCleanupBudget(t)
+	}
+	budget, err = CleanupBudgetE(budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.stop, r.stopped = StopContext(t, budget), false
+	if errors.Is(context.Cause(r.stop), ErrInterrupted) {
+		t.Fatalf("Not running %s: %v", r.TestApp, ErrInterrupted)
+	}
+
+	defer func() {
+		cleanedUp := false
+		r.runStage(t, StageCleanup, cleanupStage, func() {
+			r.lifecycle.Destroy(t, r.TfWorkingDir)
+			cleanedUp = true
+		})
+		if cleanedUp {
+			r.releaseState(t)
+			r.runStages(t, r.cleanup, afterCleanupStage)
+		} else if r.holdsState() {
+			t.Logf("[WARNING] %s still holds state in %s, see %s", r.TestApp, r.TfWorkingDir, r.stateHeldFile())
+		}
+	}()
+
+	r.runStages(t, r.setup, setupStage)
+	r.runStage(t, StageSynth, setupStage, func() {
+		r.lifecycle.Synth(t, r.TestApp, r.TfWorkingDir, r.Env, r.Assets...)
+	})
+	r.runStage(t, StageDeploy, setupStage, func() {
+		r.holdState(t)
+		r.lifecycle.Deploy(t, r.TfWorkingDir, r.RetryableErrors)
+	})
+	r.runStages(t, r.before, validationStage)
+	if validate != nil {
+		r.runStage(t, StageValidate, validationStage, func() {
+			validate(t, r.TfWorkingDir, r.Region)
+		})
+	}
+	r.runStages(t, r.after, validationStage)
+}
+
+// stageKind decides how a stage is stopped
+type stageKind int
+
+const (
+	// setupStage stages are skipped once the run is stopped, running ones finish, i.e. an apply
+	setupStage stageKind = iota
+	// validationStage stages are skipped once the run is stopped, running ones stop through RunContext
+	validationStage
+	// cleanupStage stages always run
+	cleanupStage
+	// afterCleanupStage stages always run, running ones end early through RunContext without failing the test,
+	// i.e. the leak check
+	afterCleanupStage
+)
+
+// startJournal continues the journal of the last run if it is resumed or synth is skipped, otherwise it starts a new one
+func (r *Runner) startJournal(t *testing.T) {
+	last, err := LoadJournalE(r.TfWorkingDir)
+	if err != nil {
+		t.Logf("Ignoring the journal of %s: %v", r.TestApp, err)
+	}
+	r.resumeFrom = nil
+	switch {
+	case Resuming() && last != nil && !last.Completed():
+		t.Logf("Resuming the last run of %s\n%s", r.TestApp, last.Status(r.TfWorkingDir))
+		resumeFrom := *last
+		resumeFrom.Entries = append([]JournalEntry(nil), last.Entries...)
+		r.resumeFrom = &resumeFrom
+		r.journal = last
+	case Resuming():
+		t.Logf("No run of %s to resume, starting a new run", r.TestApp)
+		r.journal = &Journal{App: r.TestApp, RunID: r.RunID}
+	case os.Getenv(SkipStageEnvPrefix+StageSynth) != "" && last != nil:
+		r.journal = last
+	default:
+		r.journal = &Journal{App: r.TestApp, RunID: r.RunID}
+	}
+}
+
+// printStatus logs the journal of the last run, see StatusEnv
+func (r *Runner) printStatus(t *testing.T) {
+	journal, err := LoadJournalE(r.TfWorkingDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if journal == nil {
+		t.Skipf("No journal of %s in %s", r.TestApp, r.TfWorkingDir)
+	}
+	t.Skipf("%s is set, not running %s\n%s", StatusEnv, r.TestApp, journal.Status(r.TfWorkingDir))
+}
+
+// resolveRunIDE returns the requested run ID, a new one for AutoRunID or the recorded one when synth is skipped
+func (r *Runner) resolveRunIDE() (string, error) {
+	requested := r.runID
+	if runID := os.Getenv(RunIDEnv); runID != "" {
+		requested = runID
+	}
+	// a resumed run may start over, a run skipping synth must continue the last one
+	mustContinue := os.Getenv(SkipStageEnvPrefix+StageSynth) != "" || PrintingStatus()
+	resuming := mustContinue || Resuming()
+	switch {
+	case requested == AutoRunID && !resuming:
+		return NewRunID(), nil
+	case requested == AutoRunID || (requested == "" && resuming):
+		runID, err := LoadRunIDE(r.TfWorkingDir)
+		if err != nil {
+			return "", err
+		}
+		if runID == "" && requested == AutoRunID {
+			if !mustContinue {
+				return NewRunID(), nil
+			}
+			return "", fmt.Errorf("no run of %s recorded in %s, set %s to the run ID to resume", r.TestApp, r.TfWorkingDir, RunIDEnv)
+		}
+		return runID, nil
+	default:
+		if requested != "" {
+			if err := ValidateRunIDE(requested); err != nil {
+				return "", err
+			}
+		}
+		return requested, nil
+	}
+}
+
+// applyRunID suffixes the app names and working directory with the run ID
+func (r *Runner) applyRunID(runID string) {
+	r.RunID = runID
+	r.TfWorkingDir = RunTfWorkingDir(r.TfWorkingDir, runID)
+	r.Env["STACK_NAME"] += "-" + runID
+	r.Env["ENVIRONMENT_NAME"] += "-" + runID
+	r.Env[RunIDEnv] = runID
+}
+
+func (r *Runner) runStages(t *testing.T, stages []namedStage, kind stageKind) {
+	for _, s := range stages {
+		r.runStage(t, s.name, kind, func() {
+			s.fn(t, r)
+		})
+	}
+}
+
+// runStage runs the stage with RunStage and records it in the journal, unless the resumed run already did,
+// see Journal.SkipOnResume, or the run was stopped.
+func (r *Runner) runStage(t *testing.T, stage string, kind stageKind, fn func()) {
+	if r.resumeFrom != nil && r.resumeFrom.SkipOnResume(stage, kind >= cleanupStage) {
+		t.Logf("Resuming the last run, so skipping stage '%s'.", stage)
+		return
+	}
+	if kind < cleanupStage && r.stop != nil && r.stop.Err() != nil {
+		if !r.stopped {
+			t.Errorf("Stopped %s before stage '%s': %v", r.TestApp, stage, context.Cause(r.stop))
+			r.stopped = true
+		} else {
+			t.Logf("Stopped %s, so skipping stage '%s'.", r.TestApp, stage)
+		}
+		return
+	}
+	RunStage(t, stage, func() {
+		r.journal.Start(stage, time.Now())
+		r.saveJournal(t)
+		failed, finished := t.Failed(), false
+		// record failures of fn, including t.FailNow and panics
+		defer func() {
+			status := StageSucceeded
+			if !finished || (t.Failed() && !failed) {
+				status = StageFailed
+			}
+			r.journal.Finish(stage, status, time.Now())
+			r.saveJournal(t)
+		}()
+		switch {
+		case kind == validationStage && r.stop != nil:
+			r.runStoppable(t, stage, fn)
+		case kind == afterCleanupStage && r.stop != nil:
+			runContexts.Store(t, r.stop)
+			defer runContexts.Delete(t)
+			fn()
+		default:
+			fn()
+		}
+		finished = true
+	})
+}
+
+// runStoppable runs fn with r.stop as the RunContext of t, so validations stop at their next retry once the run
+// is stopped and the cleanup starts in time. A stage stopped while running fails the test.
+func (r *Runner) runStoppable(t *testing.T, stage string, fn func()) {
+	runContexts.Store(t, r.stop)
+	defer func() {
+		runContexts.Delete(t)
+		if r.stop.Err() != nil && !r.stopped {
+			r.stopped = true
+			t.Errorf("Stopped stage '%s' of %s early: %v", stage, r.TestApp, context.Cause(r.stop))
+		}
+	}()
+	fn()
+}
+
+// lockWorkingDir locks the working directory until the test ends, see LockWorkingDirE
+func (r *Runner) lockWorkingDir(t *testing.T) {
+	lock, err := LockWorkingDirE(r.TfWorkingDir, r.TestApp, r.RunID, BreakingLock())
+	if err != nil {
+		t.Fatalf("Not running %s: %v", r.TestApp, err)
+	}
+	if lock.Replaced != nil {
+		t.Logf("[WARNING] Took over the lock of %s held by %s", r.TfWorkingDir, lock.Replaced)
+	}
+	t.Cleanup(func() {
+		if err := lock.UnlockE(); err != nil {
+			t.Logf("Failed to unlock %s: %v", r.TfWorkingDir, err)
+		}
+	})
+}
+
+// stateHeldFile is the StateHeldFile of the namespace, next to the working directories
+func (r *Runner) stateHeldFile() string {
+	return filepath.Join(filepath.Dir(r.TfWorkingDir), StateHeldFile)
+}
+
+// holdState lists the app in the StateHeldFile before it is deployed
+func (r *Runner) holdState(t *testing.T) {
+	held := HeldState{App: r.TestApp, RunID: r.RunID, TfWorkingDir: r.TfWorkingDir, Since: time.Now().UTC()}
+	if err := HoldStateE(r.stateHeldFile(), held); err != nil {
+		t.Logf("Failed to list %s in %s: %v", r.TestApp, r.stateHeldFile(), err)
+	}
+}
+
+// releaseState removes the app from the StateHeldFile after its cleanup
+func (r *Runner) releaseState(t *testing.T) {
+	if err := ReleaseStateE(r.stateHeldFile(), r.TfWorkingDir); err != nil {
+		t.Logf("Failed to remove %s from %s: %v", r.TestApp, r.stateHeldFile(), err)
+	}
+}
+
+// holdsState returns true if the app is listed in the StateHeldFile
+func (r *Runner) holdsState() bool {
+	entries, err := LoadHeldStateE(r.stateHeldFile())
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if e.TfWorkingDir == r.TfWorkingDir {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Runner) saveJournal(t *testing.T) {
+	if err := r.journal.SaveE(r.TfWorkingDir); err != nil {
+		t.Logf("Failed to save the journal of %s: %v", r.TestApp, err)
+	}
+}
+
+// Synth synthesizes the app again with env overriding the Runner environment, i.e. to rename the environment.
+// The overrides are kept for later stages.
+func (r *Runner) Synth(t *testing.T, env map[string]string) {
+	for k, v := range env {
+		r.Env[k] = v
+	}
+	r.lifecycle.Synth(t, r.TestApp, r.TfWorkingDir, r.Env, r.Assets...)
+}
+
+// RunStage runs the stage with terratest test_structure.RunTestStage, unless the `SKIP_<stage>` environment variable is set
+func RunStage(t *testing.T, stage string, fn func()) {
+	test_structure.RunTestStage(t, stage, fn)
+}
+
+// envMap converts `KEY=value` pairs to a map
+func envMap(environ []string) map[string]string {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	return env
+}
@@ -0,0 +1,279 @@
+package integ
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLifecycle records the lifecycle calls
+type fakeLifecycle struct {
+	calls []string
+	envs  []map[string]string
+}
+
+func (l *fakeLifecycle) Synth(t *testing.T, testApp, tfWorkingDir string, env map[string]string, assets ...string) {
+	l.calls = append(l.calls, "synth "+testApp+" "+tfWorkingDir+" "+env["ENVIRONMENT_NAME"])
+	envCopy := map[string]string{}
+	for k, v := range env {
+		envCopy[k] = v
+	}
+	l.envs = append(l.envs, envCopy)
+}
+
+func (l *fakeLifecycle) Deploy(t *testing.T, tfWorkingDir string, retryableErrors map[string]string) {
+	l.calls = append(l.calls, "deploy "+tfWorkingDir)
+}
+
+func (l *fakeLifecycle) Destroy(t *testing.T, tfWorkingDir string) {
+	l.calls = append(l.calls, "destroy "+tfWorkingDir)
+}
+
+// inTempDir runs the test in a temporary directory, runners save test data in the default tf/<app> working directory
+func inTempDir(t *testing.T) {
+	t.Chdir(t.TempDir())
+}
+
+// runParallel runs fn as a parallel subtest and waits for it to complete
+func runParallel(t *testing.T, fn func(t *testing.T)) {
+	t.Run("group", func(t *testing.T) {
+		t.Run("run", fn)
+	})
+}
+
+func TestRunner(t *testing.T) {
+	inTempDir(t)
+	lifecycle := &fakeLifecycle{}
+	t.Setenv("INTEG_RUNNER_TEST", "inherited")
+	r := NewRunner(lifecycle, "sqs",
+		WithRegion("eu-west-1"),
+		WithAssets("handlers"),
+		WithAssets("site"),
+		WithEnv(map[string]string{"STACK_NAME": "sqs-custom", "MAX_RECEIVE_COUNT": "2"}),
+		WithRetryableErrors(map[string]string{".*concurrent update.*": "concurrent update"}),
+		WithStageBeforeSynth("pin", func(t *testing.T, r *Runner) {
+			lifecycle.calls = append(lifecycle.calls, "pin "+r.TfWorkingDir)
+		}),
+		WithStageBeforeValidate("seed", func(t *testing.T, r *Runner) {
+			lifecycle.calls = append(lifecycle.calls, "seed")
+		}),
+		WithStageAfterValidate("rename_app", func(t *testing.T, r *Runner) {
+			r.Synth(t, map[string]string{"ENVIRONMENT_NAME": "renamed"})
+		}),
+		WithStageAfterCleanup("verify_cleanup", func(t *testing.T, r *Runner) {
+			assert.NotNil(t, RunContext(t).Done(), "verify_cleanup runs with the run context")
+			lifecycle.calls = append(lifecycle.calls, "verify "+r.TfWorkingDir)
+		}),
+	)
+	assert.Equal(t, filepath.Join("tf", "sqs"), r.TfWorkingDir)
+	assert.Equal(t, []string{"handlers", "site"}, r.Assets)
+	assert.Equal(t, map[string]string{".*concurrent update.*": "concurrent update"}, r.RetryableErrors)
+
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, func(t *testing.T, tfWorkingDir string, awsRegion string) {
+			lifecycle.calls = append(lifecycle.calls, "validate "+tfWorkingDir+" "+awsRegion)
+		})
+	})
+
+	tfWorkingDir := filepath.Join("tf", "sqs")
+	assert.Equal(t, []string{
+		"pin " + tfWorkingDir,
+		"synth sqs " + tfWorkingDir + " test",
+		"deploy " + tfWorkingDir,
+		"seed",
+		"validate " + tfWorkingDir + " eu-west-1",
+		"synth sqs " + tfWorkingDir + " renamed",
+		"destroy " + tfWorkingDir,
+		"verify " + tfWorkingDir,
+	}, lifecycle.calls)
+
+	env := lifecycle.envs[0]
+	assert.Equal(t, "eu-west-1", env["AWS_REGION"])
+	assert.Equal(t, "sqs-custom", env["STACK_NAME"])
+	assert.Equal(t, "2", env["MAX_RECEIVE_COUNT"])
+	assert.Equal(t, "inherited", env["INTEG_RUNNER_TEST"])
+}
+
+func TestRunner_SkipStages(t *testing.T) {
+	inTempDir(t)
+	lifecycle := &fakeLifecycle{}
+	t.Setenv(SkipStageEnvPrefix+StageDeploy, "true")
+	t.Setenv(SkipStageEnvPrefix+StageValidate, "true")
+	t.Setenv(SkipStageEnvPrefix+StageCleanup, "true")
+	t.Setenv(SkipStageEnvPrefix+"rename_app", "true")
+	r := NewRunner(lifecycle, "sqs",
+		WithTfWorkingDir(filepath.Join("tf", "sqs-synth")),
+		WithStageAfterValidate("rename_app", func(t *testing.T, r *Runner) {
+			r.Synth(t, map[string]string{"ENVIRONMENT_NAME": "renamed"})
+		}),
+		WithStageAfterCleanup("verify_cleanup", func(t *testing.T, r *Runner) {
+			t.Error("verify_cleanup should not run without cleanup")
+		}),
+	)
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, func(t *testing.T, tfWorkingDir string, awsRegion string) {
+			t.Error("validate should be skipped")
+		})
+	})
+	assert.Equal(t, []string{"synth sqs " + filepath.Join("tf", "sqs-synth") + " test"}, lifecycle.calls)
+}
+
+func TestRunner_NilValidate(t *testing.T) {
+	inTempDir(t)
+	lifecycle := &fakeLifecycle{}
+	r := NewRunner(lifecycle, "sns")
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, nil)
+	})
+	tfWorkingDir := filepath.Join("tf", "sns")
+	assert.Equal(t, []string{
+		"synth sns " + tfWorkingDir + " test",
+		"deploy " + tfWorkingDir,
+		"destroy " + tfWorkingDir,
+	}, lifecycle.calls)
+	assert.Equal(t, "us-east-1", lifecycle.envs[0]["AWS_REGION"])
+}
+
+func TestRunner_WithLifecycle(t *testing.T) {
+	inTempDir(t)
+	replaced, lifecycle := &fakeLifecycle{}, &fakeLifecycle{}
+	r := NewRunner(replaced, "sns", WithLifecycle(lifecycle))
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, nil)
+	})
+	assert.Empty(t, replaced.calls)
+	assert.Len(t, lifecycle.calls, 3)
+}
+
+func TestRunner_WithTfWorkingDirSuffix(t *testing.T) {
+	inTempDir(t)
+	lifecycle := &fakeLifecycle{}
+	// the suffix is kept if the working directory is set after it
+	r := NewRunner(lifecycle, "sns", WithTfWorkingDirSuffix("upgrade"), WithTfWorkingDir(filepath.Join("tf", "topic")))
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, nil)
+	})
+	tfWorkingDir := filepath.Join("tf", "topic-upgrade")
+	assert.Equal(t, tfWorkingDir, r.TfWorkingDir)
+	assert.Equal(t, []string{
+		"synth sns " + tfWorkingDir + " test",
+		"deploy " + tfWorkingDir,
+		"destroy " + tfWorkingDir,
+	}, lifecycle.calls)
+}
+
+// saveTestJournal records the stages with their status as the last run of the app in tfWorkingDir
+func saveTestJournal(t *testing.T, tfWorkingDir string, stages ...string) {
+	j := &Journal{App: "sqs"}
+	for i := 0; i < len(stages); i += 2 {
+		j.Start(stages[i], time.Now())
+		if status := StageStatus(stages[i+1]); status != StageStarted {
+			j.Finish(stages[i], status, time.Now())
+		}
+	}
+	require.NoError(t, j.SaveE(tfWorkingDir))
+}
+
+func recordingValidate(lifecycle *fakeLifecycle) ValidateFunc {
+	return func(t *testing.T, tfWorkingDir string, awsRegion string) {
+		lifecycle.calls = append(lifecycle.calls, "validate "+tfWorkingDir)
+	}
+}
+
+func TestRunner_Journal(t *testing.T) {
+	tfWorkingDir := filepath.Join(t.TempDir(), "sqs")
+	lifecycle := &fakeLifecycle{}
+	r := NewRunner(lifecycle, "sqs", WithTfWorkingDir(tfWorkingDir))
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, recordingValidate(lifecycle))
+	})
+	j, err := LoadJournalE(tfWorkingDir)
+	require.NoError(t, err)
+	var stages []string
+	for _, e := range j.Entries {
+		assert.Equal(t, StageSucceeded, e.Status, e.Stage)
+		assert.False(t, e.FinishedAt.Before(e.StartedAt))
+		stages = append(stages, e.Stage)
+	}
+	assert.Equal(t, []string{StageSynth, StageDeploy, StageValidate, StageCleanup}, stages)
+	assert.True(t, j.Completed())
+
+	// a run skipping synth continues the journal, skipped stages keep their entries
+	t.Setenv(SkipStageEnvPrefix+StageSynth, "true")
+	t.Setenv(SkipStageEnvPrefix+StageDeploy, "true")
+	t.Setenv(SkipStageEnvPrefix+StageCleanup, "true")
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, recordingValidate(lifecycle))
+	})
+	j, err = LoadJournalE(tfWorkingDir)
+	require.NoError(t, err)
+	assert.Len(t, j.Entries, 4)
+}
+
+func TestRunner_Resume(t *testing.T) {
+	tfWorkingDir := filepath.Join(t.TempDir(), "sqs")
+	saveTestJournal(t, tfWorkingDir,
+		StageSynth, string(StageSucceeded),
+		StageDeploy, string(StageSucceeded),
+		StageValidate, string(StageFailed))
+	t.Setenv(ResumeEnv, "true")
+
+	lifecycle := &fakeLifecycle{}
+	r := NewRunner(lifecycle, "sqs", WithTfWorkingDir(tfWorkingDir))
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, recordingValidate(lifecycle))
+	})
+	assert.Equal(t, []string{"validate " + tfWorkingDir, "destroy " + tfWorkingDir}, lifecycle.calls)
+	j, err := LoadJournalE(tfWorkingDir)
+	require.NoError(t, err)
+	assert.True(t, j.Succeeded(StageValidate))
+	assert.True(t, j.Completed())
+
+	// a completed run starts over
+	lifecycle.calls = nil
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, recordingValidate(lifecycle))
+	})
+	assert.Len(t, lifecycle.calls, 4)
+}
+
+func TestRunner_ResumeCleanup(t *testing.T) {
+	tfWorkingDir := filepath.Join(t.TempDir(), "sqs")
+	saveTestJournal(t, tfWorkingDir,
+		StageSynth, string(StageSucceeded),
+		StageDeploy, string(StageSucceeded),
+		StageValidate, string(StageFailed),
+		StageCleanup, string(StageStarted))
+	t.Setenv(ResumeEnv, "true")
+
+	lifecycle := &fakeLifecycle{}
+	r := NewRunner(lifecycle, "sqs", WithTfWorkingDir(tfWorkingDir),
+		WithStageAfterValidate("rename_app", func(t *testing.T, r *Runner) {
+			t.Error("rename_app should not run after an interrupted cleanup")
+		}),
+		WithStageAfterCleanup("verify_cleanup", func(t *testing.T, r *Runner) {
+			lifecycle.calls = append(lifecycle.calls, "verify "+r.TfWorkingDir)
+		}),
+	)
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, recordingValidate(lifecycle))
+	})
+	assert.Equal(t, []string{"destroy " + tfWorkingDir, "verify " + tfWorkingDir}, lifecycle.calls)
+}
+
+func TestRunner_Status(t *testing.T) {
+	tfWorkingDir := filepath.Join(t.TempDir(), "sqs")
+	saveTestJournal(t, tfWorkingDir, StageSynth, string(StageSucceeded))
+	t.Setenv(StatusEnv, "true")
+
+	lifecycle := &fakeLifecycle{}
+	r := NewRunner(lifecycle, "sqs", WithTfWorkingDir(tfWorkingDir))
+	runParallel(t, func(t *testing.T) {
+		r.Run(t, recordingValidate(lifecycle))
+		t.Error("Run should skip the test")
+	})
+	assert.Empty(t, lifecycle.calls)
+}
@@ -0,0 +1,93 @@
+package integ
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ref https://github.com/aws/aws-cdk/blob/v2.161.1/packages/%40aws-cdk/integ-tests-alpha/lib/assertions/sdk.ts
+
+// SdkCall declares an AWS SDK API call, i.e. sqs GetQueueAttributes
+type SdkCall struct {
+	Service    string         `yaml:"service"`    // Service name the client is registered as, i.e. "sqs"
+	Api        string         `yaml:"api"`        // Name of the client method, i.e. "GetQueueAttributes"
+	Parameters map[string]any `yaml:"parameters"` // Input fields of the API call
+}
+
+// SdkClientFactory creates an AWS SDK v2 service client for the region
+type SdkClientFactory func(t *testing.T, region string) (any, error)
+
+var (
+	sdkClientsMu sync.RWMutex
+	sdkClients   = map[string]SdkClientFactory{}
+)
+
+// RegisterSdkClient registers the client factory used for SdkCalls to service.
+// The integ/aws package registers the services used by the integration tests.
+func RegisterSdkClient(service string, factory SdkClientFactory) {
+	sdkClientsMu.Lock()
+	defer sdkClientsMu.Unlock()
+	sdkClients[strings.ToLower(service)] = factory
+}
+
+func registeredSdkServices() []string {
+	services := make([]string, 0, len(sdkClients))
+	for s := range sdkClients {
+		services = append(services, s)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// InvokeSdkCallE invokes the SDK call and returns its output in JSON shape, ready for assertions.
+func InvokeSdkCallE(t *testing.T, region string, call SdkCall) (any, error) {
+	sdkClientsMu.RLock()
+	factory, ok := sdkClients[strings.ToLower(call.Service)]
+	services := registeredSdkServices()
+	sdkClientsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no SDK client registered for service %q, registered services: %v", call.Service, services)
+	}
+	client, err := factory(t, region)
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s client: %v", call.Service, err)
+	}
+	return invokeClientMethod(context.Background(), client, call.Api, call.Parameters)
+}
+
+// invokeClientMethod calls an SDK client method with signature
+// `func(ctx, *Input, ...optFns) (*Output, error)`, decoding parameters into the Input struct.
+func invokeClientMethod(ctx context.Context, client any, api string, parameters map[string]any) (any, error) {
+	method := reflect.ValueOf(client).MethodByName(api)
+	if !method.IsValid() {
+		return nil, fmt.Errorf("%T has no method %s", client, api)
+	}
+	methodType := method.Type()
+	if methodType.NumIn() < 2 || methodType.In(1).Kind() != reflect.Ptr || methodType.NumOut() != 2 {
+		return nil, fmt.Errorf("%T.%s is not an SDK API call", client, api)
+	}
+
+	// SDK input structs have no json tags, field names are matched case insensitively
+	input := reflect.New(methodType.In(1).Elem())
+	if len(parameters) > 0 {
+		data, err := json.Marshal(parameters)
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling %s parameters: %v", api, err)
+		}
+		if err := json.Unmarshal(data, input.Interface()); err != nil {
+			return nil, fmt.Errorf("error decoding %s parameters into %s: %v", api, methodType.In(1).Elem(), err)
+		}
+	}
+
+	results := method.Call([]reflect.Value{reflect.ValueOf(ctx), input})
+	if errValue := results[1]; !errValue.IsNil() {
+		return nil, errValue.Interface().(error)
+	}
+	return normalizeValue(results[0].Interface()), nil
+}
@@ -0,0 +1,81 @@
+package integ
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeGetQueueAttributesInput struct {
+	QueueUrl       *string
+	AttributeNames []string
+}
+
+type fakeGetQueueAttributesOutput struct {
+	Attributes map[string]string
+}
+
+type fakeSqsClient struct{}
+
+func (fakeSqsClient) GetQueueAttributes(ctx context.Context, params *fakeGetQueueAttributesInput, optFns ...func(*struct{})) (*fakeGetQueueAttributesOutput, error) {
+	if params.QueueUrl == nil {
+		return nil, errors.New("QueueUrl is required")
+	}
+	return &fakeGetQueueAttributesOutput{
+		Attributes: map[string]string{
+			"QueueUrl":       *params.QueueUrl,
+			"AttributeNames": params.AttributeNames[0],
+		},
+	}, nil
+}
+
+func (fakeSqsClient) Close() {}
+
+func TestInvokeSdkCall(t *testing.T) {
+	RegisterSdkClient("FakeSQS", func(t *testing.T, region string) (any, error) {
+		return fakeSqsClient{}, nil
+	})
+
+	result, err := InvokeSdkCallE(t, "us-east-1", SdkCall{
+		Service: "fakesqs",
+		Api:     "GetQueueAttributes",
+		Parameters: map[string]any{
+			"QueueUrl":       "https://sqs.us-east-1.amazonaws.com/123456789012/orders",
+			"attributeNames": []any{"All"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"Attributes": map[string]any{
+			"QueueUrl":       "https://sqs.us-east-1.amazonaws.com/123456789012/orders",
+			"AttributeNames": "All",
+		},
+	}, result)
+}
+
+func TestInvokeSdkCall_Errors(t *testing.T) {
+	RegisterSdkClient("fakesqs", func(t *testing.T, region string) (any, error) {
+		return fakeSqsClient{}, nil
+	})
+
+	tests := []struct {
+		call          SdkCall
+		expectedError string
+	}{
+		{SdkCall{Service: "unknown", Api: "GetQueueAttributes"}, `no SDK client registered for service "unknown"`},
+		{SdkCall{Service: "fakesqs", Api: "ListQueues"}, "has no method ListQueues"},
+		{SdkCall{Service: "fakesqs", Api: "Close"}, "Close is not an SDK API call"},
+		{SdkCall{Service: "fakesqs", Api: "GetQueueAttributes", Parameters: map[string]any{"QueueUrl": 42}}, "error decoding GetQueueAttributes parameters"},
+		{SdkCall{Service: "fakesqs", Api: "GetQueueAttributes"}, "QueueUrl is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.call.Service+"/"+tt.call.Api, func(t *testing.T) {
+			_, err := InvokeSdkCallE(t, "us-east-1", tt.call)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package snapshot
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Tokens replacing volatile values in snapshots
+const (
+	AccountToken   = "<ACCOUNT>"
+	RegionToken    = "<REGION>"
+	TimestampToken = "<TIMESTAMP>"
+	SuffixToken    = "<SUFFIX>"
+)
+
+// Rule replaces a volatile value in snapshots with a stable token
+type Rule struct {
+	Name        string         // Name of the rule, used to replace or remove default rules
+	Pattern     *regexp.Regexp // Pattern matched against string values, nil replaces the full value of Keys
+	Replacement string         // Replacement for Pattern matches, may reference capture groups, i.e. "${1}<ACCOUNT>"
+	Keys        []string       // Object keys the rule is limited to (case insensitive), empty applies to all string values
+}
+
+// DefaultRules normalize account IDs, regions, timestamps and generated name suffixes
+var DefaultRules = []Rule{
+	{
+		Name:        "timestamp",
+		Pattern:     regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?`),
+		Replacement: TimestampToken,
+	},
+	{
+		// epoch timestamps, i.e. KMS CreationDate
+		Name:        "epoch",
+		Replacement: TimestampToken,
+		Keys:        []string{"CreationDate", "CreatedDate", "CreatedTimestamp", "LastModifiedDate", "LastModifiedTimestamp", "LastUpdatedDate", "DeletionDate"},
+	},
+	{
+		// terraform name_prefix suffixes, i.e. sqsRole20260105090853909000000008
+		Name:        "terraform-unique-id",
+		Pattern:     regexp.MustCompile(`20\d{16}[0-9a-f]{8}\b`),
+		Replacement: SuffixToken,
+	},
+	{
+		// IAM unique IDs, i.e. AROA2DP7X6SILGZAVMM4M
+		Name:        "iam-unique-id",
+		Pattern:     regexp.MustCompile(`\b(AROA|AIDA|ANPA|AGPA|AIPA)[A-Z0-9]{12,}\b`),
+		Replacement: "${1}" + SuffixToken,
+	},
+	{
+		Name:        "uuid",
+		Pattern:     regexp.MustCompile(`\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`),
+		Replacement: SuffixToken,
+	},
+	{
+		Name:        "arn-account",
+		Pattern:     regexp.MustCompile(`(arn:aws[a-z-]*:[a-z0-9-]*:[a-z0-9-]*:)\d{12}\b`),
+		Replacement: "${1}" + AccountToken,
+	},
+	{
+		// queue urls, i.e. https://sqs.us-east-1.amazonaws.com/123456789012/queue
+		Name:        "url-account",
+		Pattern:     regexp.MustCompile(`(\.amazonaws\.com/)\d{12}\b`),
+		Replacement: "${1}" + AccountToken,
+	},
+	{
+		Name:        "account",
+		Pattern:     regexp.MustCompile(`^\d{12}$`),
+		Replacement: AccountToken,
+		Keys:        []string{"AccountId", "AWSAccountId", "Account", "OwnerId", "aws:SourceAccount", "AWS"},
+	},
+	{
+		Name:        "region",
+		Pattern:     regexp.MustCompile(`\b(?:af|ap|ca|cn|eu|il|me|mx|sa|us)(?:-gov|-iso[bef]?)?-(?:central|north|south|east|west|northeast|northwest|southeast|southwest)-\d\b`),
+		Replacement: RegionToken,
+	},
+}
+
+// Normalizer replaces volatile values by applying Rules in order
+type Normalizer struct {
+	Rules []Rule
+}
+
+// NewNormalizer returns a Normalizer applying DefaultRules followed by rules.
+// rules named like a default rule replace it, rules with only a Name remove it.
+func NewNormalizer(rules ...Rule) *Normalizer {
+	result := make([]Rule, 0, len(DefaultRules)+len(rules))
+	result = append(result, DefaultRules...)
+	for _, r := range rules {
+		replaced := false
+		for i := range result {
+			if r.Name != "" && result[i].Name == r.Name {
+				result[i] = r
+				replaced = true
+			}
+		}
+		if !replaced {
+			result = append(result, r)
+		}
+	}
+	// drop rules disabled by name
+	rulesEnabled := result[:0]
+	for _, r := range result {
+		if r.Pattern != nil || len(r.Keys) > 0 {
+			rulesEnabled = append(rulesEnabled, r)
+		}
+	}
+	return &Normalizer{Rules: rulesEnabled}
+}
+
+var (
+	normalizerMu sync.RWMutex
+	normalizer   = NewNormalizer()
+)
+
+// SetNormalizer configures the Normalizer used by Write and RunChecks, nil disables normalization.
+// Each namespace test package has its own instance, configure it from an init function or TestMain, i.e.
+//
+//	func init() {
+//		snapshot.SetNormalizer(snapshot.NewNormalizer(snapshot.Rule{Name: "region"}))
+//	}
+func SetNormalizer(n *Normalizer) {
+	normalizerMu.Lock()
+	defer normalizerMu.Unlock()
+	normalizer = n
+}
+
+// normalize applies the configured Normalizer to value
+func normalize(value any) (any, error) {
+	normalizerMu.RLock()
+	n := normalizer
+	normalizerMu.RUnlock()
+	if n == nil {
+		return value, nil
+	}
+	return n.Normalize(value)
+}
+
+// Normalize converts value to its JSON representation and replaces volatile values with tokens
+func (n *Normalizer) Normalize(value any) (any, error) {
+	jsonValue, err := toJSONValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return n.normalizeValue("", jsonValue), nil
+}
+
+func (n *Normalizer) normalizeValue(key string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for k, elem := range v {
+			result[k] = n.normalizeValue(k, elem)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, elem := range v {
+			result[i] = n.normalizeValue(key, elem)
+		}
+		return result
+	case nil:
+		return nil
+	}
+
+	for _, r := range n.Rules {
+		if len(r.Keys) > 0 && !containsKey(r.Keys, key) {
+			continue
+		}
+		if r.Pattern == nil {
+			// replace the full value, regardless of its type
+			value = r.Replacement
+			continue
+		}
+		if s, ok := value.(string); ok {
+			value = r.Pattern.ReplaceAllString(s, r.Replacement)
+		}
+	}
+	return value
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
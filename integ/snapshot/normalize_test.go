@@ -0,0 +1,70 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadFixture(t *testing.T, name string) any {
+	data, err := os.ReadFile(filepath.Join("fixtures", name))
+	require.NoError(t, err)
+	var result any
+	require.NoError(t, json.Unmarshal(data, &result))
+	return result
+}
+
+func TestNormalize(t *testing.T) {
+	actual, err := NewNormalizer().Normalize(loadFixture(t, "role-outputs.json"))
+	require.NoError(t, err)
+	assert.Equal(t, loadFixture(t, "role-outputs.normalized.json"), actual)
+}
+
+func TestNormalize_Struct(t *testing.T) {
+	actual, err := NewNormalizer().Normalize(loadRole(t))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"RoleName":                 "TestRole-a1b2c3d4",
+		"Arn":                      "arn:aws:iam::<ACCOUNT>:role/TestRole-a1b2c3d4",
+		"AssumeRolePolicyDocument": `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::<ACCOUNT>:root"},"Action":"sts:AssumeRole"}]}`,
+		"AttachedPolicyArns":       []any{"arn:aws:iam::aws:policy/ReadOnlyAccess", "arn:aws:iam::<ACCOUNT>:policy/Custom"},
+	}, actual)
+}
+
+func TestNewNormalizer_Rules(t *testing.T) {
+	n := NewNormalizer(
+		// keep regions
+		Rule{Name: "region"},
+		// replace generated suffixes with a custom token
+		Rule{Name: "terraform-unique-id", Pattern: regexp.MustCompile(`20\d{16}[0-9a-f]{8}\b`), Replacement: "<ID>"},
+		Rule{Name: "grid", Pattern: regexp.MustCompile(`\b12345678-1234-`), Replacement: "<GRID>-"},
+	)
+	for _, r := range n.Rules {
+		assert.NotEqual(t, "region", r.Name)
+	}
+	assert.Equal(t, len(DefaultRules), len(n.Rules))
+
+	actual, err := n.Normalize("arn:aws:sqs:us-east-1:694710432912:12345678-1234-sqsQueue2026010509092242750000000f")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:sqs:us-east-1:<ACCOUNT>:<GRID>-sqsQueue<ID>", actual)
+}
+
+func TestRunChecks_Normalized(t *testing.T) {
+	// snapshots using tokens match any account, region and suffix
+	RunChecks(t, "fixtures", loadFixture(t, "role-outputs.json"), []Check{
+		{FieldPath: ".", ShouldMatch: "role-outputs.normalized.json"},
+	}, nil)
+}
+
+func TestSetNormalizer(t *testing.T) {
+	defer SetNormalizer(NewNormalizer())
+	SetNormalizer(nil)
+	actual, err := normalize(map[string]any{"Region": "us-east-1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"Region": "us-east-1"}, actual)
+}
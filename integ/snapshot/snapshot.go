@@ -0,0 +1,331 @@
+// Package snapshot matches entities read from AWS against JSON snapshot files.
+//
+// Snapshot files may include `regex::` prefixed strings to match values with regular expressions,
+// and files with the `.tmpl.json` suffix are rendered as Go templates before comparing.
+// Volatile values such as account IDs, regions, timestamps and generated name suffixes are replaced
+// by stable tokens (`<ACCOUNT>`, `<REGION>`, `<TIMESTAMP>`, `<SUFFIX>`) on write and compare.
+//
+// Set WRITE_SNAPSHOTS=true to dump full entities into the `outputs/` folder of the snapshot directory,
+// and UPDATE_SNAPSHOTS=1 to rewrite the expected snapshot files in place with the actual values.
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// WriteSnapshotsEnv enables writing full entity snapshots to the `outputs/` folder
+	WriteSnapshotsEnv = "WRITE_SNAPSHOTS"
+	// UpdateSnapshotsEnv enables rewriting expected snapshot files in place
+	UpdateSnapshotsEnv = "UPDATE_SNAPSHOTS"
+
+	regexPrefix  = "regex::"
+	templateExt  = ".tmpl.json"
+	outputsDir   = "outputs"
+	indentPrefix = ""
+	indent       = "  "
+)
+
+// Check defines fragments to match a snapshot.
+// the snapshot may include `regex::` prefix for matching string fields with regular expressions.
+type Check struct {
+	FieldPath   string // the path to the field in the struct, "." for the entity itself
+	Unmarshal   bool   // if the the field is a JSON string which should be unmarshalled
+	ShouldMatch string // the name of the snapshot file to compare against
+}
+
+// Templater renders `.tmpl.json` snapshot files, i.e. *aws.Variables
+type Templater interface {
+	Apply(contents string) (string, error)
+}
+
+// Writing returns true if WRITE_SNAPSHOTS is set
+func Writing() bool {
+	return envEnabled(WriteSnapshotsEnv)
+}
+
+// Updating returns true if UPDATE_SNAPSHOTS is set
+func Updating() bool {
+	return envEnabled(UpdateSnapshotsEnv)
+}
+
+func envEnabled(key string) bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(key))
+	return enabled
+}
+
+// Write writes the full entity to a snapshot file in the `outputs/` folder of snapshotDir.
+// this is useful in an initial run to capture the created resources in AWS.
+// Volatile values are replaced by the configured Normalizer, see SetNormalizer.
+func Write(t *testing.T, snapshotDir string, entity any, entityName string) {
+	fileName := filepath.Join(snapshotDir, outputsDir, entityName+".json")
+	normalized, err := normalize(entity)
+	require.NoError(t, err)
+	t.Logf("Writing snapshot to %s", fileName)
+	require.NoError(t, writeJSON(fileName, normalized))
+}
+
+// RunChecks validates entity fields against snapshot files
+// the snapshot files may include `regex::` prefix for matching string fields with regular expressions.
+// the entity fields are accessed using the FieldPath.
+//
+// Both the expected and actual values are normalized before comparing, see SetNormalizer.
+// With UPDATE_SNAPSHOTS set, mismatching snapshot files are rewritten in place instead,
+// keeping `regex::` placeholders and template expressions which still match.
+func RunChecks(t *testing.T, snapshotDir string, entity any, checks []Check, tmplVars Templater) {
+	for _, c := range checks {
+		t.Run(c.ShouldMatch, func(t *testing.T) {
+			t.Parallel()
+			actual, err := fieldValue(entity, c)
+			require.NoError(t, err)
+
+			snapFullPath := filepath.Join(snapshotDir, c.ShouldMatch)
+			rawBytes, err := os.ReadFile(snapFullPath)
+			if os.IsNotExist(err) && Updating() {
+				t.Logf("Creating snapshot %s", snapFullPath)
+				normalized, err := normalize(actual)
+				require.NoError(t, err)
+				require.NoError(t, writeJSON(snapFullPath, normalized))
+				return
+			}
+			require.NoError(t, err, "Expected snapshot file %s to exist", snapFullPath)
+
+			expectedBytes := rawBytes
+			if strings.HasSuffix(c.ShouldMatch, templateExt) {
+				require.NotNil(t, tmplVars, "Snapshot %s requires template variables", snapFullPath)
+				expectedString, err := tmplVars.Apply(string(rawBytes))
+				require.NoError(t, err)
+				expectedBytes = []byte(expectedString)
+			}
+
+			var expected any
+			err = json.Unmarshal(expectedBytes, &expected)
+			require.NoError(t, err)
+			expected = matchType(expected, actual)
+			expected, err = normalize(expected)
+			require.NoError(t, err)
+			actual, err = normalize(actual)
+			require.NoError(t, err)
+
+			diff := cmp.Diff(expected, actual, cmp.Comparer(RegexStringComparer))
+			if diff == "" {
+				return
+			}
+			if Updating() {
+				t.Logf("Updating snapshot %s (-want +got):\n%s", snapFullPath, diff)
+				require.NoError(t, updateSnapshot(snapFullPath, rawBytes, expected, actual))
+				return
+			}
+			require.Empty(t, diff, "(-want +got)")
+		})
+	}
+}
+
+// fieldValue reads the field of the check from the entity
+func fieldValue(entity any, c Check) (any, error) {
+	val, err := GetFieldValue(entity, c.FieldPath)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Unmarshal {
+		return val, nil
+	}
+	strVal, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected field %s to be a string containing JSON, but got %T", c.FieldPath, val)
+	}
+	var actual any
+	if err := json.Unmarshal([]byte(strVal), &actual); err != nil {
+		return nil, err
+	}
+	return actual, nil
+}
+
+// matchType converts expected to the type of actual where the JSON representation is ambiguous
+func matchType(expected, actual any) any {
+	// Try to match actual type
+	switch actual := actual.(type) {
+	case []string:
+		expectedSlice, ok := expected.([]any)
+		if !ok {
+			return expected
+		}
+		expectedStrings := make([]string, len(expectedSlice))
+		for i, v := range expectedSlice {
+			if expectedStrings[i], ok = v.(string); !ok {
+				return expected
+			}
+		}
+		// Sort both slices before comparing
+		sort.Strings(actual)
+		sort.Strings(expectedStrings)
+		return expectedStrings
+	}
+	return expected
+}
+
+// GetFieldValue accesses a nested field in the struct, "." returns data itself
+func GetFieldValue(data any, fieldPath string) (any, error) {
+	// TODO: use jmespath instead?
+	if fieldPath == "." {
+		return data, nil
+	}
+	fields := strings.Split(fieldPath, ".")
+	val := reflect.ValueOf(data)
+	for _, field := range fields {
+		if val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+		if val.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("expected struct but got %s", val.Kind())
+		}
+		val = val.FieldByName(field)
+		if !val.IsValid() {
+			return nil, fmt.Errorf("field '%s' not found", field)
+		}
+	}
+	return val.Interface(), nil
+}
+
+// RegexStringComparer is a custom comparer for strings that allows for regex pattern matching.
+//
+// Option configures for specific behavior of Equal and Diff.
+// In particular, the fundamental Option functions (Ignore, Transformer, and Comparer),
+// configure how equality is determined.
+//
+// ref: https://pkg.go.dev/github.com/google/go-cmp/cmp#example-Option-EqualNaNs
+func RegexStringComparer(a, b string) bool {
+	getPattern := func(s string) (string, bool) {
+		if strings.HasPrefix(s, regexPrefix) {
+			return strings.TrimPrefix(s, regexPrefix), true
+		}
+		return s, false
+	}
+
+	patternA, isRegexA := getPattern(a)
+	patternB, isRegexB := getPattern(b)
+
+	switch {
+	case isRegexA && isRegexB:
+		return patternA == patternB
+	case isRegexA:
+		matched, err := regexp.MatchString(patternA, b)
+		if err != nil {
+			panic(fmt.Sprintf("Invalid regex pattern '%s': %v", patternA, err))
+		}
+		return matched
+	case isRegexB:
+		matched, err := regexp.MatchString(patternB, a)
+		if err != nil {
+			panic(fmt.Sprintf("Invalid regex pattern '%s': %v", patternB, err))
+		}
+		return matched
+	default:
+		return a == b
+	}
+}
+
+// updateSnapshot rewrites the snapshot file with the actual value.
+// rawBytes are the snapshot contents before templating, string values which still match
+// are kept as is to preserve `regex::` placeholders and template expressions.
+func updateSnapshot(fileName string, rawBytes []byte, expected, actual any) error {
+	actual, err := toJSONValue(actual)
+	if err != nil {
+		return err
+	}
+	var raw any
+	if err := json.Unmarshal(rawBytes, &raw); err != nil {
+		// template expressions outside of JSON strings, keep the rendered values instead
+		raw = expected
+	}
+	return writeJSON(fileName, merge(raw, expected, actual))
+}
+
+// merge returns actual, replacing matching string values by their raw snapshot value
+func merge(raw, expected, actual any) any {
+	switch actual := actual.(type) {
+	case map[string]any:
+		rawMap, _ := raw.(map[string]any)
+		expectedMap, _ := expected.(map[string]any)
+		result := make(map[string]any, len(actual))
+		for k, v := range actual {
+			result[k] = merge(rawMap[k], expectedMap[k], v)
+		}
+		return result
+	case []any:
+		rawSlice, _ := raw.([]any)
+		expectedSlice := toAnySlice(expected)
+		result := make([]any, len(actual))
+		for i, v := range actual {
+			if i < len(rawSlice) && i < len(expectedSlice) {
+				result[i] = merge(rawSlice[i], expectedSlice[i], v)
+			} else {
+				result[i] = v
+			}
+		}
+		return result
+	case string:
+		expectedString, ok := expected.(string)
+		rawString, rawOk := raw.(string)
+		if ok && rawOk && RegexStringComparer(expectedString, actual) {
+			return rawString
+		}
+		return actual
+	default:
+		return actual
+	}
+}
+
+func toAnySlice(value any) []any {
+	switch v := value.(type) {
+	case []any:
+		return v
+	case []string:
+		result := make([]any, len(v))
+		for i, s := range v {
+			result[i] = s
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// toJSONValue converts value to its JSON representation (maps, slices and primitives)
+func toJSONValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result any
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// writeJSON writes value as indented JSON, without escaping HTML characters in regex patterns
+func writeJSON(fileName string, value any) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent(indentPrefix, indent)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, buf.Bytes(), 0644)
+}
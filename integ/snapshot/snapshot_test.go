@@ -0,0 +1,134 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRole struct {
+	RoleName                 string
+	Arn                      string
+	AssumeRolePolicyDocument string
+	AttachedPolicyArns       []string
+}
+
+// testVars mirrors aws.Variables without importing the aws helpers
+type testVars map[string]any
+
+func (v testVars) Apply(contents string) (string, error) {
+	tmpl, err := template.New("test").Parse(contents)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, v)
+	return buf.String(), err
+}
+
+var vars = testVars{"AccountId": "123456789012"}
+
+func loadRole(t *testing.T) *testRole {
+	data, err := os.ReadFile(filepath.Join("fixtures", "role.json"))
+	require.NoError(t, err)
+	var role testRole
+	require.NoError(t, json.Unmarshal(data, &role))
+	return &role
+}
+
+// copyFixtures copies the snapshot fixtures into a temporary directory for updates
+func copyFixtures(t *testing.T, names ...string) string {
+	dir := t.TempDir()
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join("fixtures", name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0644))
+	}
+	return dir
+}
+
+func TestRunChecks(t *testing.T) {
+	RunChecks(t, "fixtures", loadRole(t), []Check{
+		{FieldPath: "AssumeRolePolicyDocument", Unmarshal: true, ShouldMatch: "assume-role.tmpl.json"},
+		{FieldPath: "RoleName", ShouldMatch: "role-name.json"},
+		{FieldPath: "AttachedPolicyArns", ShouldMatch: "attached-policies.tmpl.json"},
+	}, vars)
+}
+
+func TestRunChecks_Update(t *testing.T) {
+	t.Setenv(UpdateSnapshotsEnv, "1")
+	dir := copyFixtures(t, "assume-role.tmpl.json", "role-name.json")
+	role := loadRole(t)
+	role.AssumeRolePolicyDocument = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Action":["sts:AssumeRole","sts:TagSession"]}]}`
+	role.RoleName = "Renamed"
+
+	t.Run("checks", func(t *testing.T) {
+		RunChecks(t, dir, role, []Check{
+			{FieldPath: "AssumeRolePolicyDocument", Unmarshal: true, ShouldMatch: "assume-role.tmpl.json"},
+			{FieldPath: "RoleName", ShouldMatch: "role-name.json"},
+			{FieldPath: "Arn", ShouldMatch: "new.json"},
+		}, vars)
+	})
+
+	updated, err := os.ReadFile(filepath.Join(dir, "assume-role.tmpl.json"))
+	require.NoError(t, err)
+	// matching template expressions are kept, changed values are replaced
+	assert.Contains(t, string(updated), `"AWS": "arn:aws:iam::{{ .AccountId }}:root"`)
+	assert.Contains(t, string(updated), `"sts:TagSession"`)
+
+	updated, err = os.ReadFile(filepath.Join(dir, "role-name.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "\"Renamed\"\n", string(updated))
+
+	created, err := os.ReadFile(filepath.Join(dir, "new.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "\"arn:aws:iam::<ACCOUNT>:role/TestRole-a1b2c3d4\"\n", string(created))
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	Write(t, dir, map[string]any{"Pattern": "a<b&c"}, "Entity")
+	data, err := os.ReadFile(filepath.Join(dir, "outputs", "Entity.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"Pattern\": \"a<b&c\"\n}\n", string(data))
+}
+
+func TestRegexStringComparer(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"foo", "foo", true},
+		{"foo", "bar", false},
+		{"regex::^f.o$", "foo", true},
+		{"foo", "regex::^f.o$", true},
+		{"regex::^b", "foo", false},
+		{"regex::^f", "regex::^f", true},
+		{"regex::^f", "regex::^b", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, RegexStringComparer(tt.a, tt.b), "%q vs %q", tt.a, tt.b)
+	}
+}
+
+func TestGetFieldValue(t *testing.T) {
+	role := loadRole(t)
+	value, err := GetFieldValue(role, "RoleName")
+	require.NoError(t, err)
+	assert.Equal(t, "TestRole-a1b2c3d4", value)
+
+	value, err = GetFieldValue(role, ".")
+	require.NoError(t, err)
+	assert.Same(t, role, value)
+
+	_, err = GetFieldValue(role, "Missing")
+	assert.EqualError(t, err, "field 'Missing' not found")
+	_, err = GetFieldValue(role, "RoleName.Length")
+	assert.EqualError(t, err, "expected struct but got string")
+}
@@ -0,0 +1,169 @@
+package integ
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+)
+
+// State indexes the resources of a Terraform state (`tofu show -json`) across all modules
+type State struct {
+	Resources []*StateResource // Resources sorted by address
+}
+
+// StateResource is a resource instance in the Terraform state
+type StateResource struct {
+	*tfjson.StateResource
+	Module string // Address of the module containing the resource, empty for the root module
+}
+
+// StateFilter selects state resources by exact values, empty fields match any value
+type StateFilter struct {
+	Mode   tfjson.ResourceMode // Resource mode, defaults to managed resources
+	Type   string              // Resource type, i.e. "aws_api_gateway_deployment"
+	Name   string              // Resource name, i.e. "Deployment_33C0A8A4"
+	Module string              // Module address, i.e. "module.network"
+}
+
+// String describes the filter for error messages
+func (f StateFilter) String() string {
+	var parts []string
+	for _, p := range [][2]string{{"mode", string(f.mode())}, {"type", f.Type}, {"name", f.Name}, {"module", f.Module}} {
+		if p[1] != "" {
+			parts = append(parts, p[0]+"="+p[1])
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (f StateFilter) mode() tfjson.ResourceMode {
+	if f.Mode == "" {
+		return tfjson.ManagedResourceMode
+	}
+	return f.Mode
+}
+
+// Matches returns true if the resource matches all fields of the filter exactly
+func (f StateFilter) Matches(r *StateResource) bool {
+	return r.Mode == f.mode() &&
+		(f.Type == "" || r.Type == f.Type) &&
+		(f.Name == "" || r.Name == f.Name) &&
+		(f.Module == "" || r.Module == f.Module)
+}
+
+// NewState indexes the resources of state, a nil state or empty state has no resources
+func NewState(state *tfjson.State) *State {
+	s := &State{}
+	if state != nil && state.Values != nil {
+		s.addModule(state.Values.RootModule)
+	}
+	sort.Slice(s.Resources, func(i, j int) bool {
+		return s.Resources[i].Address < s.Resources[j].Address
+	})
+	return s
+}
+
+func (s *State) addModule(module *tfjson.StateModule) {
+	if module == nil {
+		return
+	}
+	for _, r := range module.Resources {
+		s.Resources = append(s.Resources, &StateResource{StateResource: r, Module: module.Address})
+	}
+	for _, child := range module.ChildModules {
+		s.addModule(child)
+	}
+}
+
+// ParseStateE parses the JSON representation of a Terraform state, i.e. the output of `tofu show -json`
+func ParseStateE(data []byte) (*State, error) {
+	var state tfjson.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error parsing state: %v", err)
+	}
+	return NewState(&state), nil
+}
+
+// LoadStateFileE reads a Terraform state in JSON format, i.e. written by `tofu show -json > state.json`
+func LoadStateFileE(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStateE(data)
+}
+
+// Find returns all resources matching the filter, sorted by address
+func (s *State) Find(filter StateFilter) []*StateResource {
+	var result []*StateResource
+	for _, r := range s.Resources {
+		if filter.Matches(r) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// FindOne returns the only resource matching the filter. This fails the test on any errors
+func (s *State) FindOne(t *testing.T, filter StateFilter) *StateResource {
+	r, err := s.FindOneE(filter)
+	require.NoError(t, err)
+	return r
+}
+
+// FindOneE returns the only resource matching the filter, it fails if no or multiple resources match
+func (s *State) FindOneE(filter StateFilter) (*StateResource, error) {
+	matches := s.Find(filter)
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return nil, fmt.Errorf("no resource matching %s in state resources: %v", filter, s.addresses(s.Resources))
+	default:
+		return nil, fmt.Errorf("%d resources matching %s, expected one: %v", len(matches), filter, s.addresses(matches))
+	}
+}
+
+func (s *State) addresses(resources []*StateResource) []string {
+	addresses := make([]string, len(resources))
+	for i, r := range resources {
+		addresses[i] = r.Address
+	}
+	return addresses
+}
+
+// Attr searches the attribute values of the resource using JMESPath and converts to the desired type,
+// i.e. `integ.Attr[string](t, r, "arn")`. This fails the test on any errors
+func Attr[T any](t *testing.T, r *StateResource, query string) T {
+	result, err := AttrE[T](r, query)
+	require.NoError(t, err)
+	return result
+}
+
+// AttrE searches the attribute values of the resource using JMESPath and converts to the desired type.
+// Missing attributes are an error, unknown and null values are indistinguishable in the state.
+func AttrE[T any](r *StateResource, query string) (T, error) {
+	var result T
+	value, err := SearchJMESPath(query, r.AttributeValues)
+	if err != nil {
+		return result, fmt.Errorf("error searching %s attributes: %v", r.Address, err)
+	}
+	if value == nil {
+		return result, fmt.Errorf("%s has no attribute %q", r.Address, query)
+	}
+	// Go type assertions fail on this, so we use JSON marshalling
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return result, fmt.Errorf("failed to marshal value to JSON: %v", err)
+	}
+	if err := json.Unmarshal(jsonData, &result); err != nil {
+		return result, fmt.Errorf("failed to convert %s attribute %q to %T: %v", r.Address, query, result, err)
+	}
+	return result, nil
+}
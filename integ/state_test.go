@@ -0,0 +1,81 @@
+package integ
+
+import (
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadStateFixture(t *testing.T) *State {
+	state, err := LoadStateFileE("fixtures/state/apigw.json")
+	require.NoError(t, err)
+	return state
+}
+
+func addresses(resources []*StateResource) []string {
+	result := make([]string, len(resources))
+	for i, r := range resources {
+		result[i] = r.Address
+	}
+	return result
+}
+
+func TestStateFind(t *testing.T) {
+	state := loadStateFixture(t)
+
+	// exact type match does not include aws_api_gateway_deployment_foo
+	assert.Equal(t, []string{
+		"aws_api_gateway_deployment.Deployment_33C0A8A4",
+		"module.api.aws_api_gateway_deployment.Deployment_33C0A8A4[0]",
+	}, addresses(state.Find(StateFilter{Type: "aws_api_gateway_deployment"})))
+
+	assert.Equal(t, []string{
+		"module.api.aws_api_gateway_deployment.Deployment_33C0A8A4[0]",
+	}, addresses(state.Find(StateFilter{Type: "aws_api_gateway_deployment", Module: "module.api"})))
+
+	assert.Equal(t, []string{
+		"data.aws_caller_identity.CallerIdentity",
+	}, addresses(state.Find(StateFilter{Mode: tfjson.DataResourceMode})))
+
+	assert.Empty(t, state.Find(StateFilter{Type: "aws_iam_role", Name: "Role"}))
+	assert.Len(t, state.Find(StateFilter{}), 4)
+}
+
+func TestStateFindOne(t *testing.T) {
+	state := loadStateFixture(t)
+	r := state.FindOne(t, StateFilter{Type: "aws_iam_role"})
+	assert.Equal(t, "aws_iam_role.Role_1ABCC5F0", r.Address)
+	assert.Equal(t, "", r.Module)
+
+	_, err := state.FindOneE(StateFilter{Type: "aws_api_gateway_deployment"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 resources matching mode=managed type=aws_api_gateway_deployment, expected one")
+
+	_, err = state.FindOneE(StateFilter{Type: "aws_lambda_function", Name: "Handler"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no resource matching mode=managed type=aws_lambda_function name=Handler in state resources")
+}
+
+func TestStateAttr(t *testing.T) {
+	state := loadStateFixture(t)
+	role := state.FindOne(t, StateFilter{Type: "aws_iam_role"})
+	assert.Equal(t, "arn:aws:iam::123456789012:role/apigw-Role", Attr[string](t, role, "arn"))
+	assert.Equal(t, 3600, Attr[int](t, role, "max_session_duration"))
+	assert.Equal(t, map[string]string{"Name": "apigw-Role"}, Attr[map[string]string](t, role, "tags"))
+	assert.Equal(t, "apigw-Role", Attr[string](t, role, "tags.Name"))
+
+	_, err := AttrE[string](role, "name")
+	require.Error(t, err)
+	assert.Equal(t, `aws_iam_role.Role_1ABCC5F0 has no attribute "name"`, err.Error())
+
+	_, err = AttrE[int](role, "arn")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to convert aws_iam_role.Role_1ABCC5F0 attribute "arn" to int`)
+}
+
+func TestNewState_Empty(t *testing.T) {
+	assert.Empty(t, NewState(nil).Resources)
+	assert.Empty(t, NewState(&tfjson.State{}).Resources)
+}
@@ -0,0 +1,115 @@
+package integ
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StateHeldFile lists the apps of a namespace holding Terraform state, next to their working directories (tf/state-held.json)
+const StateHeldFile = "state-held.json"
+
+// HeldState is an app deployed without a successful cleanup, its resources may still exist
+type HeldState struct {
+	App          string    `json:"app"`
+	RunID        string    `json:"runId,omitempty"`
+	TfWorkingDir string    `json:"tfWorkingDir"`
+	Since        time.Time `json:"since"` // start of the deploy
+}
+
+// runners of the process share the marker file of their namespace
+var stateHeldMu sync.Mutex
+
+// HoldStateE adds the app to the marker file, replacing an entry for the same working directory.
+// Runners add apps before deploying them, so apps remain listed if the test binary is killed before cleanup.
+func HoldStateE(file string, held HeldState) error {
+	stateHeldMu.Lock()
+	defer stateHeldMu.Unlock()
+	entries, err := LoadHeldStateE(file)
+	if err != nil {
+		return err
+	}
+	entries = removeHeldState(entries, held.TfWorkingDir)
+	entries = append(entries, held)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].TfWorkingDir < entries[j].TfWorkingDir
+	})
+	return writeHeldStateE(file, entries)
+}
+
+// ReleaseStateE removes the app in tfWorkingDir from the marker file, and the file once no app is left
+func ReleaseStateE(file, tfWorkingDir string) error {
+	stateHeldMu.Lock()
+	defer stateHeldMu.Unlock()
+	entries, err := LoadHeldStateE(file)
+	if err != nil {
+		return err
+	}
+	entries = removeHeldState(entries, tfWorkingDir)
+	if len(entries) == 0 {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return writeHeldStateE(file, entries)
+}
+
+// LoadHeldStateE returns the apps listed in the marker file, none if it does not exist
+func LoadHeldStateE(file string) ([]HeldState, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []HeldState
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %v", file, err)
+	}
+	return entries, nil
+}
+
+func removeHeldState(entries []HeldState, tfWorkingDir string) []HeldState {
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.TfWorkingDir != tfWorkingDir {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// writeHeldStateE replaces the file by rename, so a killed test binary does not leave a truncated list
+func writeHeldStateE(file string, entries []HeldState) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	// concurrent test binaries of the namespace write their own temporary file
+	f, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), file)
+}
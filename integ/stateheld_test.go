@@ -0,0 +1,48 @@
+package integ
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoldStateE(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tf", StateHeldFile)
+	since := time.Date(2026, 10, 16, 9, 12, 3, 0, time.UTC)
+	sqs := HeldState{App: "sqs", RunID: "4f2a9c", TfWorkingDir: "tf/sqs-4f2a9c", Since: since}
+	sns := HeldState{App: "sns", TfWorkingDir: "tf/sns", Since: since}
+
+	require.NoError(t, HoldStateE(file, sqs))
+	require.NoError(t, HoldStateE(file, sns))
+	// deploying again replaces the entry
+	sqs.Since = since.Add(time.Hour)
+	require.NoError(t, HoldStateE(file, sqs))
+
+	entries, err := LoadHeldStateE(file)
+	require.NoError(t, err)
+	assert.Equal(t, []HeldState{sns, sqs}, entries)
+	// no temporary files are left next to the file
+	files, err := os.ReadDir(filepath.Dir(file))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	info, err := files[0].Info()
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+
+	require.NoError(t, ReleaseStateE(file, "tf/sqs-4f2a9c"))
+	entries, err = LoadHeldStateE(file)
+	require.NoError(t, err)
+	assert.Equal(t, []HeldState{sns}, entries)
+
+	// the file is removed with the last app
+	require.NoError(t, ReleaseStateE(file, "tf/sns"))
+	assert.NoFileExists(t, file)
+	require.NoError(t, ReleaseStateE(file, "tf/sns"))
+	entries, err = LoadHeldStateE(file)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,67 @@
+package sweeper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ARN is an Amazon Resource Name, arn:partition:service:region:account-id:resource
+type ARN struct {
+	Partition string
+	Service   string
+	Region    string
+	AccountID string
+	Resource  string
+}
+
+// ParseARN splits an ARN into its sections
+func ParseARN(s string) (ARN, error) {
+	sections := strings.SplitN(s, ":", 6)
+	if len(sections) != 6 || sections[0] != "arn" {
+		return ARN{}, fmt.Errorf("invalid ARN %q", s)
+	}
+	return ARN{
+		Partition: sections[1],
+		Service:   sections[2],
+		Region:    sections[3],
+		AccountID: sections[4],
+		Resource:  sections[5],
+	}, nil
+}
+
+// resources without type in their ARN
+var untypedResources = map[string]string{
+	"s3":  "bucket",
+	"sns": "topic",
+	"sqs": "queue",
+}
+
+// Type returns the resource type as `service:type`, i.e. "lambda:function" or "sqs:queue"
+func (a ARN) Type() string {
+	// arn:aws:apigateway:us-east-1::/restapis/abc123
+	resource := strings.TrimPrefix(a.Resource, "/")
+	if i := strings.IndexAny(resource, "/:"); i >= 0 {
+		// arn:aws:sns:us-east-1:123456789012:topic:subscription-id
+		if a.Service == "sns" {
+			return "sns:subscription"
+		}
+		return a.Service + ":" + resource[:i]
+	}
+	return a.Service + ":" + untypedResources[a.Service]
+}
+
+// ID returns the resource without its type, i.e. the function name of a Lambda function ARN
+func (a ARN) ID() string {
+	if _, ok := untypedResources[a.Service]; ok {
+		return a.Resource
+	}
+	resource := strings.TrimPrefix(a.Resource, "/")
+	if i := strings.IndexAny(resource, "/:"); i >= 0 {
+		return resource[i+1:]
+	}
+	return resource
+}
+
+func (a ARN) String() string {
+	return strings.Join([]string{"arn", a.Partition, a.Service, a.Region, a.AccountID, a.Resource}, ":")
+}
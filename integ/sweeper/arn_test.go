@@ -0,0 +1,46 @@
+package sweeper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseARN(t *testing.T) {
+	arn, err := ParseARN("arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/echo")
+	require.NoError(t, err)
+	assert.Equal(t, ARN{
+		Partition: "aws",
+		Service:   "logs",
+		Region:    "us-east-1",
+		AccountID: "123456789012",
+		Resource:  "log-group:/aws/lambda/echo",
+	}, arn)
+	assert.Equal(t, "arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/echo", arn.String())
+
+	_, err = ParseARN("sqs-test-queue")
+	assert.EqualError(t, err, `invalid ARN "sqs-test-queue"`)
+}
+
+func TestARN_Type(t *testing.T) {
+	for _, tc := range []struct {
+		arn, typ, id string
+	}{
+		{"arn:aws:sqs:us-east-1:123456789012:sqs-test", "sqs:queue", "sqs-test"},
+		{"arn:aws:sns:us-east-1:123456789012:topic", "sns:topic", "topic"},
+		{"arn:aws:sns:us-east-1:123456789012:topic:5c1e2b3a", "sns:subscription", "topic:5c1e2b3a"},
+		{"arn:aws:s3:::my-bucket", "s3:bucket", "my-bucket"},
+		{"arn:aws:lambda:us-east-1:123456789012:function:echo", "lambda:function", "echo"},
+		{"arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/echo", "logs:log-group", "/aws/lambda/echo"},
+		{"arn:aws:ec2:us-east-1:123456789012:vpc/vpc-0abc", "ec2:vpc", "vpc-0abc"},
+		{"arn:aws:ecs:us-east-1:123456789012:service/cluster/web", "ecs:service", "cluster/web"},
+		{"arn:aws:kms:us-east-1:123456789012:key/1234abcd", "kms:key", "1234abcd"},
+		{"arn:aws:apigateway:us-east-1::/restapis/abc123", "apigateway:restapis", "abc123"},
+	} {
+		arn, err := ParseARN(tc.arn)
+		require.NoError(t, err)
+		assert.Equal(t, tc.typ, arn.Type(), tc.arn)
+		assert.Equal(t, tc.id, arn.ID(), tc.arn)
+	}
+}
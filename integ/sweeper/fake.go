@@ -0,0 +1,132 @@
+package sweeper
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+const (
+	getResourcesTarget = "ResourceGroupsTaggingAPI_20170126.GetResources"
+	jsonContentType    = "application/x-amz-json-1.1"
+)
+
+// FakeTaggingAPI serves GetResources of the Resource Groups Tagging API from Resources,
+// i.e. with httptest.NewServer and NewTaggingClient to test the sweeper without an AWS account.
+type FakeTaggingAPI struct {
+	Resources []Resource
+
+	mu       sync.Mutex
+	requests int
+}
+
+type getResourcesInput struct {
+	TagFilters       []TagFilter `json:"TagFilters,omitempty"`
+	PaginationToken  string      `json:"PaginationToken,omitempty"`
+	ResourcesPerPage int         `json:"ResourcesPerPage,omitempty"`
+}
+
+type tag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+type resourceTagMapping struct {
+	ResourceARN string `json:"ResourceARN"`
+	Tags        []tag  `json:"Tags"`
+}
+
+type getResourcesOutput struct {
+	PaginationToken        string               `json:"PaginationToken"`
+	ResourceTagMappingList []resourceTagMapping `json:"ResourceTagMappingList"`
+}
+
+type apiError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// Requests returns the number of GetResources requests served
+func (f *FakeTaggingAPI) Requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func (f *FakeTaggingAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if target := r.Header.Get("X-Amz-Target"); target != getResourcesTarget {
+		writeAPIError(w, http.StatusBadRequest, "UnknownOperationException", "unknown operation "+target)
+		return
+	}
+	var input getResourcesInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "SerializationException", err.Error())
+		return
+	}
+	start := 0
+	if input.PaginationToken != "" {
+		var err error
+		if start, err = strconv.Atoi(input.PaginationToken); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "PaginationTokenExpiredException", "invalid pagination token")
+			return
+		}
+	}
+	pageSize := input.ResourcesPerPage
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	var matching []Resource
+	for _, res := range f.Resources {
+		if matchesTagFilters(res, input.TagFilters) {
+			matching = append(matching, res)
+		}
+	}
+	var output getResourcesOutput
+	for i := start; i < len(matching) && i < start+pageSize; i++ {
+		m := resourceTagMapping{ResourceARN: matching[i].ARN}
+		for k, v := range matching[i].Tags {
+			m.Tags = append(m.Tags, tag{Key: k, Value: v})
+		}
+		output.ResourceTagMappingList = append(output.ResourceTagMappingList, m)
+	}
+	if start+pageSize < len(matching) {
+		output.PaginationToken = strconv.Itoa(start + pageSize)
+	}
+	w.Header().Set("Content-Type", jsonContentType)
+	_ = json.NewEncoder(w).Encode(output)
+}
+
+// matchesTagFilters reports if the resource matches all filters, as GetResources does
+func matchesTagFilters(r Resource, filters []TagFilter) bool {
+	for _, f := range filters {
+		value, ok := r.Tags[f.Key]
+		if !ok {
+			return false
+		}
+		if len(f.Values) == 0 {
+			continue
+		}
+		found := false
+		for _, v := range f.Values {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func writeAPIError(w http.ResponseWriter, status int, errorType, message string) {
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(apiError{Type: errorType, Message: message})
+}
@@ -0,0 +1,245 @@
+// Package sweeper deletes resources leaked by integration test runs, i.e. after a panic or a failed cleanup_terraform.
+//
+// The integration test harness tags every resource with the integ.TagApp, integ.TagRunID and integ.TagCreatedAt
+// default tags. The Sweeper finds tagged resources older than a TTL through the Resource Groups Tagging API
+// and deletes them in DeleteOrder.
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/terraconstructs/base/integ"
+)
+
+// DefaultTTL keeps resources of runs that may still be in progress
+const DefaultTTL = 6 * time.Hour
+
+// DeleteOrder lists resource types in the order they are deleted, resources depending on others first.
+// Types not listed are deleted last, in ARN order.
+var DeleteOrder = []string{
+	// event sources and triggers
+	"events:rule",
+	"sns:subscription",
+	"cloudwatch:alarm",
+	// compute
+	"states:stateMachine",
+	"ecs:service",
+	"autoscaling:autoScalingGroup",
+	"lambda:function",
+	"ec2:instance",
+	"ec2:launch-template",
+	"ecs:cluster",
+	// data and messaging
+	"sqs:queue",
+	"sns:topic",
+	"kinesis:stream",
+	"dynamodb:table",
+	"s3:bucket",
+	"secretsmanager:secret",
+	"logs:log-group",
+	// network
+	"ec2:natgateway",
+	"ec2:elastic-ip",
+	"ec2:internet-gateway",
+	"ec2:security-group",
+	"ec2:subnet",
+	"ec2:route-table",
+	"ec2:vpc",
+	// keys last, resources above may be encrypted with them
+	"kms:key",
+}
+
+// ErrPendingDeletion is returned by a Deleter if the resource is already scheduled for deletion,
+// i.e. a KMS key in its waiting period. The resource is kept and reported as StatusPending.
+var ErrPendingDeletion = errors.New("pending deletion")
+
+// Deleter deletes a resource of a type, see Sweeper.Deleters
+type Deleter interface {
+	Delete(ctx context.Context, r Resource) error
+}
+
+// DeleterFunc adapts a function to a Deleter
+type DeleterFunc func(ctx context.Context, r Resource) error
+
+func (f DeleterFunc) Delete(ctx context.Context, r Resource) error {
+	return f(ctx, r)
+}
+
+// Sweeper finds leaked resources by their run tags and deletes them
+type Sweeper struct {
+	Tagging  *TaggingClient
+	Deleters map[string]Deleter // by resource type, i.e. "sqs:queue", see ARN.Type
+	TTL      time.Duration      // resources created less than TTL ago are kept, i.e. DefaultTTL
+	Filters  []TagFilter        // narrow the sweep, i.e. to an app or run ID
+	DryRun   bool               // report the resources without deleting them
+	Now      func() time.Time   // defaults to time.Now
+}
+
+// Status of a resource in the Report
+type Status string
+
+const (
+	StatusDeleted     Status = "deleted"
+	StatusWouldDelete Status = "would delete"
+	StatusFailed      Status = "failed"
+	StatusNotExpired  Status = "kept (younger than TTL)"
+	StatusUntracked   Status = "kept (no creation time)"
+	StatusPending     Status = "kept (pending deletion)"
+	StatusUnsupported Status = "skipped (no deleter)"
+)
+
+// ReportEntry is the outcome of the sweep for a resource
+type ReportEntry struct {
+	Resource Resource
+	Type     string
+	Age      time.Duration // zero if the creation time is unknown
+	Status   Status
+	Err      error
+}
+
+// Report lists the resources found by the sweep in the order they were processed
+type Report struct {
+	DryRun  bool
+	Entries []ReportEntry
+}
+
+// SweepE finds the tagged resources and deletes the expired ones in DeleteOrder.
+// Failed deletes do not stop the sweep, they are reported and returned as a combined error.
+func (s *Sweeper) SweepE(ctx context.Context) (*Report, error) {
+	filters := append([]TagFilter{{Key: integ.TagApp}}, s.Filters...)
+	resources, err := s.Tagging.GetResourcesE(ctx, filters...)
+	if err != nil {
+		return nil, fmt.Errorf("error finding tagged resources: %v", err)
+	}
+
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+
+	report := &Report{DryRun: s.DryRun}
+	for _, r := range resources {
+		entry := ReportEntry{Resource: r, Type: resourceType(r)}
+		createdAt, err := time.Parse(time.RFC3339, r.Tags[integ.TagCreatedAt])
+		switch {
+		case err != nil:
+			entry.Status = StatusUntracked
+		case now.Sub(createdAt) < s.TTL:
+			entry.Age = now.Sub(createdAt)
+			entry.Status = StatusNotExpired
+		default:
+			entry.Age = now.Sub(createdAt)
+			entry.Status = StatusWouldDelete
+			if _, ok := s.Deleters[entry.Type]; !ok {
+				entry.Status = StatusUnsupported
+			}
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	sortDeleteOrder(report.Entries)
+	if s.DryRun {
+		return report, nil
+	}
+
+	var combinedErr error
+	for i, entry := range report.Entries {
+		if entry.Status != StatusWouldDelete {
+			continue
+		}
+		err := s.Deleters[entry.Type].Delete(ctx, entry.Resource)
+		if errors.Is(err, ErrPendingDeletion) {
+			report.Entries[i].Status = StatusPending
+			continue
+		}
+		if err != nil {
+			report.Entries[i].Status = StatusFailed
+			report.Entries[i].Err = err
+			combinedErr = multierror.Append(combinedErr, fmt.Errorf("%s: %v", entry.Resource.ARN, err))
+			continue
+		}
+		report.Entries[i].Status = StatusDeleted
+	}
+	return report, combinedErr
+}
+
+func resourceType(r Resource) string {
+	arn, err := ParseARN(r.ARN)
+	if err != nil {
+		return "unknown"
+	}
+	return arn.Type()
+}
+
+// sortDeleteOrder sorts entries by DeleteOrder, then by ARN
+func sortDeleteOrder(entries []ReportEntry) {
+	rank := make(map[string]int, len(DeleteOrder))
+	for i, t := range DeleteOrder {
+		rank[t] = i
+	}
+	rankOf := func(t string) int {
+		if r, ok := rank[t]; ok {
+			return r
+		}
+		return len(DeleteOrder)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		ri, rj := rankOf(entries[i].Type), rankOf(entries[j].Type)
+		if ri != rj {
+			return ri < rj
+		}
+		return entries[i].Resource.ARN < entries[j].Resource.ARN
+	})
+}
+
+// Count returns the number of entries with status
+func (r *Report) Count(status Status) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Write renders the report as a table, i.e.
+//
+//	STATUS        TYPE          AGE    APP  RUN     ARN
+//	would delete  sqs:queue     7h05m  sqs  4f2a9c  arn:aws:sqs:us-east-1:123456789012:sqs-test-4f2a9c
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tTYPE\tAGE\tAPP\tRUN\tARN")
+	for _, e := range r.Entries {
+		age := "-"
+		if e.Age > 0 {
+			age = fmt.Sprintf("%dh%02dm", int(e.Age.Hours()), int(e.Age.Minutes())%60)
+		}
+		runID := e.Resource.Tags[integ.TagRunID]
+		if runID == "" {
+			runID = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Status, e.Type, age, e.Resource.Tags[integ.TagApp], runID, e.Resource.ARN)
+		if e.Err != nil {
+			fmt.Fprintf(tw, "\t\t\t\t\t  %v\n", e.Err)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	expired := r.Count(StatusDeleted)
+	summary := "%d deleted, %d failed, %d skipped, %d kept.\n"
+	if r.DryRun {
+		expired = r.Count(StatusWouldDelete)
+		summary = "%d to delete, %d failed, %d skipped, %d kept.\n"
+	}
+	_, err := fmt.Fprintf(w, summary, expired, r.Count(StatusFailed), r.Count(StatusUnsupported),
+		r.Count(StatusNotExpired)+r.Count(StatusUntracked)+r.Count(StatusPending))
+	return err
+}
@@ -0,0 +1,147 @@
+package sweeper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sweepTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func taggedResource(arn, app, runID string, age time.Duration) Resource {
+	return Resource{ARN: arn, Tags: map[string]string{
+		"integ:app":        app,
+		"integ:run-id":     runID,
+		"integ:created-at": sweepTime.Add(-age).Format(time.RFC3339),
+	}}
+}
+
+// recordingDeleters records deleted ARNs in order, failing for the failing ARN
+func recordingDeleters(deleted *[]string, failing string, types ...string) map[string]Deleter {
+	deleters := map[string]Deleter{}
+	for _, typ := range types {
+		deleters[typ] = DeleterFunc(func(ctx context.Context, r Resource) error {
+			if r.ARN == failing {
+				return errors.New("DependencyViolation: resource has a dependent object")
+			}
+			*deleted = append(*deleted, r.ARN)
+			return nil
+		})
+	}
+	return deleters
+}
+
+func TestSweeper_SweepE(t *testing.T) {
+	tagging, _ := newFakeTagging(t,
+		taggedResource("arn:aws:kms:us-east-1:123456789012:key/1234abcd", "sqs", "4f2a9c", 7*time.Hour),
+		taggedResource("arn:aws:sqs:us-east-1:123456789012:sqs-test-4f2a9c", "sqs", "4f2a9c", 7*time.Hour),
+		taggedResource("arn:aws:lambda:us-east-1:123456789012:function:echo", "sns-lambda", "", 8*time.Hour+5*time.Minute),
+		taggedResource("arn:aws:sqs:us-east-1:123456789012:sqs-test-ci1234", "sqs", "ci1234", time.Hour),
+		taggedResource("arn:aws:apigateway:us-east-1::/restapis/abc123", "apigw", "", 7*time.Hour),
+		Resource{ARN: "arn:aws:sns:us-east-1:123456789012:manual", Tags: map[string]string{"integ:app": "sns"}},
+		Resource{ARN: "arn:aws:sns:us-east-1:123456789012:untagged"},
+	)
+	var deleted []string
+	s := &Sweeper{
+		Tagging:  tagging,
+		Deleters: recordingDeleters(&deleted, "", "sqs:queue", "lambda:function", "kms:key", "sns:topic"),
+		TTL:      DefaultTTL,
+		Now:      func() time.Time { return sweepTime },
+	}
+
+	report, err := s.SweepE(context.Background())
+	require.NoError(t, err)
+	// dependents first, keys last
+	assert.Equal(t, []string{
+		"arn:aws:lambda:us-east-1:123456789012:function:echo",
+		"arn:aws:sqs:us-east-1:123456789012:sqs-test-4f2a9c",
+		"arn:aws:kms:us-east-1:123456789012:key/1234abcd",
+	}, deleted)
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	assert.Equal(t, `STATUS                   TYPE                 AGE    APP         RUN     ARN
+deleted                  lambda:function      8h05m  sns-lambda  -       arn:aws:lambda:us-east-1:123456789012:function:echo
+deleted                  sqs:queue            7h00m  sqs         4f2a9c  arn:aws:sqs:us-east-1:123456789012:sqs-test-4f2a9c
+kept (younger than TTL)  sqs:queue            1h00m  sqs         ci1234  arn:aws:sqs:us-east-1:123456789012:sqs-test-ci1234
+kept (no creation time)  sns:topic            -      sns         -       arn:aws:sns:us-east-1:123456789012:manual
+deleted                  kms:key              7h00m  sqs         4f2a9c  arn:aws:kms:us-east-1:123456789012:key/1234abcd
+skipped (no deleter)     apigateway:restapis  7h00m  apigw       -       arn:aws:apigateway:us-east-1::/restapis/abc123
+3 deleted, 0 failed, 1 skipped, 2 kept.
+`, out.String())
+}
+
+func TestSweeper_DryRun(t *testing.T) {
+	tagging, _ := newFakeTagging(t,
+		taggedResource("arn:aws:sqs:us-east-1:123456789012:sqs-test-4f2a9c", "sqs", "4f2a9c", 7*time.Hour),
+		taggedResource("arn:aws:sqs:us-east-1:123456789012:sqs-test-ci1234", "sqs", "ci1234", 7*time.Hour),
+	)
+	var deleted []string
+	s := &Sweeper{
+		Tagging:  tagging,
+		Deleters: recordingDeleters(&deleted, "", "sqs:queue"),
+		Filters:  []TagFilter{{Key: "integ:run-id", Values: []string{"ci1234"}}},
+		DryRun:   true,
+		Now:      func() time.Time { return sweepTime },
+	}
+
+	report, err := s.SweepE(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+	require.Len(t, report.Entries, 1)
+	assert.Equal(t, StatusWouldDelete, report.Entries[0].Status)
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	assert.Contains(t, out.String(), "1 to delete, 0 failed, 0 skipped, 0 kept.")
+}
+
+func TestSweeper_Failed(t *testing.T) {
+	failing := "arn:aws:ec2:us-east-1:123456789012:security-group/sg-0abc"
+	tagging, _ := newFakeTagging(t,
+		taggedResource(failing, "vpc", "", 7*time.Hour),
+		taggedResource("arn:aws:ec2:us-east-1:123456789012:vpc/vpc-0abc", "vpc", "", 7*time.Hour),
+	)
+	var deleted []string
+	s := &Sweeper{
+		Tagging:  tagging,
+		Deleters: recordingDeleters(&deleted, failing, "ec2:security-group", "ec2:vpc"),
+		TTL:      time.Hour,
+		Now:      func() time.Time { return sweepTime },
+	}
+
+	report, err := s.SweepE(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), failing+": DependencyViolation: resource has a dependent object")
+	// the sweep continues after a failed delete
+	assert.Equal(t, []string{"arn:aws:ec2:us-east-1:123456789012:vpc/vpc-0abc"}, deleted)
+	assert.Equal(t, 1, report.Count(StatusFailed))
+	assert.Equal(t, 1, report.Count(StatusDeleted))
+}
+
+func TestSweeper_PendingDeletion(t *testing.T) {
+	pending := "arn:aws:kms:us-east-1:123456789012:key/1234abcd"
+	tagging, _ := newFakeTagging(t, taggedResource(pending, "sqs", "4f2a9c", 7*time.Hour))
+	s := &Sweeper{
+		Tagging: tagging,
+		Deleters: map[string]Deleter{"kms:key": DeleterFunc(func(ctx context.Context, r Resource) error {
+			return ErrPendingDeletion
+		})},
+		TTL: time.Hour,
+		Now: func() time.Time { return sweepTime },
+	}
+
+	report, err := s.SweepE(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Count(StatusPending))
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	assert.Contains(t, out.String(), "kept (pending deletion)  kms:key  7h00m  sqs  4f2a9c  "+pending)
+	assert.Contains(t, out.String(), "0 deleted, 0 failed, 0 skipped, 1 kept.")
+}
@@ -0,0 +1,68 @@
+package sweeper
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+)
+
+// Resource is a tagged resource returned by the Resource Groups Tagging API
+type Resource struct {
+	ARN  string
+	Tags map[string]string
+}
+
+// TagFilter selects resources with the tag key and one of the values, any value if Values is empty
+type TagFilter struct {
+	Key    string   `json:"Key"`
+	Values []string `json:"Values,omitempty"`
+}
+
+// TaggingClient lists tagged resources with the Resource Groups Tagging API, see NewTaggingClient
+type TaggingClient struct {
+	API resourcegroupstaggingapi.GetResourcesAPIClient
+}
+
+// NewTaggingClient returns a TaggingClient for the region and credentials of cfg.
+// A non-empty endpoint replaces the regional endpoint, i.e. the URL of a FakeTaggingAPI.
+func NewTaggingClient(cfg aws.Config, endpoint string) *TaggingClient {
+	return &TaggingClient{
+		API: resourcegroupstaggingapi.NewFromConfig(cfg, func(o *resourcegroupstaggingapi.Options) {
+			if endpoint != "" {
+				o.BaseEndpoint = aws.String(endpoint)
+			}
+		}),
+	}
+}
+
+// GetResourcesE returns all resources matching the tag filters, sorted by ARN
+func (c *TaggingClient) GetResourcesE(ctx context.Context, filters ...TagFilter) ([]Resource, error) {
+	input := &resourcegroupstaggingapi.GetResourcesInput{}
+	for _, f := range filters {
+		input.TagFilters = append(input.TagFilters, types.TagFilter{Key: aws.String(f.Key), Values: f.Values})
+	}
+	paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(c.API, input, func(o *resourcegroupstaggingapi.GetResourcesPaginatorOptions) {
+		o.Limit = 100
+	})
+	var resources []Resource
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range output.ResourceTagMappingList {
+			r := Resource{ARN: aws.ToString(m.ResourceARN), Tags: make(map[string]string, len(m.Tags))}
+			for _, t := range m.Tags {
+				r.Tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+			}
+			resources = append(resources, r)
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].ARN < resources[j].ARN
+	})
+	return resources, nil
+}
@@ -0,0 +1,94 @@
+package synthcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// RegistryEnv overrides the npm registry dist tags are resolved with, as for npm and bun
+	RegistryEnv = "NPM_CONFIG_REGISTRY"
+
+	defaultRegistry = "https://registry.npmjs.org"
+	resolveTimeout  = 30 * time.Second
+)
+
+// dist tags start with a letter, versions and ranges with a digit, an operator or `x`,
+// paths and protocols (i.e. file:, github:, workspace:*) contain `/` or `:`
+var distTagPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._-]*$`)
+
+// DistTag returns true if the dependency version is an npm dist tag, i.e. latest or next
+func DistTag(version string) bool {
+	return distTagPattern.MatchString(version) && version != "x" && version != "X"
+}
+
+// Resolver resolves npm dist tags to the versions they point to, see Inputs.Resolver.
+// Resolved tags are remembered, all tests of a namespace synth against the same release.
+type Resolver struct {
+	Registry   string       // i.e. https://registry.npmjs.org
+	HTTPClient *http.Client // defaults to a client with a timeout
+
+	mu       sync.Mutex
+	versions map[string]string
+}
+
+// DefaultResolver resolves dist tags with NPM_CONFIG_REGISTRY or the public npm registry
+func DefaultResolver() *Resolver {
+	registry := os.Getenv(RegistryEnv)
+	if registry == "" {
+		registry = defaultRegistry
+	}
+	return &Resolver{Registry: registry}
+}
+
+// ResolveE returns the version the dist tag of the package points to
+func (r *Resolver) ResolveE(name, tag string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if version, ok := r.versions[name+"@"+tag]; ok {
+		return version, nil
+	}
+
+	// scoped packages keep the `@`, their `/` is escaped
+	endpoint := strings.TrimSuffix(r.Registry, "/") + "/-/package/" + url.PathEscape(name) + "/dist-tags"
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: resolveTimeout}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error resolving %s@%s: %v", name, tag, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error resolving %s@%s: %s returned %s", name, tag, endpoint, resp.Status)
+	}
+	var tags map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return "", fmt.Errorf("error decoding dist tags of %s: %v", name, err)
+	}
+	version, ok := tags[tag]
+	if !ok {
+		return "", fmt.Errorf("%s has no dist tag %q", name, tag)
+	}
+	if r.versions == nil {
+		r.versions = map[string]string{}
+	}
+	r.versions[name+"@"+tag] = version
+	return version, nil
+}
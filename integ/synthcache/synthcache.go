@@ -0,0 +1,371 @@
+// Package synthcache caches synthesized stacks keyed on the hashes of the synth inputs.
+//
+// A cache hit restores the stack files into the Terraform working directory, so the synth executor
+// (copying the repo and running `bun install`) is skipped when neither the app, the compiled lib,
+// the package manifests, the dependencies, the environment of the app nor the assets changed.
+//
+// Set FRESH_SYNTH=true to ignore cached stacks, the fresh result is cached again.
+// Stacks are cached in SYNTH_CACHE_DIR, defaulting to terraconstructs/synth in the user cache directory,
+// entries unused for SYNTH_CACHE_MAX_AGE or beyond SYNTH_CACHE_MAX_SIZE are evicted, see Cache.PruneE.
+package synthcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// FreshEnv forces a fresh synth, ignoring cached stacks
+	FreshEnv = "FRESH_SYNTH"
+	// DirEnv overrides the cache directory
+	DirEnv = "SYNTH_CACHE_DIR"
+	// MaxAgeEnv overrides the time entries are kept unused, i.e. 72h
+	MaxAgeEnv = "SYNTH_CACHE_MAX_AGE"
+	// MaxSizeEnv overrides the size of the cache directory in megabytes
+	MaxSizeEnv = "SYNTH_CACHE_MAX_SIZE"
+
+	// DefaultMaxAge evicts entries unused for a week
+	DefaultMaxAge = 7 * 24 * time.Hour
+	// DefaultMaxSize evicts the least recently used entries beyond 1 GiB
+	DefaultMaxSize int64 = 1 << 30
+
+	// version invalidates all cached stacks when the key or the layout changes
+	version = "3"
+)
+
+// StackFiles are the files of a synthesized stack in the Terraform working directory
+var StackFiles = []string{"cdk.tf.json", "assets"}
+
+// Inputs of a synth, the cache key is derived from their contents
+type Inputs struct {
+	App          []byte            // source of the app
+	Lib          string            // path of the compiled lib, empty if the app does not use the local lib
+	Manifests    []string          // paths of package manifests and lockfiles, missing files are skipped
+	Dependencies map[string]string // dependencies of the synth app
+	Resolver     *Resolver         // resolves dist tags of Dependencies, nil fails the key of dist tags
+	Env          map[string]string // environment of the synth, all variables are in the key
+	Assets       []string          // paths of files or directories copied to the synth app
+}
+
+// KeyE returns the hex encoded SHA-256 of the inputs
+func (in Inputs) KeyE() (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "version %s\n", version)
+	fmt.Fprintf(h, "app %x\n", sha256.Sum256(in.App))
+	if in.Lib != "" {
+		libHash, err := libTreeHashE(in.Lib)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "lib %s\n", libHash)
+	}
+	for _, manifest := range in.Manifests {
+		if _, err := os.Stat(manifest); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		manifestHash, err := TreeHashE(manifest)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "manifest %q %s\n", filepath.Base(manifest), manifestHash)
+	}
+	for _, k := range sortedKeys(in.Dependencies) {
+		dependency := in.Dependencies[k]
+		// a new release of the tag changes the synthesized stack
+		if DistTag(dependency) {
+			if in.Resolver == nil {
+				return "", fmt.Errorf("no resolver for the dist tag of %s@%s", k, dependency)
+			}
+			resolved, err := in.Resolver.ResolveE(k, dependency)
+			if err != nil {
+				return "", err
+			}
+			dependency += "=" + resolved
+		}
+		fmt.Fprintf(h, "dependency %q %q\n", k, dependency)
+	}
+	// the app may read any variable passed to the synth, inherited ones included
+	for _, k := range sortedKeys(in.Env) {
+		fmt.Fprintf(h, "env %q %q\n", k, in.Env[k])
+	}
+	for _, asset := range in.Assets {
+		assetHash, err := TreeHashE(asset)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "asset %q %s\n", filepath.Base(asset), assetHash)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Fresh returns true if FRESH_SYNTH is set
+func Fresh() bool {
+	fresh, _ := strconv.ParseBool(os.Getenv(FreshEnv))
+	return fresh
+}
+
+// Cache stores synthesized stacks by key
+type Cache struct {
+	Dir     string
+	MaxAge  time.Duration // entries unused for longer are evicted by PruneE, 0 keeps them
+	MaxSize int64         // bytes kept by PruneE, the least recently used entries beyond are evicted, 0 is unbounded
+}
+
+// Default returns the cache in SYNTH_CACHE_DIR or the user cache directory, with the limits of
+// SYNTH_CACHE_MAX_AGE and SYNTH_CACHE_MAX_SIZE or DefaultMaxAge and DefaultMaxSize
+func Default() (*Cache, error) {
+	cache := &Cache{Dir: os.Getenv(DirEnv), MaxAge: DefaultMaxAge, MaxSize: DefaultMaxSize}
+	if cache.Dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("error finding the user cache directory, set %s: %v", DirEnv, err)
+		}
+		cache.Dir = filepath.Join(userCacheDir, "terraconstructs", "synth")
+	}
+	if maxAge := os.Getenv(MaxAgeEnv); maxAge != "" {
+		d, err := time.ParseDuration(maxAge)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid %s %q, expected a duration such as 72h", MaxAgeEnv, maxAge)
+		}
+		cache.MaxAge = d
+	}
+	if maxSize := os.Getenv(MaxSizeEnv); maxSize != "" {
+		mb, err := strconv.ParseInt(maxSize, 10, 64)
+		if err != nil || mb < 0 {
+			return nil, fmt.Errorf("invalid %s %q, expected megabytes", MaxSizeEnv, maxSize)
+		}
+		cache.MaxSize = mb << 20
+	}
+	return cache, nil
+}
+
+// RestoreE copies the stack files cached for key into tfWorkingDir, it returns false if nothing is cached
+func (c *Cache) RestoreE(key, tfWorkingDir string) (bool, error) {
+	entry := filepath.Join(c.Dir, key)
+	if _, err := os.Stat(entry); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	for _, name := range StackFiles {
+		src := filepath.Join(entry, name)
+		if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		dst := filepath.Join(tfWorkingDir, name)
+		if err := os.RemoveAll(dst); err != nil {
+			return false, err
+		}
+		if err := copyTree(src, dst); err != nil {
+			return false, fmt.Errorf("error restoring cached stack %s: %v", key, err)
+		}
+	}
+	// the modification time of entries tracks their last use, see PruneE
+	now := time.Now()
+	if err := os.Chtimes(entry, now, now); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SaveE caches the stack files of tfWorkingDir for key, replacing any cached entry.
+// Entries are written to a temporary directory and renamed, so concurrent tests do not restore partial entries.
+func (c *Cache) SaveE(key, tfWorkingDir string) error {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(c.Dir, key+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	for _, name := range StackFiles {
+		src := filepath.Join(tfWorkingDir, name)
+		if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := copyTree(src, filepath.Join(tmp, name)); err != nil {
+			return fmt.Errorf("error caching stack %s: %v", key, err)
+		}
+	}
+	entry := filepath.Join(c.Dir, key)
+	if err := os.Rename(tmp, entry); err == nil {
+		return nil
+	}
+	// replace the existing entry, i.e. with FRESH_SYNTH
+	old := tmp + ".old"
+	if err := os.Rename(entry, old); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	defer os.RemoveAll(old)
+	return os.Rename(tmp, entry)
+}
+
+// cacheEntry is an entry of the cache directory, including temporary entries of SaveE
+type cacheEntry struct {
+	name    string
+	size    int64
+	lastUse time.Time
+}
+
+// PruneE evicts the entries unused for MaxAge, then the least recently used entries until the cache
+// fits in MaxSize. Temporary entries left by interrupted saves are evicted with MaxAge only.
+func (c *Cache) PruneE() error {
+	dirEntries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var entries []cacheEntry
+	for _, d := range dirEntries {
+		info, err := d.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		size, err := treeSizeE(filepath.Join(c.Dir, d.Name()))
+		if err != nil {
+			return err
+		}
+		entries = append(entries, cacheEntry{name: d.Name(), size: size, lastUse: info.ModTime()})
+	}
+	// most recently used first
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].lastUse.After(entries[j].lastUse)
+	})
+	var kept int64
+	for _, e := range entries {
+		expired := c.MaxAge > 0 && time.Since(e.lastUse) > c.MaxAge
+		// temporary entries are still being written
+		temporary := strings.Contains(e.name, ".tmp-")
+		if !expired && (temporary || c.MaxSize <= 0 || kept+e.size <= c.MaxSize) {
+			kept += e.size
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(c.Dir, e.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// treeSizeE returns the size of the files under path
+func treeSizeE(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+var (
+	libHashesMu sync.Mutex
+	libHashes   = map[string]string{}
+)
+
+// libTreeHashE hashes the lib once per process, all tests of a namespace synth against the same compiled lib
+func libTreeHashE(lib string) (string, error) {
+	libHashesMu.Lock()
+	defer libHashesMu.Unlock()
+	if h, ok := libHashes[lib]; ok {
+		return h, nil
+	}
+	h, err := TreeHashE(lib)
+	if err != nil {
+		return "", err
+	}
+	libHashes[lib] = h
+	return h, nil
+}
+
+// TreeHashE returns the hex encoded SHA-256 of the relative paths and contents of all files under path,
+// path may be a single file
+func TreeHashE(path string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fileHash := sha256.New()
+		if _, err := io.Copy(fileHash, f); err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%q %x\n", filepath.ToSlash(rel), fileHash.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error hashing %s: %v", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyTree copies the file or directory src to dst
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
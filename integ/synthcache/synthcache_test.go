@@ -0,0 +1,258 @@
+package synthcache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+}
+
+func testInputs(t *testing.T) Inputs {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"lib/index.js":            "exports.x = 1;",
+		"lib/aws/notify/queue.js": "exports.Queue = class {};",
+		"package.json":            `{"peerDependencies": {"constructs": "^10.6.0"}}`,
+		"pnpm-lock.yaml":          "constructs: 10.6.0",
+		"apps/handlers/index.py":  "def handler(event, context): pass",
+		"apps/cdktf.json":         `{"language": "typescript"}`,
+	})
+	return Inputs{
+		App:          []byte(`new Queue(stack, "Queue");`),
+		Lib:          filepath.Join(dir, "lib"),
+		Manifests:    []string{filepath.Join(dir, "package.json"), filepath.Join(dir, "pnpm-lock.yaml"), filepath.Join(dir, "bun.lock")},
+		Dependencies: map[string]string{"terraconstructs": "./terraconstructs"},
+		Env:          map[string]string{"STACK_NAME": "sqs", "ENVIRONMENT_NAME": "test", "PWD": "/home/a"},
+		Assets:       []string{filepath.Join(dir, "apps", "handlers"), filepath.Join(dir, "apps", "cdktf.json")},
+	}
+}
+
+func TestInputs_KeyE(t *testing.T) {
+	in := testInputs(t)
+	key, err := in.KeyE()
+	require.NoError(t, err)
+	assert.Len(t, key, 64)
+
+	unchanged, err := testInputs(t).KeyE()
+	require.NoError(t, err)
+	assert.Equal(t, key, unchanged)
+
+	for name, change := range map[string]func(in *Inputs){
+		"app":           func(in *Inputs) { in.App = []byte(`new Queue(stack, "Renamed");`) },
+		"dependencies":  func(in *Inputs) { in.Dependencies = map[string]string{"terraconstructs": "0.1.0"} },
+		"env":           func(in *Inputs) { in.Env = map[string]string{"STACK_NAME": "sqs-4f2a9c", "ENVIRONMENT_NAME": "test"} },
+		"app env":       func(in *Inputs) { in.Env["QUEUE_FIFO"] = "true" },
+		"inherited env": func(in *Inputs) { in.Env["PWD"] = "/home/b" },
+		"no lib":        func(in *Inputs) { in.Lib = "" },
+		"package.json": func(in *Inputs) {
+			writeFiles(t, filepath.Dir(in.Manifests[0]), map[string]string{"package.json": `{"peerDependencies": {"constructs": "^10.7.0"}}`})
+		},
+		"lockfile": func(in *Inputs) {
+			writeFiles(t, filepath.Dir(in.Manifests[1]), map[string]string{"pnpm-lock.yaml": "constructs: 10.6.1"})
+		},
+		"asset": func(in *Inputs) {
+			writeFiles(t, in.Assets[0], map[string]string{"index.py": "def handler(event, context): return 1"})
+		},
+	} {
+		changed := testInputs(t)
+		change(&changed)
+		changedKey, err := changed.KeyE()
+		require.NoError(t, err)
+		// testInputs uses a new temp dir, lib and asset paths are not part of the key
+		assert.NotEqual(t, key, changedKey, name)
+	}
+}
+
+func TestInputs_KeyE_DistTag(t *testing.T) {
+	latest := "0.1.0"
+	var requests int
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/-/package/terraconstructs/dist-tags", r.URL.EscapedPath())
+		fmt.Fprintf(w, `{"latest": %q, "next": "0.2.0-rc.1"}`, latest)
+	}))
+	t.Cleanup(registry.Close)
+
+	in := testInputs(t)
+	in.Lib = ""
+	in.Dependencies = map[string]string{"terraconstructs": "latest"}
+	_, err := in.KeyE()
+	assert.EqualError(t, err, "no resolver for the dist tag of terraconstructs@latest")
+
+	in.Resolver = &Resolver{Registry: registry.URL}
+	key, err := in.KeyE()
+	require.NoError(t, err)
+	pinned := in
+	pinned.Dependencies = map[string]string{"terraconstructs": "0.1.0"}
+	pinnedKey, err := pinned.KeyE()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, pinnedKey)
+
+	// a new release invalidates the key
+	latest = "0.1.1"
+	in.Resolver = &Resolver{Registry: registry.URL}
+	releasedKey, err := in.KeyE()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, releasedKey)
+	// tags are resolved once per resolver
+	_, err = in.KeyE()
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+
+	in.Dependencies = map[string]string{"terraconstructs": "beta"}
+	_, err = in.KeyE()
+	assert.EqualError(t, err, `terraconstructs has no dist tag "beta"`)
+}
+
+func TestDistTag(t *testing.T) {
+	for version, distTag := range map[string]bool{
+		"latest":            true,
+		"next":              true,
+		"0.1.0":             false,
+		"^3.682.0":          false,
+		"~1.2":              false,
+		"x":                 false,
+		"*":                 false,
+		"./terraconstructs": false,
+		"file:../lib":       false,
+		"workspace:*":       false,
+	} {
+		assert.Equal(t, distTag, DistTag(version), version)
+	}
+}
+
+func TestTreeHashE(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a/b.js": "b", "c.js": "c"})
+	h1, err := TreeHashE(dir)
+	require.NoError(t, err)
+
+	// renaming a file changes the hash
+	require.NoError(t, os.Rename(filepath.Join(dir, "c.js"), filepath.Join(dir, "d.js")))
+	h2, err := TreeHashE(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, h1, h2)
+
+	_, err = TreeHashE(filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "error hashing")
+}
+
+func TestCache(t *testing.T) {
+	cache := &Cache{Dir: filepath.Join(t.TempDir(), "cache")}
+	synthDir := t.TempDir()
+	writeFiles(t, synthDir, map[string]string{
+		"cdk.tf.json":             `{"resource": {}}`,
+		"assets/handler/index.py": "def handler(event, context): pass",
+		"terraform.tfstate":       "{}",
+		".test-data/app.json":     "{}",
+	})
+
+	tfWorkingDir := t.TempDir()
+	hit, err := cache.RestoreE("abc", tfWorkingDir)
+	require.NoError(t, err)
+	assert.False(t, hit)
+
+	require.NoError(t, cache.SaveE("abc", synthDir))
+	writeFiles(t, tfWorkingDir, map[string]string{"assets/stale/index.py": "stale"})
+	hit, err = cache.RestoreE("abc", tfWorkingDir)
+	require.NoError(t, err)
+	assert.True(t, hit)
+
+	data, err := os.ReadFile(filepath.Join(tfWorkingDir, "cdk.tf.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"resource": {}}`, string(data))
+	assert.FileExists(t, filepath.Join(tfWorkingDir, "assets", "handler", "index.py"))
+	assert.NoFileExists(t, filepath.Join(tfWorkingDir, "assets", "stale", "index.py"))
+	// only stack files are cached
+	assert.NoFileExists(t, filepath.Join(tfWorkingDir, "terraform.tfstate"))
+
+	// saving again replaces the entry
+	writeFiles(t, synthDir, map[string]string{"cdk.tf.json": `{"resource": {"aws_sqs_queue": {}}}`})
+	require.NoError(t, cache.SaveE("abc", synthDir))
+	_, err = cache.RestoreE("abc", tfWorkingDir)
+	require.NoError(t, err)
+	data, err = os.ReadFile(filepath.Join(tfWorkingDir, "cdk.tf.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"resource": {"aws_sqs_queue": {}}}`, string(data))
+	entries, err := os.ReadDir(cache.Dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary entries are removed")
+}
+
+// writeEntry writes a cache entry of size bytes last used at lastUse
+func writeEntry(t *testing.T, cache *Cache, name string, size int, lastUse time.Time) {
+	writeFiles(t, filepath.Join(cache.Dir, name), map[string]string{"cdk.tf.json": strings.Repeat("x", size)})
+	require.NoError(t, os.Chtimes(filepath.Join(cache.Dir, name), lastUse, lastUse))
+}
+
+func TestCache_PruneE(t *testing.T) {
+	cache := &Cache{Dir: filepath.Join(t.TempDir(), "cache"), MaxAge: 24 * time.Hour, MaxSize: 250}
+	require.NoError(t, cache.PruneE(), "a missing cache has nothing to prune")
+
+	now := time.Now()
+	writeEntry(t, cache, "expired", 10, now.Add(-48*time.Hour))
+	writeEntry(t, cache, "expired.tmp-123", 10, now.Add(-48*time.Hour))
+	writeEntry(t, cache, "oldest", 100, now.Add(-3*time.Hour))
+	writeEntry(t, cache, "older", 100, now.Add(-2*time.Hour))
+	writeEntry(t, cache, "recent", 100, now.Add(-time.Hour))
+	writeEntry(t, cache, "recent.tmp-456", 10, now)
+
+	// restoring an entry marks it as used
+	hit, err := cache.RestoreE("oldest", t.TempDir())
+	require.NoError(t, err)
+	assert.True(t, hit)
+
+	require.NoError(t, cache.PruneE())
+	entries, err := os.ReadDir(cache.Dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"oldest", "recent", "recent.tmp-456"}, names)
+}
+
+func TestDefault(t *testing.T) {
+	t.Setenv(DirEnv, "/tmp/synth-cache")
+	cache, err := Default()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/synth-cache", cache.Dir)
+	assert.Equal(t, DefaultMaxAge, cache.MaxAge)
+	assert.Equal(t, DefaultMaxSize, cache.MaxSize)
+
+	t.Setenv(MaxAgeEnv, "72h")
+	t.Setenv(MaxSizeEnv, "512")
+	cache, err = Default()
+	require.NoError(t, err)
+	assert.Equal(t, 72*time.Hour, cache.MaxAge)
+	assert.Equal(t, int64(512<<20), cache.MaxSize)
+
+	t.Setenv(MaxSizeEnv, "1GB")
+	_, err = Default()
+	assert.EqualError(t, err, `invalid SYNTH_CACHE_MAX_SIZE "1GB", expected megabytes`)
+	t.Setenv(MaxSizeEnv, "")
+	t.Setenv(MaxAgeEnv, "7d")
+	_, err = Default()
+	assert.EqualError(t, err, `invalid SYNTH_CACHE_MAX_AGE "7d", expected a duration such as 72h`)
+	t.Setenv(MaxAgeEnv, "")
+
+	t.Setenv(FreshEnv, "true")
+	assert.True(t, Fresh())
+	t.Setenv(FreshEnv, "")
+	assert.False(t, Fresh())
+}
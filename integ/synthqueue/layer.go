@@ -0,0 +1,186 @@
+package synthqueue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	// LayerDirEnv overrides the directory of the dependency layers
+	LayerDirEnv = "SYNTH_LAYER_DIR"
+	// InstallCacheEnv points bun to the shared install cache of the layers
+	InstallCacheEnv = "BUN_INSTALL_CACHE_DIR"
+
+	// installCache is the bun install cache shared by all layers
+	installCache = "bun-cache"
+	// completeMarker is written once the layer is installed, incomplete layers are installed again
+	completeMarker = ".complete"
+)
+
+// InstallFunc installs the dependencies of package.json in dir, with env added to the process environment
+type InstallFunc func(ctx context.Context, dir string, env []string) error
+
+// BunInstall runs `bun install` in dir
+func BunInstall(ctx context.Context, dir string, env []string) error {
+	cmd := exec.CommandContext(ctx, "bun", "install")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("bun install in %s failed: %v\n%s", dir, err, output)
+	}
+	return nil
+}
+
+// Layers pre-install the dependencies of synth apps, one layer per dependency set.
+//
+// A layer is a node_modules installed once for the dependencies, its packages are kept in a bun install cache
+// shared by all layers. Synth executors installing the same dependencies with InstallCacheEnv set to CacheDir
+// hard link the packages from that cache instead of downloading and extracting them.
+type Layers struct {
+	Dir     string
+	Install InstallFunc // defaults to BunInstall
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex // per layer, concurrent synths wait for the first install
+}
+
+// DefaultLayers returns the layers in SYNTH_LAYER_DIR or the user cache directory
+func DefaultLayers() (*Layers, error) {
+	if dir := os.Getenv(LayerDirEnv); dir != "" {
+		return &Layers{Dir: dir}, nil
+	}
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("error finding the user cache directory, set %s: %v", LayerDirEnv, err)
+	}
+	return &Layers{Dir: filepath.Join(userCacheDir, "terraconstructs", "synth-layers")}, nil
+}
+
+// CacheDir returns the bun install cache shared by the layers
+func (l *Layers) CacheDir() string {
+	return filepath.Join(l.Dir, installCache)
+}
+
+// Env returns the environment pointing bun installs to the shared install cache
+func (l *Layers) Env() map[string]string {
+	return map[string]string{InstallCacheEnv: l.CacheDir()}
+}
+
+// PrepareE installs the layer of dependencies unless it is installed already and returns its directory.
+// Concurrent calls for the same dependencies install the layer once.
+func (l *Layers) PrepareE(ctx context.Context, dependencies map[string]string) (string, error) {
+	key := dependenciesKey(dependencies)
+	dir := filepath.Join(l.Dir, key)
+
+	lock := l.layerLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, err := os.Stat(filepath.Join(dir, completeMarker)); err == nil {
+		return dir, nil
+	}
+	if err := os.MkdirAll(l.Dir, 0o755); err != nil {
+		return "", err
+	}
+	// install into a temporary directory, other test processes may prepare the same layer
+	tmp, err := os.MkdirTemp(l.Dir, key+".tmp-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	packageJson, err := json.MarshalIndent(map[string]any{
+		"name":         "synth-layer",
+		"private":      true,
+		"dependencies": dependencies,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "package.json"), packageJson, 0o644); err != nil {
+		return "", err
+	}
+	install := l.Install
+	if install == nil {
+		install = BunInstall
+	}
+	if err := install(ctx, tmp, []string{InstallCacheEnv + "=" + l.CacheDir()}); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, completeMarker), nil, 0o644); err != nil {
+		return "", err
+	}
+	// an incomplete layer of an interrupted install is replaced
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		if _, statErr := os.Stat(filepath.Join(dir, completeMarker)); statErr == nil {
+			return dir, nil
+		}
+		return "", err
+	}
+	return dir, nil
+}
+
+func (l *Layers) layerLock(key string) *sync.Mutex {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locks == nil {
+		l.locks = map[string]*sync.Mutex{}
+	}
+	if _, ok := l.locks[key]; !ok {
+		l.locks[key] = &sync.Mutex{}
+	}
+	return l.locks[key]
+}
+
+// LayerDependenciesE returns the dependencies a synth app installs for the local lib at repoRoot: the dependencies
+// and peer dependencies of its package.json, with the synth dependencies except the local lib package itself.
+func LayerDependenciesE(repoRoot, libPackage string, synthDependencies map[string]string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(repoRoot, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	var pkg struct {
+		Dependencies     map[string]string `json:"dependencies"`
+		PeerDependencies map[string]string `json:"peerDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("error parsing %s/package.json: %v", repoRoot, err)
+	}
+	deps := map[string]string{}
+	for _, m := range []map[string]string{pkg.Dependencies, pkg.PeerDependencies, synthDependencies} {
+		for k, v := range m {
+			deps[k] = v
+		}
+	}
+	delete(deps, libPackage)
+	if len(deps) == 0 {
+		return nil, errors.New("no dependencies to pre-install")
+	}
+	return deps, nil
+}
+
+// dependenciesKey returns a short hash of the sorted dependencies
+func dependenciesKey(dependencies map[string]string) string {
+	keys := make([]string, 0, len(dependencies))
+	for k := range dependencies {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%q %q\n", k, dependencies[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
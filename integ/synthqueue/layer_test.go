@@ -0,0 +1,117 @@
+package synthqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeInstall(calls *atomic.Int32) InstallFunc {
+	return func(ctx context.Context, dir string, env []string) error {
+		calls.Add(1)
+		return os.MkdirAll(filepath.Join(dir, "node_modules", "constructs"), 0o755)
+	}
+}
+
+func TestLayers_PrepareE(t *testing.T) {
+	var calls atomic.Int32
+	layers := &Layers{Dir: t.TempDir(), Install: fakeInstall(&calls)}
+	deps := map[string]string{"constructs": "^10.6.0", "cdktn": "^0.23.0"}
+
+	var wg sync.WaitGroup
+	dirs := make([]string, 5)
+	for i := range dirs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dir, err := layers.PrepareE(context.Background(), deps)
+			assert.NoError(t, err)
+			dirs[i] = dir
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load(), "concurrent prepares install once")
+	for _, dir := range dirs {
+		assert.Equal(t, dirs[0], dir)
+	}
+	assert.DirExists(t, filepath.Join(dirs[0], "node_modules", "constructs"))
+
+	var packageJson struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	data, err := os.ReadFile(filepath.Join(dirs[0], "package.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &packageJson))
+	assert.Equal(t, deps, packageJson.Dependencies)
+
+	// a new process reuses the installed layer
+	reused := &Layers{Dir: layers.Dir, Install: fakeInstall(&calls)}
+	dir, err := reused.PrepareE(context.Background(), deps)
+	require.NoError(t, err)
+	assert.Equal(t, dirs[0], dir)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// other dependencies get another layer
+	other, err := layers.PrepareE(context.Background(), map[string]string{"constructs": "^10.7.0"})
+	require.NoError(t, err)
+	assert.NotEqual(t, dirs[0], other)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestLayers_PrepareE_InstallFails(t *testing.T) {
+	var calls atomic.Int32
+	layers := &Layers{Dir: t.TempDir()}
+	layers.Install = func(ctx context.Context, dir string, env []string) error {
+		calls.Add(1)
+		assert.Equal(t, []string{InstallCacheEnv + "=" + layers.CacheDir()}, env)
+		return errors.New("bun install failed")
+	}
+	deps := map[string]string{"constructs": "^10.6.0"}
+	_, err := layers.PrepareE(context.Background(), deps)
+	assert.ErrorContains(t, err, "bun install failed")
+
+	// failed installs leave no layer behind and are retried
+	entries, err := os.ReadDir(layers.Dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	layers.Install = fakeInstall(&calls)
+	_, err = layers.PrepareE(context.Background(), deps)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestLayers_Env(t *testing.T) {
+	layers := &Layers{Dir: "/cache/layers"}
+	assert.Equal(t, map[string]string{"BUN_INSTALL_CACHE_DIR": "/cache/layers/bun-cache"}, layers.Env())
+}
+
+func TestLayerDependenciesE(t *testing.T) {
+	repoRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repoRoot, "package.json"), []byte(`{
+  "name": "terraconstructs",
+  "dependencies": {"change-case": "^4.1.1"},
+  "peerDependencies": {"constructs": "^10.6.0", "cdktn": "^0.23.0"}
+}`), 0o644))
+
+	deps, err := LayerDependenciesE(repoRoot, "terraconstructs", map[string]string{
+		"terraconstructs": "./terraconstructs",
+		"cdktn":           "0.23.1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"change-case": "^4.1.1",
+		"constructs":  "^10.6.0",
+		"cdktn":       "0.23.1",
+	}, deps)
+
+	_, err = LayerDependenciesE(t.TempDir(), "terraconstructs", nil)
+	assert.Error(t, err)
+}
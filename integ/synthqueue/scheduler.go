@@ -0,0 +1,114 @@
+// Package synthqueue bounds the number of concurrent synth executors of the test process.
+//
+// Runners call t.Parallel, so `go test ./...` would otherwise start a Bun executor for every app at once,
+// each copying the repo and installing dependencies. The Scheduler queues synths beyond SYNTH_CONCURRENCY
+// and a Layers directory pre-installs the synth dependencies once, so executors link them from a shared
+// install cache instead of downloading and extracting them again.
+package synthqueue
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// ConcurrencyEnv sets the maximum number of concurrent synths of the process
+const ConcurrencyEnv = "SYNTH_CONCURRENCY"
+
+// Scheduler hands out a bounded number of synth slots
+type Scheduler struct {
+	slots chan struct{}
+
+	mu     sync.Mutex
+	queued int
+}
+
+// New returns a scheduler running at most max synths at once, max is at least 1
+func New(max int) *Scheduler {
+	if max < 1 {
+		max = 1
+	}
+	return &Scheduler{slots: make(chan struct{}, max)}
+}
+
+var (
+	defaultScheduler     *Scheduler
+	defaultSchedulerOnce sync.Once
+)
+
+// Default returns the process-wide scheduler, sized by SYNTH_CONCURRENCY or DefaultConcurrency
+func Default() *Scheduler {
+	defaultSchedulerOnce.Do(func() {
+		defaultScheduler = New(Concurrency())
+	})
+	return defaultScheduler
+}
+
+// DefaultConcurrency is half the CPUs, installs and synths are memory bound rather than CPU bound
+func DefaultConcurrency() int {
+	return max(1, runtime.NumCPU()/2)
+}
+
+// Concurrency returns SYNTH_CONCURRENCY, or DefaultConcurrency if it is not a positive number
+func Concurrency() int {
+	n, err := strconv.Atoi(os.Getenv(ConcurrencyEnv))
+	if err != nil || n < 1 {
+		return DefaultConcurrency()
+	}
+	return n
+}
+
+// Max returns the maximum number of concurrent synths
+func (s *Scheduler) Max() int {
+	return cap(s.slots)
+}
+
+// Running returns the number of synths holding a slot
+func (s *Scheduler) Running() int {
+	return len(s.slots)
+}
+
+// Queued returns the number of synths waiting for a slot
+func (s *Scheduler) Queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queued
+}
+
+// AcquireE waits for a synth slot, it returns an error if ctx is done first, i.e. when the test times out.
+// Call release once the synth finished, calling it again has no effect.
+func (s *Scheduler) AcquireE(ctx context.Context) (release func(), err error) {
+	select {
+	case s.slots <- struct{}{}:
+		return s.releaseFunc(), nil
+	default:
+	}
+
+	s.mu.Lock()
+	s.queued++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.queued--
+		s.mu.Unlock()
+	}()
+
+	select {
+	case s.slots <- struct{}{}:
+		return s.releaseFunc(), nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("canceled while waiting for one of %d synth slots: %w", s.Max(), context.Cause(ctx))
+	}
+}
+
+func (s *Scheduler) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-s.slots
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package synthqueue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_Bounded(t *testing.T) {
+	s := New(2)
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := s.AcquireE(context.Background())
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer release()
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), peak.Load())
+	assert.Equal(t, 0, s.Running())
+	assert.Equal(t, 0, s.Queued())
+}
+
+func TestScheduler_CanceledWhileQueued(t *testing.T) {
+	s := New(1)
+	release, err := s.AcquireE(context.Background())
+	require.NoError(t, err)
+
+	deadline := errors.New("test deadline reached")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := s.AcquireE(ctx)
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return s.Queued() == 1 }, time.Second, time.Millisecond)
+	cancel(deadline)
+
+	err = <-done
+	assert.ErrorIs(t, err, deadline)
+	assert.ErrorContains(t, err, "canceled while waiting for one of 1 synth slots")
+	assert.Equal(t, 0, s.Queued())
+	assert.Equal(t, 1, s.Running())
+
+	// releasing twice frees a single slot
+	release()
+	release()
+	assert.Equal(t, 0, s.Running())
+}
+
+func TestNew_AtLeastOne(t *testing.T) {
+	assert.Equal(t, 1, New(0).Max())
+}
+
+func TestConcurrency(t *testing.T) {
+	t.Setenv(ConcurrencyEnv, "3")
+	assert.Equal(t, 3, Concurrency())
+	t.Setenv(ConcurrencyEnv, "zero")
+	assert.Equal(t, DefaultConcurrency(), Concurrency())
+	t.Setenv(ConcurrencyEnv, "0")
+	assert.Equal(t, DefaultConcurrency(), Concurrency())
+}
@@ -0,0 +1,87 @@
+package integ
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Default tags of the resources of integration test apps, the sweeper finds leaked resources by them
+const (
+	TagApp       = "integ:app"
+	TagRunID     = "integ:run-id"
+	TagCreatedAt = "integ:created-at" // RFC 3339 time of the first synth of the app
+)
+
+// RunTags returns the default tags of the resources of testApp, runID may be empty
+func RunTags(testApp, runID string, createdAt time.Time) map[string]string {
+	tags := map[string]string{
+		TagApp:       testApp,
+		TagCreatedAt: createdAt.UTC().Format(time.RFC3339),
+	}
+	if runID != "" {
+		tags[TagRunID] = runID
+	}
+	return tags
+}
+
+// InjectDefaultTagsE adds tags to the default_tags of every aws provider in the synthesized stack file.
+// Default tags set by the app take precedence.
+func InjectDefaultTagsE(stackFile string, tags map[string]string) error {
+	data, err := os.ReadFile(stackFile)
+	if err != nil {
+		return err
+	}
+	var stack map[string]any
+	if err := json.Unmarshal(data, &stack); err != nil {
+		return fmt.Errorf("error decoding %s: %v", stackFile, err)
+	}
+	providers, _ := stack["provider"].(map[string]any)
+	awsProviders, _ := providers["aws"].([]any)
+	if len(awsProviders) == 0 {
+		return nil
+	}
+	for i, p := range awsProviders {
+		provider, ok := p.(map[string]any)
+		if !ok {
+			return fmt.Errorf("error decoding %s: provider.aws[%d] is not an object", stackFile, i)
+		}
+		injectDefaultTags(provider, tags)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(stack); err != nil {
+		return err
+	}
+	return os.WriteFile(stackFile, buf.Bytes(), 0o644)
+}
+
+// injectDefaultTags merges tags into the first default_tags block of the provider
+func injectDefaultTags(provider map[string]any, tags map[string]string) {
+	blocks, _ := provider["default_tags"].([]any)
+	var block map[string]any
+	if len(blocks) > 0 {
+		block, _ = blocks[0].(map[string]any)
+	}
+	if block == nil {
+		block = map[string]any{}
+		blocks = []any{block}
+	}
+	existing, _ := block["tags"].(map[string]any)
+	if existing == nil {
+		existing = map[string]any{}
+	}
+	for k, v := range tags {
+		if _, ok := existing[k]; !ok {
+			existing[k] = v
+		}
+	}
+	block["tags"] = existing
+	blocks[0] = block
+	provider["default_tags"] = blocks
+}
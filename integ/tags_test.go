@@ -0,0 +1,62 @@
+package integ
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTags(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	assert.Equal(t, map[string]string{
+		TagApp:       "sqs",
+		TagRunID:     "4f2a9c",
+		TagCreatedAt: "2024-05-01T10:00:00Z",
+	}, RunTags("sqs", "4f2a9c", createdAt))
+	assert.NotContains(t, RunTags("sqs", "", createdAt), TagRunID)
+}
+
+func TestInjectDefaultTagsE(t *testing.T) {
+	stackFile := filepath.Join(t.TempDir(), "cdk.tf.json")
+	require.NoError(t, os.WriteFile(stackFile, []byte(`{
+  "provider": {
+    "aws": [
+      {"region": "us-east-1"},
+      {"alias": "edge", "region": "us-east-1", "default_tags": [{"tags": {"owner": "app", "integ:app": "custom"}}]}
+    ]
+  },
+  "resource": {"aws_sqs_queue": {"Queue": {"name_prefix": "sqs<Queue"}}}
+}`), 0o644))
+
+	tags := RunTags("sqs", "4f2a9c", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, InjectDefaultTagsE(stackFile, tags))
+
+	var stack map[string]any
+	data, err := os.ReadFile(stackFile)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &stack))
+	value, err := SearchJMESPath("provider.aws[*].default_tags[0].tags", stack)
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		map[string]any{"integ:app": "sqs", "integ:run-id": "4f2a9c", "integ:created-at": "2024-05-01T10:00:00Z"},
+		// tags set by the app are kept
+		map[string]any{"owner": "app", "integ:app": "custom", "integ:run-id": "4f2a9c", "integ:created-at": "2024-05-01T10:00:00Z"},
+	}, value)
+	// resources are untouched and HTML characters are not escaped
+	assert.Contains(t, string(data), `"name_prefix": "sqs<Queue"`)
+}
+
+func TestInjectDefaultTagsE_NoProvider(t *testing.T) {
+	stackFile := filepath.Join(t.TempDir(), "cdk.tf.json")
+	contents := `{"output": {}}`
+	require.NoError(t, os.WriteFile(stackFile, []byte(contents), 0o644))
+	require.NoError(t, InjectDefaultTagsE(stackFile, RunTags("sqs", "", time.Now())))
+	data, err := os.ReadFile(stackFile)
+	require.NoError(t, err)
+	assert.Equal(t, contents, string(data))
+}
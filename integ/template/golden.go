@@ -0,0 +1,163 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/terraconstructs/base/integ/snapshot"
+)
+
+const (
+	// GoldenDir holds the golden files of a namespace, relative to the namespace folder
+	GoldenDir = "golden"
+	// UpdateGoldenEnv enables regenerating golden files from the synthesized stack
+	UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+	// Tokens replacing volatile values in golden files
+	HashToken    = "<HASH>"
+	TempDirToken = "<TMPDIR>"
+	VersionToken = "<VERSION>"
+)
+
+// GoldenRules normalize asset hashes and temporary paths in the synthesized stack.
+// Provider and cdktf versions are normalized by their location in the Terraform JSON.
+var GoldenRules = []snapshot.Rule{
+	{
+		// go-synth working directories, i.e. /tmp/go-synth-1234567/cdktf.out
+		Name:        "temp-dir",
+		Pattern:     regexp.MustCompile(`(?:/private)?(?:/tmp|/var/folders/[^/"\s]+/[^/"\s]+/T)/[^/"\s]+`),
+		Replacement: TempDirToken,
+	},
+	{
+		// asset hashes, i.e. asset.<sha256>.zip or assets/FunctionCode/<MD5>/archive.zip
+		Name:        "asset-hash",
+		Pattern:     regexp.MustCompile(`\b(?:[0-9a-fA-F]{64}|[0-9a-fA-F]{32})\b`),
+		Replacement: HashToken,
+	},
+	{
+		// base64 encoded sha256 of asset archives
+		Name:        "base64-hash",
+		Pattern:     regexp.MustCompile(`^[A-Za-z0-9+/]{43}=$`),
+		Replacement: HashToken,
+		Keys:        []string{"source_code_hash", "source_hash", "output_base64sha256"},
+	},
+}
+
+var goldenNormalizer = &snapshot.Normalizer{Rules: GoldenRules}
+
+// UpdatingGolden returns true if UPDATE_GOLDEN is set
+func UpdatingGolden() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(UpdateGoldenEnv))
+	return enabled
+}
+
+// GoldenFile returns the golden file of the stack of testApp, i.e. golden/sqs.tf.json for the sqs app.
+// All working directories of the app (i.e. tf/sqs and tf/sqs-plan) share the golden file.
+func GoldenFile(testApp string) string {
+	return filepath.Join(GoldenDir, testApp+".tf.json")
+}
+
+// Normalized returns the Terraform JSON with asset hashes, temporary paths and versions replaced by tokens
+func (tpl *Template) Normalized() (map[string]any, error) {
+	return normalizeGolden(tpl.template)
+}
+
+// MatchGolden asserts the normalized stack matches goldenFile, see MatchGoldenE
+func (tpl *Template) MatchGolden(t *testing.T, goldenFile string) {
+	if err := tpl.MatchGoldenE(goldenFile); err != nil {
+		t.Error(err)
+	}
+}
+
+// MatchGoldenE compares the normalized stack against goldenFile and returns the structural diff on mismatch.
+// With UPDATE_GOLDEN set, the golden file is (re)generated instead.
+func (tpl *Template) MatchGoldenE(goldenFile string) error {
+	actual, err := tpl.Normalized()
+	if err != nil {
+		return err
+	}
+	if UpdatingGolden() {
+		return writeGolden(goldenFile, actual)
+	}
+	golden, err := FromFile(goldenFile)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("golden file %s does not exist, set %s=true to create it", goldenFile, UpdateGoldenEnv)
+	}
+	if err != nil {
+		return err
+	}
+	expected, err := golden.Normalized()
+	if err != nil {
+		return err
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		return fmt.Errorf("synthesized stack does not match golden file %s, set %s=true to regenerate it (-want +got):\n%s",
+			goldenFile, UpdateGoldenEnv, diff)
+	}
+	return nil
+}
+
+// normalizeGolden applies GoldenRules and replaces provider and cdktf versions
+func normalizeGolden(template map[string]any) (map[string]any, error) {
+	value, err := goldenNormalizer.Normalize(template)
+	if err != nil {
+		return nil, err
+	}
+	normalized, _ := value.(map[string]any)
+	if normalized == nil {
+		return map[string]any{}, nil
+	}
+	// "//": {"metadata": {"version": "0.21.0"}}
+	if metadata, ok := lookup(normalized, "//", "metadata").(map[string]any); ok {
+		replaceIfPresent(metadata, "version")
+	}
+	// "terraform": {"required_providers": {"aws": {"version": "5.100.0"}}}
+	if providers, ok := lookup(normalized, "terraform", "required_providers").(map[string]any); ok {
+		for _, p := range providers {
+			if provider, ok := p.(map[string]any); ok {
+				replaceIfPresent(provider, "version")
+			}
+		}
+	}
+	return normalized, nil
+}
+
+func lookup(m map[string]any, keys ...string) any {
+	var current any = m
+	for _, key := range keys {
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = obj[key]
+	}
+	return current
+}
+
+func replaceIfPresent(m map[string]any, key string) {
+	if _, ok := m[key]; ok {
+		m[key] = VersionToken
+	}
+}
+
+// writeGolden writes value as indented JSON, without escaping the tokens
+func writeGolden(fileName string, value any) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, buf.Bytes(), 0644)
+}
@@ -0,0 +1,88 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadGoldenStack(t *testing.T) *Template {
+	return FromStack(t, filepath.Join("fixtures", "golden", "sqs"))
+}
+
+// updateGolden writes the golden file of the fixture stack into a temporary directory
+func updateGolden(t *testing.T) string {
+	t.Setenv(UpdateGoldenEnv, "true")
+	goldenFile := filepath.Join(t.TempDir(), GoldenDir, "sqs.tf.json")
+	require.NoError(t, loadGoldenStack(t).MatchGoldenE(goldenFile))
+	t.Setenv(UpdateGoldenEnv, "")
+	return goldenFile
+}
+
+func resourceOf(tpl *Template, resourceType, name string) map[string]any {
+	return tpl.ToJSON()["resource"].(map[string]any)[resourceType].(map[string]any)[name].(map[string]any)
+}
+
+func TestGoldenFile(t *testing.T) {
+	assert.Equal(t, filepath.Join("golden", "sqs.tf.json"), GoldenFile("sqs"))
+}
+
+func TestMatchGolden(t *testing.T) {
+	goldenFile := updateGolden(t)
+
+	// volatile values differ between synths
+	tpl := loadGoldenStack(t)
+	resourceOf(tpl, "aws_lambda_function", "Handler_886CB40B")["s3_key"] =
+		"asset.9f8e7d6c5b4a39281706f5e4d3c2b1a09f8e7d6c5b4a39281706f5e4d3c2b1a0.zip"
+	resourceOf(tpl, "aws_s3_object", "Asset_4E3B3B0C")["source"] =
+		"/tmp/go-synth-1112131/cdktf.out/stacks/sqs/assets/FunctionCode/8A1C2E3D4F5A6B7C8D9E0F1A2B3C4D5E/archive.zip"
+	tpl.MatchGolden(t, goldenFile)
+
+	changed, err := FromJSON([]byte(`{
+		"//": {"metadata": {"version": "0.22.0"}},
+		"terraform": {"required_providers": {"aws": {"version": "6.0.0"}}}
+	}`))
+	require.NoError(t, err)
+	expected, err := changed.Normalized()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"//":        map[string]any{"metadata": map[string]any{"version": VersionToken}},
+		"terraform": map[string]any{"required_providers": map[string]any{"aws": map[string]any{"version": VersionToken}}},
+	}, expected)
+}
+
+func TestMatchGolden_Diff(t *testing.T) {
+	goldenFile := updateGolden(t)
+	tpl := loadGoldenStack(t)
+	resourceOf(tpl, "aws_sqs_queue", "Queue_4A7E3555")["kms_master_key_id"] = "alias/custom"
+
+	err := tpl.MatchGoldenE(goldenFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "set UPDATE_GOLDEN=true to regenerate it (-want +got)")
+	assert.Contains(t, err.Error(), `"kms_master_key_id": string("alias/aws/sqs")`)
+	assert.Contains(t, err.Error(), `"kms_master_key_id": string("alias/custom")`)
+}
+
+func TestMatchGolden_Missing(t *testing.T) {
+	t.Setenv(UpdateGoldenEnv, "")
+	err := loadGoldenStack(t).MatchGoldenE(filepath.Join(t.TempDir(), "sqs.tf.json"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not exist, set UPDATE_GOLDEN=true to create it")
+}
+
+func TestMatchGolden_Update(t *testing.T) {
+	goldenFile := updateGolden(t)
+	actual, err := os.ReadFile(goldenFile)
+	require.NoError(t, err)
+	// the tokens are not escaped
+	assert.Contains(t, string(actual), `"s3_key": "asset.<HASH>.zip"`)
+	assert.Contains(t, string(actual), `"source_code_hash": "<HASH>"`)
+	assert.Contains(t, string(actual), `"source": "<TMPDIR>/cdktf.out/stacks/sqs/assets/FunctionCode/<HASH>/archive.zip"`)
+	assert.Contains(t, string(actual), `"version": "<VERSION>"`)
+	assert.Contains(t, string(actual), `"kms_master_key_id": "alias/aws/sqs"`)
+
+	loadGoldenStack(t).MatchGolden(t, goldenFile)
+}
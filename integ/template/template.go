@@ -0,0 +1,184 @@
+// Package template inspects the Terraform JSON (`cdk.tf.json`) of a synthesized stack,
+// modeled on the CDK `Template` assertions. No deploy or AWS credentials are required.
+//
+// Stacks can also be compared against golden files, see MatchGoldenE.
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/terraconstructs/base/integ"
+)
+
+// ref https://github.com/aws/aws-cdk/blob/v2.161.1/packages/aws-cdk-lib/assertions/lib/template.ts
+
+// StackFile is the Terraform JSON file SynthApp writes into the working directory
+const StackFile = "cdk.tf.json"
+
+// Template is a synthesized stack
+type Template struct {
+	template map[string]any
+}
+
+// FromStack loads the stack synthesized into tfWorkingDir by SynthApp. This fails the test on any errors
+func FromStack(t *testing.T, tfWorkingDir string) *Template {
+	tpl, err := FromFile(filepath.Join(tfWorkingDir, StackFile))
+	if err != nil {
+		t.Fatalf("failed to load stack template: %v", err)
+	}
+	return tpl
+}
+
+// FromFile loads the Terraform JSON file at path
+func FromFile(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := FromJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return tpl, nil
+}
+
+// FromJSON parses Terraform JSON
+func FromJSON(data []byte) (*Template, error) {
+	var template map[string]any
+	if err := json.Unmarshal(data, &template); err != nil {
+		return nil, err
+	}
+	return &Template{template: template}, nil
+}
+
+// ToJSON returns the parsed Terraform JSON, i.e. to use with integ.Assert
+func (tpl *Template) ToJSON() map[string]any {
+	return tpl.template
+}
+
+// ResourceCountIs asserts the number of resources of resourceType, i.e. "aws_sqs_queue"
+func (tpl *Template) ResourceCountIs(t *testing.T, resourceType string, count int) {
+	if err := tpl.ResourceCountIsE(resourceType, count); err != nil {
+		t.Error(err)
+	}
+}
+
+// ResourceCountIsE asserts the number of resources of resourceType
+func (tpl *Template) ResourceCountIsE(resourceType string, count int) error {
+	if actual := len(tpl.section("resource", resourceType)); actual != count {
+		return fmt.Errorf("expected %d resources of type %s but found %d", count, resourceType, actual)
+	}
+	return nil
+}
+
+// HasResourceProperties asserts a resource of resourceType has properties matching props.
+// props is either a Matcher or a map which is matched using integ.ObjectLike.
+func (tpl *Template) HasResourceProperties(t *testing.T, resourceType string, props any) {
+	if err := tpl.HasResourcePropertiesE(resourceType, props); err != nil {
+		t.Error(err)
+	}
+}
+
+// HasResourcePropertiesE asserts a resource of resourceType has properties matching props.
+func (tpl *Template) HasResourcePropertiesE(resourceType string, props any) error {
+	return hasMatch("resource", resourceType, tpl.section("resource", resourceType), toMatcher(props))
+}
+
+// HasOutput asserts the output with logicalId has properties matching props, i.e. `{"value": ..., "sensitive": true}`.
+// Use "*" as logicalId to match any output.
+func (tpl *Template) HasOutput(t *testing.T, logicalId string, props any) {
+	if err := tpl.HasOutputE(logicalId, props); err != nil {
+		t.Error(err)
+	}
+}
+
+// HasOutputE asserts the output with logicalId has properties matching props.
+func (tpl *Template) HasOutputE(logicalId string, props any) error {
+	outputs := tpl.section("output")
+	if logicalId != "*" {
+		output, ok := outputs[logicalId]
+		if !ok {
+			return fmt.Errorf("template has no output named %s, outputs: %v", logicalId, sortedKeys(outputs))
+		}
+		outputs = map[string]any{logicalId: output}
+	}
+	return hasMatch("output", logicalId, outputs, toMatcher(props))
+}
+
+// FindResources returns the resources of resourceType matching props by their logical ids, nil props matches all resources.
+func (tpl *Template) FindResources(resourceType string, props any) map[string]map[string]any {
+	var matcher integ.Matcher
+	if props != nil {
+		matcher = toMatcher(props)
+	}
+	result := map[string]map[string]any{}
+	for id, resource := range tpl.section("resource", resourceType) {
+		if matcher != nil && matcher.Test(resource).HasFailed() {
+			continue
+		}
+		if properties, ok := resource.(map[string]any); ok {
+			result[id] = properties
+		}
+	}
+	return result
+}
+
+// section returns the nested object at keys, i.e. resource.aws_sqs_queue
+func (tpl *Template) section(keys ...string) map[string]any {
+	current := tpl.template
+	for _, key := range keys {
+		next, ok := current[key].(map[string]any)
+		if !ok {
+			return map[string]any{}
+		}
+		current = next
+	}
+	return current
+}
+
+// hasMatch reports the closest candidate if none of the candidates match
+func hasMatch(kind, name string, candidates map[string]any, matcher integ.Matcher) error {
+	if len(candidates) == 0 {
+		return fmt.Errorf("template has no %s of type %s", kind, name)
+	}
+	var closestId string
+	var closest *integ.MatchResult
+	for _, id := range sortedKeys(candidates) {
+		result := matcher.Test(candidates[id])
+		if !result.HasFailed() {
+			return nil
+		}
+		if closest == nil || result.FailCount() < closest.FailCount() {
+			closestId, closest = id, result
+		}
+	}
+	return fmt.Errorf("template has %d %s(s) matching %s, but none match as expected.\nThe closest result is %s:\n  %s",
+		len(candidates), kind, name, closestId, strings.Join(closest.Failures(), "\n  "))
+}
+
+// toMatcher wraps maps in ObjectLike, other values are matched exactly
+func toMatcher(props any) integ.Matcher {
+	switch p := props.(type) {
+	case integ.Matcher:
+		return p
+	case map[string]any:
+		return integ.ObjectLike(p)
+	default:
+		return integ.Exact(props)
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,101 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/terraconstructs/base/integ"
+)
+
+func loadFixture(t *testing.T) *Template {
+	tpl, err := FromFile("fixtures/cdk.tf.json")
+	require.NoError(t, err)
+	return tpl
+}
+
+func TestFromStack(t *testing.T) {
+	tpl := FromStack(t, "fixtures")
+	assert.Contains(t, tpl.ToJSON(), "resource")
+}
+
+func TestFromJSON_Invalid(t *testing.T) {
+	_, err := FromJSON([]byte("{"))
+	require.Error(t, err)
+}
+
+func TestResourceCountIs(t *testing.T) {
+	tpl := loadFixture(t)
+	tpl.ResourceCountIs(t, "aws_sqs_queue", 3)
+	tpl.ResourceCountIs(t, "aws_kms_key", 0)
+
+	err := tpl.ResourceCountIsE("aws_iam_role", 2)
+	require.Error(t, err)
+	assert.Equal(t, "expected 2 resources of type aws_iam_role but found 1", err.Error())
+}
+
+func TestHasResourceProperties(t *testing.T) {
+	tpl := loadFixture(t)
+	tpl.HasResourceProperties(t, "aws_sqs_queue", map[string]any{
+		"kms_master_key_id": "alias/aws/sqs",
+		"redrive_policy": integ.SerializedJSON(map[string]any{
+			"deadLetterTargetArn": integ.StringLikeRegexp(`aws_sqs_queue\.DeadLetterQueue`),
+			"maxReceiveCount":     5,
+		}),
+	})
+	tpl.HasResourceProperties(t, "aws_sqs_queue", integ.ObjectEquals(map[string]any{
+		"name_prefix":             "123e4567-e89b-sqs-DeadLetterQueue",
+		"sqs_managed_sse_enabled": true,
+	}))
+	tpl.HasResourceProperties(t, "aws_sqs_queue", map[string]any{
+		"fifo_queue":          true,
+		"content_based_dedup": integ.Absent(),
+	})
+}
+
+func TestHasResourceProperties_ClosestMatch(t *testing.T) {
+	tpl := loadFixture(t)
+	err := tpl.HasResourcePropertiesE("aws_sqs_queue", map[string]any{
+		"fifo_queue":        true,
+		"kms_master_key_id": "alias/aws/sqs",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "template has 3 resource(s) matching aws_sqs_queue, but none match as expected")
+	// FifoQueue and Queue fail one key each, the first by logical id is reported
+	assert.Contains(t, err.Error(), "The closest result is FifoQueue_E5FF7493")
+	assert.Contains(t, err.Error(), "kms_master_key_id")
+
+	err = tpl.HasResourcePropertiesE("aws_kms_key", map[string]any{})
+	require.Error(t, err)
+	assert.Equal(t, "template has no resource of type aws_kms_key", err.Error())
+}
+
+func TestHasOutput(t *testing.T) {
+	tpl := loadFixture(t)
+	tpl.HasOutput(t, "QueueUrl", map[string]any{
+		"value": integ.StringLikeRegexp(`^\$\{aws_sqs_queue\.Queue_\w+\.url\}$`),
+	})
+	tpl.HasOutput(t, "*", map[string]any{
+		"value": integ.StringLikeRegexp(`aws_iam_role`),
+	})
+
+	err := tpl.HasOutputE("TopicArn", map[string]any{})
+	require.Error(t, err)
+	assert.Equal(t, "template has no output named TopicArn, outputs: [DlqUrl QueueUrl RoleArn]", err.Error())
+
+	err = tpl.HasOutputE("RoleArn", map[string]any{"sensitive": true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "The closest result is RoleArn")
+}
+
+func TestFindResources(t *testing.T) {
+	tpl := loadFixture(t)
+	assert.Len(t, tpl.FindResources("aws_sqs_queue", nil), 3)
+
+	fifo := tpl.FindResources("aws_sqs_queue", map[string]any{"fifo_queue": true})
+	require.Len(t, fifo, 1)
+	assert.Equal(t, "123e4567-e89b-sqs-FifoQueue", fifo["FifoQueue_E5FF7493"]["name_prefix"])
+
+	assert.Empty(t, tpl.FindResources("aws_sqs_queue", map[string]any{"fifo_queue": false}))
+	assert.Empty(t, tpl.FindResources("aws_kms_key", nil))
+}
@@ -2,10 +2,13 @@ package integ
 
 import (
 	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
-	"github.com/jmespath/go-jmespath"
 
 	"github.com/stretchr/testify/require"
 )
@@ -33,19 +36,123 @@ func TerraformOutputJMESAny(t *testing.T, terraformOptions *terraform.Options, q
 	return result
 }
 
-// TerraformOutputJMESAnyE calls terraform output and searches values using JMESPath.
+// TerraformOutputJMESAnyE searches values using JMESPath, terraform output is called once per TerraformDir (see LoadOutputs).
 func TerraformOutputJMESAnyE(t *testing.T, terraformOptions *terraform.Options, query string) (interface{}, error) {
-	p, err := jmespath.Compile(query)
-	if err != nil {
-		return nil, err
+	return LoadOutputs(terraformOptions).QueryE(t, query)
+}
+
+var (
+	outputsCacheMu sync.Mutex
+	outputsCache   = map[outputsCacheKey]*Outputs{}
+)
+
+// outputsCacheKey identifies the terraform output calls of a TerraformDir, the binary and its environment
+// may change the outputs, i.e. with another workspace or state backend
+type outputsCacheKey struct {
+	dir    string
+	binary string
+	env    string
+}
+
+// LoadOutputs returns the Outputs cache of the TerraformDir, outputs are fetched once on first use.
+// Callers using another binary or other env vars for the same directory get their own cache.
+// Call ReloadOutputs after changing state outside of DeployUsingTerraform, i.e. with a `-replace` apply.
+func LoadOutputs(terraformOptions *terraform.Options) *Outputs {
+	key := newOutputsCacheKey(terraformOptions)
+	outputsCacheMu.Lock()
+	defer outputsCacheMu.Unlock()
+	if o, ok := outputsCache[key]; ok {
+		return o
 	}
-	outputMap, err := terraform.OutputForKeysE(t, terraformOptions, nil)
-	if err != nil {
-		return nil, err
+	// the options may be changed by the caller after this call
+	options := *terraformOptions
+	o := NewOutputs(func(t *testing.T) (map[string]any, error) {
+		return terraform.OutputForKeysE(t, &options, nil)
+	})
+	outputsCache[key] = o
+	return o
+}
+
+// ReloadOutputs drops the cached outputs of terraformDir, they are fetched again on next use
+func ReloadOutputs(terraformDir string) {
+	dir := absTerraformDir(terraformDir)
+	outputsCacheMu.Lock()
+	var outputs []*Outputs
+	for key, o := range outputsCache {
+		if key.dir == dir {
+			outputs = append(outputs, o)
+		}
+	}
+	outputsCacheMu.Unlock()
+	for _, o := range outputs {
+		o.Reload()
+	}
+}
+
+func newOutputsCacheKey(terraformOptions *terraform.Options) outputsCacheKey {
+	env := make([]string, 0, len(terraformOptions.EnvVars))
+	for k, v := range terraformOptions.EnvVars {
+		env = append(env, k+"="+v)
 	}
-	value, err := p.Search(outputMap)
+	sort.Strings(env)
+	return outputsCacheKey{
+		dir:    absTerraformDir(terraformOptions.TerraformDir),
+		binary: terraformOptions.TerraformBinary,
+		env:    strings.Join(env, "\x00"),
+	}
+}
+
+func absTerraformDir(terraformDir string) string {
+	if abs, err := filepath.Abs(terraformDir); err == nil {
+		return abs
+	}
+	return filepath.Clean(terraformDir)
+}
+
+// RunAssertionManifest loads the manifest at path and evaluates every run as a subtest.
+// Terraform outputs are read once and SDK calls are made in awsRegion.
+func RunAssertionManifest(t *testing.T, path string, terraformOptions *terraform.Options, awsRegion string) {
+	outputs, err := LoadOutputs(terraformOptions).ValuesE(t)
+	require.NoError(t, err, "Failed to read Terraform outputs")
+	manifest, err := LoadAssertionManifestE(path, outputs)
+	require.NoError(t, err)
+	runs, err := manifest.Runs()
+	require.NoError(t, err, "Invalid assertion manifest %s", path)
+
+	for _, run := range runs {
+		t.Run(run.Name, func(t *testing.T) {
+			var input any
+			if run.SdkCall != nil {
+				input, err = InvokeSdkCallE(t, awsRegion, *run.SdkCall)
+				require.NoError(t, err, "Failed to invoke %s %s", run.SdkCall.Service, run.SdkCall.Api)
+			} else {
+				input, err = SearchJMESPath(run.Output, outputs)
+				require.NoError(t, err, "Failed to search Terraform outputs for %q", run.Output)
+			}
+			Assert(t, input, run.Assertions)
+		})
+	}
+}
+
+// DecodeOutputs fills dst, a pointer to a struct with `jmes` tagged fields, from a single terraform output call.
+// This fails the test listing all missing or mistyped fields, see Outputs.DecodeE.
+func DecodeOutputs(t *testing.T, terraformOptions *terraform.Options, dst any) {
+	err := LoadOutputs(terraformOptions).DecodeE(t, dst)
+	require.NoError(t, err, "Failed to decode Terraform outputs into %T", dst)
+}
+
+// LoadState reads the current state of the TerraformDir using `show -json`. This fails the test on any errors
+func LoadState(t *testing.T, terraformOptions *terraform.Options) *State {
+	state, err := LoadStateE(t, terraformOptions)
+	require.NoError(t, err, "Failed to read Terraform state")
+	return state
+}
+
+// LoadStateE reads the current state of the TerraformDir using `show -json`
+func LoadStateE(t *testing.T, terraformOptions *terraform.Options) (*State, error) {
+	out, err := terraform.RunTerraformCommandAndGetStdoutE(t, terraformOptions, "show", "-no-color", "-json")
 	if err != nil {
 		return nil, err
 	}
-	return value, nil
+	return ParseStateE([]byte(out))
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gruntwork-io/terratest/modules/files"
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -82,3 +83,16 @@ func TestTerraformOutputJMES_Generics(t *testing.T) {
 	require.Equal(t, expectedValue, result,
 		"JMESPath query %q should return %v, got %v", query, expectedValue, result)
 }
+
+func TestLoadOutputs_Options(t *testing.T) {
+	dir := t.TempDir()
+	tofu := &terraform.Options{TerraformDir: dir, TerraformBinary: "tofu"}
+	outputs := LoadOutputs(tofu)
+	assert.Same(t, outputs, LoadOutputs(&terraform.Options{TerraformDir: dir + "/", TerraformBinary: "tofu"}))
+
+	// other callers of the directory do not share the outputs of the first one
+	assert.NotSame(t, outputs, LoadOutputs(&terraform.Options{TerraformDir: dir, TerraformBinary: "terraform"}))
+	withEnv := &terraform.Options{TerraformDir: dir, TerraformBinary: "tofu", EnvVars: map[string]string{"TF_WORKSPACE": "upgrade"}}
+	assert.NotSame(t, outputs, LoadOutputs(withEnv))
+	assert.Same(t, LoadOutputs(withEnv), LoadOutputs(withEnv))
+}